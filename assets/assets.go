@@ -0,0 +1,234 @@
+// Package assets stores the images, fonts and stylesheets of the reports and resolves their asset:// references.
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxSizeBytes is the largest asset that can be uploaded when the config doesn't set one.
+const DefaultMaxSizeBytes = 5 * 1024 * 1024
+
+// maxCacheBytes bounds the memory used by the cached data URIs.
+const maxCacheBytes = 64 * 1024 * 1024
+
+// stylesheetNestingLimit is how deep stylesheets referencing stylesheets are resolved.
+const stylesheetNestingLimit = 3
+
+// contentTypes lists the accepted asset extensions.
+var contentTypes = map[string]string{
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".svg":   "image/svg+xml",
+	".webp":  "image/webp",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".css":   "text/css",
+}
+
+// nameRegex matches the valid asset names, e.g. logo.png or fonts/inter.woff2.
+var nameRegex = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._/-]*$`)
+
+// referenceRegex matches the asset:// references of a document.
+var referenceRegex = regexp.MustCompile(`asset://([A-Za-z0-9._/-]+)`)
+
+// cachedDataUri is the data URI of an asset, valid as long as the asset has the same checksum.
+type cachedDataUri struct {
+	checksum string
+	dataUri  string
+}
+
+var cacheMutex sync.Mutex
+var cache = map[string]cachedDataUri{}
+var cacheBytes = 0
+
+// ValidateName checks that the name can be used for an asset and returns its content type.
+func ValidateName(name string) (string, safego.Option[error]) {
+	if !nameRegex.MatchString(name) || strings.Contains(name, "..") || strings.HasSuffix(name, "/") {
+		return "", safego.Some(fmt.Errorf("invalid asset name %q, use letters, digits, '.', '_', '-' and '/'", name))
+	}
+
+	contentType, ok := contentTypes[strings.ToLower(path.Ext(name))]
+	if !ok {
+		return "", safego.Some(fmt.Errorf("unsupported asset type %s, expected an image (png, jpg, gif, svg, webp), a font (woff, woff2, ttf, otf) or a stylesheet (css)", path.Ext(name)))
+	}
+
+	return contentType, safego.None[error]()
+}
+
+// NewAsset returns the asset of the report, or a global one if reportName is empty, holding the data.
+// It fails if the name is invalid or the data is larger than maxSizeBytes, or DefaultMaxSizeBytes if it's 0.
+func NewAsset(reportName string, name string, data []byte, maxSizeBytes int64) (types.Asset, safego.Option[error]) {
+	contentType, errOpt := ValidateName(name)
+	if errOpt.IsSome() {
+		return types.Asset{}, errOpt
+	}
+
+	if maxSizeBytes <= 0 {
+		maxSizeBytes = DefaultMaxSizeBytes
+	}
+	if int64(len(data)) > maxSizeBytes {
+		return types.Asset{}, safego.Some(fmt.Errorf("the asset is %d bytes, more than the limit of %d bytes", len(data), maxSizeBytes))
+	}
+	if len(data) == 0 {
+		return types.Asset{}, safego.Some(fmt.Errorf("the asset is empty"))
+	}
+
+	checksum := sha256.Sum256(data)
+	now := utils.GetTimestamp()
+
+	return types.Asset{
+		ReportName:  reportName,
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		Data:        data,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, safego.None[error]()
+}
+
+// ResolveReferences replaces the asset://name references of a document with data URIs, so that the document doesn't
+// depend on anything wkhtmltopdf would have to fetch. A report sees its own assets and the global ones, its own first.
+// The asset:// references of the stylesheets are resolved too.
+func ResolveReferences(internalDbConn *datasource.DataSource, reportName string, document string) (string, safego.Option[error]) {
+	return resolveReferences(internalDbConn, reportName, document, 0)
+}
+
+func resolveReferences(internalDbConn *datasource.DataSource, reportName string, document string, depth int) (string, safego.Option[error]) {
+	var errOpt safego.Option[error]
+
+	resolved := referenceRegex.ReplaceAllStringFunc(document, func(reference string) string {
+		if errOpt.IsSome() {
+			return reference
+		}
+
+		name := strings.TrimPrefix(reference, "asset://")
+		var dataUri string
+		dataUri, errOpt = dataUriOf(internalDbConn, reportName, name, depth)
+
+		return dataUri
+	})
+
+	return resolved, errOpt
+}
+
+// dataUriOf returns the data URI of the asset the report sees under the name.
+func dataUriOf(internalDbConn *datasource.DataSource, reportName string, name string, depth int) (string, safego.Option[error]) {
+	assetOpt, errOpt := internalDb.FindAsset(internalDbConn, reportName, name)
+	if errOpt.IsSome() {
+		return "", errOpt
+	}
+	if assetOpt.IsNone() {
+		return "", safego.Some(fmt.Errorf("asset %s was not found", name))
+	}
+	asset := assetOpt.Unwrap()
+
+	cacheKey := asset.ReportName + "\x00" + asset.Name
+	cacheMutex.Lock()
+	cached, ok := cache[cacheKey]
+	cacheMutex.Unlock()
+	if ok && cached.checksum == asset.Checksum {
+		return cached.dataUri, safego.None[error]()
+	}
+
+	assetOpt, errOpt = internalDb.GetAsset(internalDbConn, asset.ReportName, asset.Name)
+	if errOpt.IsSome() {
+		return "", errOpt
+	}
+	if assetOpt.IsNone() {
+		return "", safego.Some(fmt.Errorf("asset %s was not found", name))
+	}
+	asset = assetOpt.Unwrap()
+
+	data := asset.Data
+	if asset.ContentType == "text/css" {
+		if depth >= stylesheetNestingLimit {
+			return "", safego.Some(fmt.Errorf("the stylesheet %s references stylesheets more than %d levels deep", name, stylesheetNestingLimit))
+		}
+
+		stylesheet, errOpt := resolveReferences(internalDbConn, reportName, string(data), depth+1)
+		if errOpt.IsSome() {
+			return "", safego.Some(fmt.Errorf("in the stylesheet %s: %v", name, errOpt.Unwrap()))
+		}
+		data = []byte(stylesheet)
+	}
+
+	dataUri := "data:" + asset.ContentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+
+	// Stylesheets aren't cached, their data URI depends on the assets they reference.
+	if asset.ContentType != "text/css" {
+		cacheMutex.Lock()
+		if cacheBytes+len(dataUri) > maxCacheBytes {
+			cache = map[string]cachedDataUri{}
+			cacheBytes = 0
+		}
+		cacheBytes -= len(cache[cacheKey].dataUri)
+		cache[cacheKey] = cachedDataUri{checksum: asset.Checksum, dataUri: dataUri}
+		cacheBytes += len(dataUri)
+		cacheMutex.Unlock()
+	}
+
+	return dataUri, safego.None[error]()
+}
+
+// ReferencesOf returns the names of the assets the asset:// references of a document point to, without resolving them.
+func ReferencesOf(document string) []string {
+	names := []string{}
+	for _, match := range referenceRegex.FindAllStringSubmatch(document, -1) {
+		if !utils.ContainsString(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+
+	return names
+}
+
+// RevisionOf returns the checksum of the asset the report sees under the name, and of the assets its stylesheet
+// references. It changes whenever the data URI of the reference would, and is empty if there is no such asset.
+func RevisionOf(internalDbConn *datasource.DataSource, reportName string, name string) (string, safego.Option[error]) {
+	return revisionOf(internalDbConn, reportName, name, 0)
+}
+
+func revisionOf(internalDbConn *datasource.DataSource, reportName string, name string, depth int) (string, safego.Option[error]) {
+	assetOpt, errOpt := internalDb.FindAsset(internalDbConn, reportName, name)
+	if errOpt.IsSome() || assetOpt.IsNone() {
+		return "", errOpt
+	}
+	asset := assetOpt.Unwrap()
+
+	revision := asset.ReportName + "/" + asset.Name + ":" + asset.Checksum
+	if asset.ContentType != "text/css" || depth >= stylesheetNestingLimit {
+		return revision, safego.None[error]()
+	}
+
+	assetOpt, errOpt = internalDb.GetAsset(internalDbConn, asset.ReportName, asset.Name)
+	if errOpt.IsSome() || assetOpt.IsNone() {
+		return revision, errOpt
+	}
+	for _, reference := range ReferencesOf(string(assetOpt.Unwrap().Data)) {
+		referenceRevision, errOpt := revisionOf(internalDbConn, reportName, reference, depth+1)
+		if errOpt.IsSome() {
+			return "", errOpt
+		}
+		revision += "," + referenceRevision
+	}
+
+	return revision, safego.None[error]()
+}
@@ -0,0 +1,365 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A bundle is a directory (or a zip archive of it) holding a manifest and the templates of every report:
+//
+//	manifest.yaml (or manifest.json)
+//	<report-name>/body.html
+//	<report-name>/header.html (optional)
+//	<report-name>/footer.html (optional)
+const (
+	ManifestYamlFile = "manifest.yaml"
+	ManifestJsonFile = "manifest.json"
+	BodyFile         = "body.html"
+	HeaderFile       = "header.html"
+	FooterFile       = "footer.html"
+	manifestVersion  = 1
+)
+
+// Manifest lists the reports of a bundle along with everything but their templates.
+type Manifest struct {
+	Version int              `json:"version" yaml:"version"`
+	Reports []ManifestReport `json:"reports" yaml:"reports"`
+}
+
+type ManifestReport struct {
+	Name            string                  `json:"name" yaml:"name"`
+	Title           string                  `json:"title" yaml:"title"`
+	Description     string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	PrintingOptions *types.PrintingOptions  `json:"printingOptions,omitempty" yaml:"printingOptions,omitempty"`
+	Parameters      []types.ReportParameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// Files maps slash-separated paths inside a bundle to their content.
+type Files map[string][]byte
+
+// Build serializes the reports into bundle files. The manifest format is either "yaml" or "json".
+func Build(reports []types.Report, manifestFormat string) (Files, safego.Option[error]) {
+	files := Files{}
+	manifest := Manifest{Version: manifestVersion, Reports: []ManifestReport{}}
+
+	for _, report := range reports {
+		errOpt := ValidateReportName(report.Name)
+		if errOpt.IsSome() {
+			return Files{}, errOpt
+		}
+
+		manifest.Reports = append(manifest.Reports, ManifestReport{
+			Name:            report.Name,
+			Title:           report.Title,
+			Description:     report.Description,
+			PrintingOptions: report.PrintingOptions,
+			Parameters:      report.Parameters,
+		})
+
+		files[report.Name+"/"+BodyFile] = []byte(report.Body)
+		if report.Header != "" {
+			files[report.Name+"/"+HeaderFile] = []byte(report.Header)
+		}
+		if report.Footer != "" {
+			files[report.Name+"/"+FooterFile] = []byte(report.Footer)
+		}
+	}
+
+	switch manifestFormat {
+	case "yaml", "":
+		encoded, err := yaml.Marshal(manifest)
+		if err != nil {
+			return Files{}, safego.Some(err)
+		}
+		files[ManifestYamlFile] = encoded
+	case "json":
+		encoded, err := json.MarshalIndent(manifest, "", "\t")
+		if err != nil {
+			return Files{}, safego.Some(err)
+		}
+		files[ManifestJsonFile] = encoded
+	default:
+		return Files{}, safego.Some(errors.New("unsupported manifest format: " + manifestFormat))
+	}
+
+	return files, safego.None[error]()
+}
+
+// Parse reads the reports out of bundle files.
+func Parse(files Files) ([]types.Report, safego.Option[error]) {
+	manifest := Manifest{}
+
+	if content, ok := files[ManifestYamlFile]; ok {
+		err := yaml.Unmarshal(content, &manifest)
+		if err != nil {
+			return nil, safego.Some(fmt.Errorf("invalid %s: %v", ManifestYamlFile, err))
+		}
+	} else if content, ok := files[ManifestJsonFile]; ok {
+		err := json.Unmarshal(content, &manifest)
+		if err != nil {
+			return nil, safego.Some(fmt.Errorf("invalid %s: %v", ManifestJsonFile, err))
+		}
+	} else {
+		return nil, safego.Some(errors.New("the bundle has no manifest.yaml or manifest.json"))
+	}
+
+	if manifest.Version > manifestVersion {
+		return nil, safego.Some(fmt.Errorf("the bundle manifest version %d is newer than the supported version %d", manifest.Version, manifestVersion))
+	}
+
+	reports := []types.Report{}
+	seenNames := map[string]bool{}
+	for _, manifestReport := range manifest.Reports {
+		errOpt := ValidateReportName(manifestReport.Name)
+		if errOpt.IsSome() {
+			return nil, errOpt
+		}
+		if seenNames[manifestReport.Name] {
+			return nil, safego.Some(fmt.Errorf("report %s is listed twice in the manifest", manifestReport.Name))
+		}
+		seenNames[manifestReport.Name] = true
+
+		body, ok := files[manifestReport.Name+"/"+BodyFile]
+		if !ok {
+			return nil, safego.Some(fmt.Errorf("report %s has no %s", manifestReport.Name, BodyFile))
+		}
+
+		reports = append(reports, types.Report{
+			Name:            manifestReport.Name,
+			Title:           manifestReport.Title,
+			Description:     manifestReport.Description,
+			Body:            string(body),
+			Header:          string(files[manifestReport.Name+"/"+HeaderFile]),
+			Footer:          string(files[manifestReport.Name+"/"+FooterFile]),
+			PrintingOptions: manifestReport.PrintingOptions,
+			Parameters:      manifestReport.Parameters,
+		})
+	}
+
+	return reports, safego.None[error]()
+}
+
+// ValidateReportName rejects the names that can't be used as a bundle directory.
+func ValidateReportName(name string) safego.Option[error] {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\:*?"<>|`) {
+		return safego.Some(fmt.Errorf("report name %q can't be used as a directory name", name))
+	}
+
+	return safego.None[error]()
+}
+
+// Write writes the files to a directory, or to a zip archive if the path ends with .zip.
+func Write(files Files, targetPath string) safego.Option[error] {
+	if strings.HasSuffix(strings.ToLower(targetPath), ".zip") {
+		data, errOpt := ToZip(files)
+		if errOpt.IsSome() {
+			return errOpt
+		}
+
+		err := os.WriteFile(targetPath, data, 0644)
+		if err != nil {
+			return safego.Some(err)
+		}
+
+		return safego.None[error]()
+	}
+
+	for _, name := range sortedNames(files) {
+		filePath := filepath.Join(targetPath, filepath.FromSlash(name))
+
+		err := os.MkdirAll(filepath.Dir(filePath), 0755)
+		if err != nil {
+			return safego.Some(err)
+		}
+
+		err = os.WriteFile(filePath, files[name], 0644)
+		if err != nil {
+			return safego.Some(err)
+		}
+	}
+
+	return safego.None[error]()
+}
+
+// Read reads the files of a bundle directory, or of a zip archive if the path ends with .zip.
+func Read(sourcePath string) (Files, safego.Option[error]) {
+	if strings.HasSuffix(strings.ToLower(sourcePath), ".zip") {
+		data, err := os.ReadFile(sourcePath)
+		if err != nil {
+			return Files{}, safego.Some(err)
+		}
+
+		return FromZip(data)
+	}
+
+	files := Files{}
+	err := walkFiles(sourcePath, func(relativePath string, filePath string, info fs.FileInfo) error {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		files[relativePath] = content
+
+		return nil
+	})
+	if err != nil {
+		return Files{}, safego.Some(err)
+	}
+
+	return files, safego.None[error]()
+}
+
+// walkFiles calls walkFn with the slash-separated relative path of every file of a directory. Unlike
+// filepath.WalkDir, it follows the symlinks, including those to directories.
+// The entries whose name starts with ".." are skipped: Kubernetes mounts a ConfigMap as symlinks into a ..data
+// directory, itself a symlink to a ..<timestamp> directory, which would otherwise be read more than once.
+func walkFiles(root string, walkFn func(relativePath string, filePath string, info fs.FileInfo) error) error {
+	return walkDirectory(root, "", map[string]bool{}, walkFn)
+}
+
+// walkDirectory walks a directory of walkFiles. visitedDirectories holds the resolved paths of the directories walked
+// so far, so that a symlink loop or two symlinks to the same directory don't read it again.
+func walkDirectory(directoryPath string, relativeDirectory string, visitedDirectories map[string]bool, walkFn func(relativePath string, filePath string, info fs.FileInfo) error) error {
+	resolvedPath, err := filepath.EvalSymlinks(directoryPath)
+	if err != nil {
+		return err
+	}
+	if visitedDirectories[resolvedPath] {
+		return nil
+	}
+	visitedDirectories[resolvedPath] = true
+
+	entries, err := os.ReadDir(directoryPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "..") {
+			continue
+		}
+
+		filePath := filepath.Join(directoryPath, entry.Name())
+		relativePath := path.Join(relativeDirectory, entry.Name())
+
+		// Stat rather than the entry itself, to follow the symlinks.
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			err = walkDirectory(filePath, relativePath, visitedDirectories, walkFn)
+		} else {
+			err = walkFn(relativePath, filePath, info)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ToZip archives the files.
+func ToZip(files Files) ([]byte, safego.Option[error]) {
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	now := time.Now()
+
+	for _, name := range sortedNames(files) {
+		fileWriter, err := writer.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: now,
+		})
+		if err != nil {
+			return nil, safego.Some(err)
+		}
+
+		_, err = fileWriter.Write(files[name])
+		if err != nil {
+			return nil, safego.Some(err)
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, safego.Some(err)
+	}
+
+	return buffer.Bytes(), safego.None[error]()
+}
+
+// FromZip extracts the files of a zip archive. Archives wrapping the bundle in a single top-level directory
+// (as produced by zipping the directory itself) are supported.
+func FromZip(data []byte) (Files, safego.Option[error]) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Files{}, safego.Some(err)
+	}
+
+	files := Files{}
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		fileReader, err := file.Open()
+		if err != nil {
+			return Files{}, safego.Some(err)
+		}
+		content, err := io.ReadAll(fileReader)
+		fileReader.Close()
+		if err != nil {
+			return Files{}, safego.Some(err)
+		}
+
+		files[path.Clean(file.Name)] = content
+	}
+
+	// Strip a single top-level directory holding the manifest.
+	_, hasYamlManifest := files[ManifestYamlFile]
+	_, hasJsonManifest := files[ManifestJsonFile]
+	if hasYamlManifest || hasJsonManifest {
+		return files, safego.None[error]()
+	}
+	for name := range files {
+		directory, base := path.Split(name)
+		if (base == ManifestYamlFile || base == ManifestJsonFile) && directory != "" && strings.Count(directory, "/") == 1 {
+			strippedFiles := Files{}
+			for name, content := range files {
+				if strings.HasPrefix(name, directory) {
+					strippedFiles[strings.TrimPrefix(name, directory)] = content
+				}
+			}
+			return strippedFiles, safego.None[error]()
+		}
+	}
+
+	return files, safego.None[error]()
+}
+
+func sortedNames(files Files) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
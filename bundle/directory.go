@@ -0,0 +1,169 @@
+package bundle
+
+import (
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Directory serves the reports of a bundle directory and reloads them when the files change.
+// Its reports are read-only: they can only be changed by editing the files.
+type Directory struct {
+	path        string
+	mutex       sync.RWMutex
+	reports     map[string]types.Report
+	fingerprint string
+	// failedFingerprint is the fingerprint of the last invalid state, so it is reported only once.
+	failedFingerprint string
+}
+
+// LoadDirectory loads the reports of a bundle directory.
+func LoadDirectory(path string) (*Directory, safego.Option[error]) {
+	directory := &Directory{
+		path:    path,
+		reports: map[string]types.Report{},
+	}
+
+	errOpt := directory.Reload()
+	if errOpt.IsSome() {
+		return nil, errOpt
+	}
+
+	return directory, safego.None[error]()
+}
+
+// Get returns the report with the given name, or None if the directory doesn't have it.
+func (self *Directory) Get(name string) safego.Option[types.Report] {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	report, ok := self.reports[name]
+	if !ok {
+		return safego.None[types.Report]()
+	}
+
+	return safego.Some(report)
+}
+
+// List returns every report of the directory sorted by name.
+func (self *Directory) List() []types.Report {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	reports := make([]types.Report, 0, len(self.reports))
+	for _, report := range self.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Name < reports[j].Name
+	})
+
+	return reports
+}
+
+// Reload reads the directory again if its files changed. The reports are swapped all at once, and only if the
+// whole bundle is valid, so a half-written change never takes effect.
+func (self *Directory) Reload() safego.Option[error] {
+	fingerprint, errOpt := self.computeFingerprint()
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	self.mutex.RLock()
+	unchanged := fingerprint == self.fingerprint || fingerprint == self.failedFingerprint
+	self.mutex.RUnlock()
+	if unchanged {
+		return safego.None[error]()
+	}
+
+	reports, errOpt := self.readReports()
+	if errOpt.IsSome() {
+		self.mutex.Lock()
+		self.failedFingerprint = fingerprint
+		self.mutex.Unlock()
+
+		return errOpt
+	}
+
+	reportsByName := make(map[string]types.Report, len(reports))
+	for _, report := range reports {
+		report.ReadOnly = true
+		reportsByName[report.Name] = report
+	}
+
+	self.mutex.Lock()
+	self.reports = reportsByName
+	self.fingerprint = fingerprint
+	self.failedFingerprint = ""
+	self.mutex.Unlock()
+
+	return safego.None[error]()
+}
+
+// readReports reads and parses the bundle files of the directory.
+func (self *Directory) readReports() ([]types.Report, safego.Option[error]) {
+	files, errOpt := Read(self.path)
+	if errOpt.IsSome() {
+		return nil, errOpt
+	}
+
+	return Parse(files)
+}
+
+// Watch reloads the directory every interval until the stop channel is closed.
+// Polling is used rather than file system events because it also works with network and container volumes
+// (e.g. Kubernetes ConfigMaps, which swap a symlink).
+func (self *Directory) Watch(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			previousFingerprint := self.currentFingerprint()
+
+			errOpt := self.Reload()
+			if errOpt.IsSome() {
+				log.Printf("error while reloading the reports directory, keeping the previous reports: %v", errOpt.Unwrap())
+			} else if self.currentFingerprint() != previousFingerprint {
+				log.Printf("reloaded %d report(s) from %s", len(self.List()), self.path)
+			}
+		}
+	}
+}
+
+func (self *Directory) currentFingerprint() string {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	return self.fingerprint
+}
+
+// computeFingerprint summarizes the path, size and modification time of every file of the directory.
+func (self *Directory) computeFingerprint() (string, safego.Option[error]) {
+	builder := strings.Builder{}
+
+	// The symlinks are followed, so swapped targets are noticed.
+	err := walkFiles(self.path, func(relativePath string, filePath string, info fs.FileInfo) error {
+		builder.WriteString(fmt.Sprintf("%s:%d:%d\n", relativePath, info.Size(), info.ModTime().UnixNano()))
+
+		return nil
+	})
+	if err != nil {
+		return "", safego.Some(err)
+	}
+
+	return builder.String(), safego.None[error]()
+}
@@ -0,0 +1,148 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const configMapManifest = `version: 1
+reports:
+  - name: invoice
+    title: Invoice
+  - name: receipt
+    title: Receipt
+`
+
+// writeConfigMap lays the files out the way Kubernetes mounts a ConfigMap: the files are written to a ..<timestamp>
+// directory, ..data links to it and every top-level entry links into ..data. Writing a new version swaps ..data.
+func writeConfigMap(t *testing.T, mountPath string, timestamp string, files map[string]string) {
+	t.Helper()
+
+	versionDirectory := ".." + timestamp
+	for name, content := range files {
+		filePath := filepath.Join(mountPath, versionDirectory, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(filePath), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filePath, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Kubernetes swaps ..data atomically by renaming a new symlink over it.
+	err := os.Symlink(versionDirectory, filepath.Join(mountPath, "..data_tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(filepath.Join(mountPath, "..data_tmp"), filepath.Join(mountPath, "..data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(mountPath, versionDirectory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		linkPath := filepath.Join(mountPath, entry.Name())
+		if _, err := os.Lstat(linkPath); err == nil {
+			continue
+		}
+		err = os.Symlink(filepath.Join("..data", entry.Name()), linkPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadConfigMap(t *testing.T) {
+	mountPath := t.TempDir()
+	writeConfigMap(t, mountPath, "2024_01_01_00_00_00.000000001", map[string]string{
+		ManifestYamlFile:      configMapManifest,
+		"invoice/" + BodyFile: "<p>Invoice</p>",
+		"receipt/" + BodyFile: "<p>Receipt</p>",
+	})
+
+	files, errOpt := Read(mountPath)
+	if errOpt.IsSome() {
+		t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+	}
+
+	want := []string{"invoice/body.html", ManifestYamlFile, "receipt/body.html"}
+	got := sortedNames(files)
+	if len(got) != len(want) {
+		t.Fatalf("got files %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got files %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDirectoryConfigMap(t *testing.T) {
+	mountPath := t.TempDir()
+	writeConfigMap(t, mountPath, "2024_01_01_00_00_00.000000001", map[string]string{
+		ManifestYamlFile:      configMapManifest,
+		"invoice/" + BodyFile: "<p>Invoice</p>",
+		"receipt/" + BodyFile: "<p>Receipt</p>",
+	})
+
+	directory, errOpt := LoadDirectory(mountPath)
+	if errOpt.IsSome() {
+		t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+	}
+	if reports := directory.List(); len(reports) != 2 || reports[0].Name != "invoice" || reports[1].Name != "receipt" {
+		t.Fatalf("got reports %v, want invoice and receipt", reports)
+	}
+
+	fingerprint := directory.currentFingerprint()
+	errOpt = directory.Reload()
+	if errOpt.IsSome() {
+		t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+	}
+	if directory.currentFingerprint() != fingerprint {
+		t.Fatalf("the fingerprint changed without any change to the files")
+	}
+
+	writeConfigMap(t, mountPath, "2024_01_02_00_00_00.000000001", map[string]string{
+		ManifestYamlFile:      configMapManifest,
+		"invoice/" + BodyFile: "<p>Invoice v2</p>",
+		"receipt/" + BodyFile: "<p>Receipt</p>",
+	})
+
+	errOpt = directory.Reload()
+	if errOpt.IsSome() {
+		t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+	}
+	if directory.currentFingerprint() == fingerprint {
+		t.Fatalf("the fingerprint didn't change after ..data was swapped")
+	}
+	report := directory.Get("invoice")
+	if report.IsNone() || report.Unwrap().Body != "<p>Invoice v2</p>" {
+		t.Fatalf("the invoice wasn't reloaded after ..data was swapped")
+	}
+}
+
+func TestReadSymlinkLoop(t *testing.T) {
+	sourcePath := t.TempDir()
+	err := os.WriteFile(filepath.Join(sourcePath, ManifestYamlFile), []byte("version: 1\nreports: []\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(".", filepath.Join(sourcePath, "loop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, errOpt := Read(sourcePath)
+	if errOpt.IsSome() {
+		t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+	}
+	if len(files) != 1 {
+		t.Fatalf("got files %v, want only the manifest", sortedNames(files))
+	}
+}
@@ -0,0 +1,133 @@
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+)
+
+// The import modes.
+const (
+	// ModeCreateOnly creates the missing reports and leaves the existing ones untouched.
+	ModeCreateOnly = "create-only"
+	// ModeOverwrite creates the missing reports and overwrites the existing ones.
+	ModeOverwrite = "overwrite"
+)
+
+// ImportModes lists the supported import modes.
+var ImportModes = []string{ModeCreateOnly, ModeOverwrite}
+
+// Import saves the reports in the internal database according to the mode and describes what was done.
+// Every report to create or update is checked with validate first, the invalid ones aren't written.
+// With dryRun, nothing is written and the results describe what would be done.
+func Import(internalDbConn *datasource.DataSource, reports []types.Report, mode string, dryRun bool, validate func(types.Report) types.ValidationResult) ([]types.ImportResult, safego.Option[error]) {
+	if !utils.ContainsString(ImportModes, mode) {
+		return nil, safego.Some(errors.New("unsupported import mode: " + mode))
+	}
+
+	results := []types.ImportResult{}
+	for _, report := range reports {
+		existingReportOpt, errOpt := internalDb.GetReport(internalDbConn, report.Name)
+		if errOpt.IsSome() {
+			return results, errOpt
+		}
+
+		result := types.ImportResult{Name: report.Name}
+
+		if existingReportOpt.IsNone() {
+			result.Action = "create"
+		} else {
+			result.ChangedFields, result.Diff = diffReports(existingReportOpt.Unwrap(), report)
+
+			if len(result.ChangedFields) == 0 {
+				result.Action = "unchanged"
+			} else if mode == ModeCreateOnly {
+				result.Action = "skip"
+			} else {
+				result.Action = "update"
+			}
+		}
+
+		if result.Action == "create" || result.Action == "update" {
+			validationResult := validate(report)
+			result.Problems = validationResult.Problems
+			if !validationResult.Valid {
+				result.Action = "invalid"
+			}
+		}
+
+		if !dryRun {
+			switch result.Action {
+			case "create":
+				report.CreatedAt = utils.GetTimestamp()
+				report.UpdatedAt = 0
+				errOpt = internalDb.SaveReport(internalDbConn, report)
+			case "update":
+				report.UpdatedAt = utils.GetTimestamp()
+				errOpt = internalDb.UpdateReport(internalDbConn, report)
+			}
+			if errOpt.IsSome() {
+				return results, errOpt
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, safego.None[error]()
+}
+
+// diffReports lists the fields that differ between two reports and returns a line diff of the changed templates.
+func diffReports(oldReport types.Report, newReport types.Report) ([]string, string) {
+	changedFields := []string{}
+	diff := ""
+
+	if oldReport.Title != newReport.Title {
+		changedFields = append(changedFields, "title")
+	}
+	if oldReport.Description != newReport.Description {
+		changedFields = append(changedFields, "description")
+	}
+
+	for _, template := range []struct{ name, oldText, newText string }{
+		{"body", oldReport.Body, newReport.Body},
+		{"header", oldReport.Header, newReport.Header},
+		{"footer", oldReport.Footer, newReport.Footer},
+	} {
+		if template.oldText == template.newText {
+			continue
+		}
+
+		changedFields = append(changedFields, template.name)
+		diff += "--- " + template.name + "\n" + utils.DiffLines(template.oldText, template.newText)
+	}
+
+	if !jsonEqual(oldReport.PrintingOptions, newReport.PrintingOptions) {
+		changedFields = append(changedFields, "printingOptions")
+	}
+	if !jsonEqual(oldReport.Parameters, newReport.Parameters) {
+		changedFields = append(changedFields, "parameters")
+	}
+
+	return changedFields, diff
+}
+
+// jsonEqual compares two values by their JSON encoding, which ignores the differences between number types
+// coming from YAML and JSON decoding.
+func jsonEqual(a any, b any) bool {
+	var decodedA, decodedB any
+
+	encodedA, _ := json.Marshal(a)
+	encodedB, _ := json.Marshal(b)
+	_ = json.Unmarshal(encodedA, &decodedA)
+	_ = json.Unmarshal(encodedB, &decodedB)
+
+	reencodedA, _ := json.Marshal(decodedA)
+	reencodedB, _ := json.Marshal(decodedB)
+
+	return string(reencodedA) == string(reencodedB)
+}
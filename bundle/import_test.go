@@ -0,0 +1,115 @@
+package bundle
+
+import (
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/types"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rejectDeletes is a validator failing the reports whose body deletes rows.
+func rejectDeletes(report types.Report) types.ValidationResult {
+	result := types.ValidationResult{Valid: true, Parameters: []string{}, Problems: []types.ValidationProblem{}}
+	if strings.Contains(report.Body, "DELETE") {
+		result.Valid = false
+		result.Problems = append(result.Problems, types.ValidationProblem{Line: 1, Column: 4, Severity: types.ProblemSeverityError, Message: "The query is rejected"})
+	}
+
+	return result
+}
+
+func TestImportValidatesTheReports(t *testing.T) {
+	cases := []struct {
+		name       string
+		mode       string
+		dryRun     bool
+		wantAction map[string]string
+		// wantBodies are the bodies saved once imported, "" for a missing report.
+		wantBodies map[string]string
+	}{
+		{
+			name:       "create only",
+			mode:       ModeCreateOnly,
+			wantAction: map[string]string{"invoice": "skip", "receipt": "invalid", "statement": "create"},
+			wantBodies: map[string]string{"invoice": "[Q[SELECT 1]]", "receipt": "", "statement": "[Q[SELECT 3]]"},
+		},
+		{
+			name:       "overwrite",
+			mode:       ModeOverwrite,
+			wantAction: map[string]string{"invoice": "invalid", "receipt": "invalid", "statement": "create"},
+			wantBodies: map[string]string{"invoice": "[Q[SELECT 1]]", "receipt": "", "statement": "[Q[SELECT 3]]"},
+		},
+		{
+			name:       "dry run",
+			mode:       ModeOverwrite,
+			dryRun:     true,
+			wantAction: map[string]string{"invoice": "invalid", "receipt": "invalid", "statement": "create"},
+			wantBodies: map[string]string{"invoice": "[Q[SELECT 1]]", "receipt": "", "statement": ""},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			internalDbConn := newInternalDb(t)
+			errOpt := internalDb.SaveReport(&internalDbConn, types.Report{Name: "invoice", Title: "Invoice", Body: "[Q[SELECT 1]]"})
+			if errOpt.IsSome() {
+				t.Fatal(errOpt.Unwrap())
+			}
+
+			reports := []types.Report{
+				{Name: "invoice", Title: "Invoice", Body: "[Q[SELECT 1; DELETE FROM invoices]]"},
+				{Name: "receipt", Title: "Receipt", Body: "[Q[DELETE FROM receipts]]"},
+				{Name: "statement", Title: "Statement", Body: "[Q[SELECT 3]]"},
+			}
+			results, errOpt := Import(&internalDbConn, reports, testCase.mode, testCase.dryRun, rejectDeletes)
+			if errOpt.IsSome() {
+				t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+			}
+
+			for _, result := range results {
+				if result.Action != testCase.wantAction[result.Name] {
+					t.Errorf("%s: got the action %s, want %s", result.Name, result.Action, testCase.wantAction[result.Name])
+				}
+				if (result.Action == "invalid") != (len(result.Problems) > 0) {
+					t.Errorf("%s: got the problems %+v with the action %s", result.Name, result.Problems, result.Action)
+				}
+			}
+
+			for name, wantBody := range testCase.wantBodies {
+				reportOpt, errOpt := internalDb.GetReport(&internalDbConn, name)
+				if errOpt.IsSome() {
+					t.Fatal(errOpt.Unwrap())
+				}
+				body := ""
+				if reportOpt.IsSome() {
+					body = reportOpt.Unwrap().Body
+				}
+				if body != wantBody {
+					t.Errorf("%s: got the body %q, want %q", name, body, wantBody)
+				}
+			}
+		})
+	}
+}
+
+func newInternalDb(t *testing.T) datasource.DataSource {
+	t.Helper()
+
+	var internalDbConn datasource.DataSource
+	internalDbConn = datasource.NewSqliteDb(filepath.Join(t.TempDir(), "internal.db"))
+
+	errOpt := internalDbConn.Connect()
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+	t.Cleanup(func() { internalDbConn.Disconnect() })
+
+	errOpt = internalDb.MigrateInternalDb(&internalDbConn)
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+
+	return internalDbConn
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/okira-e/goreports/assets"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+var assetCmd = &cobra.Command{
+	Use:   "asset",
+	Short: "Manage the report assets",
+	Long: `Adds, lists and deletes the images, fonts and stylesheets used by the reports.
+Assets belong to a report with --report, or are global and shared by every report otherwise.
+Templates reference them with {{asset "logo.png"}} or asset://logo.png, a report's own assets first.`,
+}
+
+var assetAddCmd = &cobra.Command{
+	Use:   "add <file>",
+	Short: "Add an asset",
+	Long:  `Stores a file as an asset, named after the file unless --name is given. An asset with the same name is replaced.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reportName, err := cmd.Flags().GetString("report")
+		if err != nil {
+			log.Fatalf("error while getting the report flag: %v", err)
+		}
+		name, err := cmd.Flags().GetString("name")
+		if err != nil {
+			log.Fatalf("error while getting the name flag: %v", err)
+		}
+		if name == "" {
+			name = filepath.Base(args[0])
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			log.Fatalf("error while reading the asset: %v", err)
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		config, errOpt := utils.GetConfigData()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+		}
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		if reportName != "" {
+			getReportOrExit(&internalDbConn, reportName)
+		}
+
+		asset, errOpt := assets.NewAsset(reportName, name, data, config.AssetConfig.MaxSizeBytes)
+		if errOpt.IsSome() {
+			log.Fatalf("error while creating the asset: %v", errOpt.Unwrap())
+		}
+
+		errOpt = internalDb.SaveAsset(&internalDbConn, asset)
+		if errOpt.IsSome() {
+			log.Fatalf("error while saving the asset: %v", errOpt.Unwrap())
+		}
+
+		utils.Log(fmt.Sprintf("Added asset %s (%s, %d bytes).", asset.Name, asset.ContentType, asset.Size))
+	},
+}
+
+var assetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the assets",
+	Long:  `Lists the global assets, or the assets of a report with --report.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		reportName, err := cmd.Flags().GetString("report")
+		if err != nil {
+			log.Fatalf("error while getting the report flag: %v", err)
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		storedAssets, errOpt := internalDb.ListAssets(&internalDbConn, safego.Some(reportName))
+		if errOpt.IsSome() {
+			log.Fatalf("error while listing the assets: %v", errOpt.Unwrap())
+		}
+
+		for _, asset := range storedAssets {
+			utils.Log(fmt.Sprintf("%s\t%s\t%d bytes", asset.Name, asset.ContentType, asset.Size))
+		}
+	},
+}
+
+var assetDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete an asset",
+	Long:  `Deletes a global asset, or an asset of a report with --report.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reportName, err := cmd.Flags().GetString("report")
+		if err != nil {
+			log.Fatalf("error while getting the report flag: %v", err)
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		assetOpt, errOpt := internalDb.GetAsset(&internalDbConn, reportName, args[0])
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the asset: %v", errOpt.Unwrap())
+		}
+		if assetOpt.IsNone() {
+			log.Fatalf("asset %s was not found", args[0])
+		}
+
+		errOpt = internalDb.DeleteAsset(&internalDbConn, reportName, args[0])
+		if errOpt.IsSome() {
+			log.Fatalf("error while deleting the asset: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Deleted asset " + args[0] + ".")
+	},
+}
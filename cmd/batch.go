@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <report-name>",
+	Short: "Render a report once per parameter set",
+	Long: `Renders a stored report once per parameter set and writes a single PDF with a bookmark per item, or a ZIP archive
+with a file per item when --out ends with .zip.
+The parameter sets are the objects of the JSON array of --items-file, or the rows of --query. Parameters passed with
+--param are given to every item, and to the [P[...]] directives of the query.
+The items that fail are left out and listed, and the command then exits with status 1.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reportName := args[0]
+
+		itemsFile, err := cmd.Flags().GetString("items-file")
+		if err != nil {
+			log.Fatalf("error while getting the items-file flag: %v", err)
+		}
+		itemsQuery, err := cmd.Flags().GetString("query")
+		if err != nil {
+			log.Fatalf("error while getting the query flag: %v", err)
+		}
+		paramFlags, err := cmd.Flags().GetStringArray("param")
+		if err != nil {
+			log.Fatalf("error while getting the param flag: %v", err)
+		}
+		outPath, err := cmd.Flags().GetString("out")
+		if err != nil {
+			log.Fatalf("error while getting the out flag: %v", err)
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			log.Fatalf("error while getting the format flag: %v", err)
+		}
+		nameTemplate, err := cmd.Flags().GetString("name")
+		if err != nil {
+			log.Fatalf("error while getting the name flag: %v", err)
+		}
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			log.Fatalf("error while getting the concurrency flag: %v", err)
+		}
+		locale, err := cmd.Flags().GetString("locale")
+		if err != nil {
+			log.Fatalf("error while getting the locale flag: %v", err)
+		}
+		reportsDir, err := cmd.Flags().GetString("reports-dir")
+		if err != nil {
+			log.Fatalf("error while getting the reports-dir flag: %v", err)
+		}
+
+		if (itemsFile == "") == (itemsQuery == "") {
+			log.Fatalf("either --items-file or --query is required")
+		}
+
+		batch := types.BatchRender{
+			ReportName:   reportName,
+			ItemsQuery:   itemsQuery,
+			Params:       map[string]any{},
+			Output:       core.BatchOutputPdf,
+			Format:       format,
+			NameTemplate: nameTemplate,
+			Concurrency:  concurrency,
+			Locale:       locale,
+		}
+		if strings.EqualFold(filepath.Ext(outPath), ".zip") {
+			batch.Output = core.BatchOutputZip
+		}
+		if itemsFile != "" {
+			errOpt := utils.ReadJSONFile(itemsFile, &batch.Items)
+			if errOpt.IsSome() {
+				log.Fatalf("error while reading the items file: %v", errOpt.Unwrap())
+			}
+			if len(batch.Items) == 0 {
+				log.Fatalf("the items file has no items")
+			}
+		}
+		parseParamFlagsOrExit(paramFlags, batch.Params)
+
+		ensureConfigFileExists(cmd, args)
+
+		reportsDirectory := loadReportsDirectoryOrExit(reportsDir)
+		report := findReportOrExit(reportsDirectory, reportName)
+
+		config, errOpt := utils.GetConfigData()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+		}
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		externalDb := connectToExternalDb()
+		defer externalDb.Disconnect()
+
+		renderer := core.Renderer{
+			InternalDb:       &internalDbConn,
+			ExternalDb:       &externalDb,
+			DefaultLocale:    config.DefaultLocale,
+			ReportsDirectory: reportsDirectory,
+			QueryLimits:      config.DbConfig.Limits,
+			AllowWrites:      config.DbConfig.AllowWrites,
+			CheckStatements:  config.DbConfig.CheckStatements,
+		}
+		printingOptions := core.ResolvePrintingOptions(report, nil)
+
+		document, results, errOpt := renderer.RenderBatch(context.Background(), report, batch, printingOptions)
+		failed := printBatchFailures(results)
+		if errOpt.IsSome() {
+			log.Fatalf("error while rendering the batch: %v", errOpt.Unwrap())
+		}
+
+		if outPath == "" {
+			_, err = os.Stdout.Write(document.Bytes())
+			if err != nil {
+				log.Fatalf("error while writing the batch to stdout: %v", err)
+			}
+		} else {
+			err = os.WriteFile(outPath, document.Bytes(), 0644)
+			if err != nil {
+				log.Fatalf("error while writing the batch: %v", err)
+			}
+
+			utils.Log(fmt.Sprintf("Rendered %d of %d items of %s to %s", len(results)-failed, len(results), reportName, outPath))
+		}
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// printBatchFailures prints the items of the batch that failed to stderr and returns how many there are.
+func printBatchFailures(results []types.BatchItemResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Error == "" {
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(os.Stderr, "item %d (%s) failed: %s\n", result.Index+1, result.Name, result.Error)
+	}
+
+	return failed
+}
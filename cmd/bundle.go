@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"github.com/okira-e/goreports/bundle"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"strconv"
+	"strings"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <directory|file.zip>",
+	Short: "Export reports as a bundle",
+	Long: `Exports reports to a bundle directory, or a zip archive if the path ends with .zip. The bundle holds a manifest
+(manifest.yaml or manifest.json) and a <report-name>/ directory with body.html, header.html and footer.html per report.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reportNames, err := cmd.Flags().GetStringArray("report")
+		if err != nil {
+			log.Fatalf("error while getting the report flag: %v", err)
+		}
+		manifestFormat, err := cmd.Flags().GetString("manifest-format")
+		if err != nil {
+			log.Fatalf("error while getting the manifest-format flag: %v", err)
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		reports, errOpt := internalDb.ListReports(&internalDbConn)
+		if errOpt.IsSome() {
+			log.Fatalf("error while listing all reports: %v", errOpt.Unwrap())
+		}
+
+		reports = filterReportsByName(reports, reportNames)
+
+		files, errOpt := bundle.Build(reports, manifestFormat)
+		if errOpt.IsSome() {
+			log.Fatalf("error while building the bundle: %v", errOpt.Unwrap())
+		}
+
+		errOpt = bundle.Write(files, args[0])
+		if errOpt.IsSome() {
+			log.Fatalf("error while writing the bundle: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Exported " + strconv.Itoa(len(reports)) + " report(s) to " + args[0])
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <directory|file.zip>",
+	Short: "Import reports from a bundle",
+	Long: `Imports the reports of a bundle directory or zip archive made by ` + "`goreports export`" + `.
+--mode create-only (the default) leaves existing reports untouched, --mode overwrite replaces them.
+--dry-run prints what would change, with a diff of the templates, without writing anything.
+The reports to create or update are validated first, the invalid ones aren't written and fail the command.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		mode, err := cmd.Flags().GetString("mode")
+		if err != nil {
+			log.Fatalf("error while getting the mode flag: %v", err)
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			log.Fatalf("error while getting the dry-run flag: %v", err)
+		}
+
+		files, errOpt := bundle.Read(args[0])
+		if errOpt.IsSome() {
+			log.Fatalf("error while reading the bundle: %v", errOpt.Unwrap())
+		}
+
+		reports, errOpt := bundle.Parse(files)
+		if errOpt.IsSome() {
+			log.Fatalf("error while parsing the bundle: %v", errOpt.Unwrap())
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		config, errOpt := utils.GetConfigData()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+		}
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+		externalDb := connectToExternalDb()
+		defer externalDb.Disconnect()
+
+		validate := func(report types.Report) types.ValidationResult {
+			return core.ValidateReport(report, &externalDb, config.DbConfig.CheckStatements)
+		}
+		results, errOpt := bundle.Import(&internalDbConn, reports, mode, dryRun, validate)
+		printImportResults(results, dryRun)
+		if errOpt.IsSome() {
+			log.Fatalf("error while importing the bundle: %v", errOpt.Unwrap())
+		}
+
+		invalidReports := 0
+		for _, result := range results {
+			if result.Action == "invalid" {
+				invalidReports++
+			}
+		}
+		if invalidReports > 0 {
+			log.Fatalf("%d report(s) are invalid and weren't imported", invalidReports)
+		}
+	},
+}
+
+// filterReportsByName keeps the reports with the given names, or every report if no name is given.
+func filterReportsByName(reports []types.Report, names []string) []types.Report {
+	if len(names) == 0 {
+		return reports
+	}
+
+	filteredReports := []types.Report{}
+	for _, name := range names {
+		found := false
+		for _, report := range reports {
+			if report.Name == name {
+				filteredReports = append(filteredReports, report)
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Fatalf("report %s was not found", name)
+		}
+	}
+
+	return filteredReports
+}
+
+// printImportResults prints what an import did, or would do in a dry run.
+func printImportResults(results []types.ImportResult, dryRun bool) {
+	if dryRun {
+		utils.Log("Dry run, nothing was written.")
+	}
+
+	for _, result := range results {
+		line := result.Action + ": " + result.Name
+		if len(result.ChangedFields) > 0 {
+			line += " (" + strings.Join(result.ChangedFields, ", ") + ")"
+		}
+		utils.Log(line)
+
+		printValidationProblems(result.Name, types.ValidationResult{Problems: result.Problems})
+
+		if dryRun && result.Diff != "" {
+			utils.Log(result.Diff)
+		}
+	}
+}
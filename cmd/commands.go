@@ -1,20 +1,31 @@
 package cmd
 
 import (
-	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/bundle"
+	"github.com/okira-e/goreports/core"
 	"github.com/okira-e/goreports/internalDb"
 	"github.com/okira-e/goreports/server"
 	"github.com/okira-e/goreports/types"
 	"github.com/okira-e/goreports/utils"
 	"github.com/spf13/cobra"
 	"log"
+	"os"
 	"strconv"
+	"time"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "goreports",
 	Short: "GoReports is a report generation tool",
 	Long:  `GoReports is a report generation tool that allows you to build and generate dynamic reports in many formats.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		configPath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			log.Fatalf("error while getting the config flag: %v", err)
+		}
+
+		utils.SetConfigFilePath(configPath)
+	},
 }
 var versionCmd = &cobra.Command{
 	Use:   "version",
@@ -109,21 +120,25 @@ var runInit = &cobra.Command{
 			log.Fatalf("error while getting the data directory: %v", errOpt.Unwrap())
 		}
 
-		// Create the database directory.
-		errOpt = utils.CreateDir(dataDir)
-		if errOpt.IsSome() {
-			log.Fatalf("error while creating the data directory: %v", errOpt.Unwrap())
-		}
-		// Create the database file.
-		errOpt = utils.CreateFile(dataDir + "/internal.db")
-		if errOpt.IsSome() {
-			log.Fatalf("error while creating the database file: %v", errOpt.Unwrap())
+		// Create the database directory. The data directory is shared by the config files given with --config, its
+		// database is kept if it exists.
+		err = os.MkdirAll(dataDir, 0755)
+		if err != nil {
+			log.Fatalf("error while creating the data directory: %v", err)
 		}
 
-		// Build the database.
-		errOpt = internalDb.BuildInternalDb(dataDir)
-		if errOpt.IsSome() {
-			log.Fatalf("error while building the database: %v", errOpt.Unwrap())
+		if _, err := os.Stat(dataDir + "/internal.db"); os.IsNotExist(err) {
+			// Create the database file.
+			errOpt = utils.CreateFile(dataDir + "/internal.db")
+			if errOpt.IsSome() {
+				log.Fatalf("error while creating the database file: %v", errOpt.Unwrap())
+			}
+
+			// Build the database.
+			errOpt = internalDb.BuildInternalDb(dataDir)
+			if errOpt.IsSome() {
+				log.Fatalf("error while building the database: %v", errOpt.Unwrap())
+			}
 		}
 
 		utils.Log("Initialized the config directory at " + configPath)
@@ -133,22 +148,84 @@ var runInit = &cobra.Command{
 var startServerCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start the program",
-	Long:  "Starts accepting requests and generating reports",
+	Long:  "Starts accepting requests and generating reports, until SIGINT or SIGTERM shuts the server down gracefully",
 	Run: func(cmd *cobra.Command, args []string) {
-		// Check if the config file exists.
-		found, errOpt := utils.DoesConfigFileExists()
-		if errOpt.IsSome() {
-			log.Fatalf("error while checking if the config file exists: %v", errOpt.Unwrap())
+		reportsDir, err := cmd.Flags().GetString("reports-dir")
+		if err != nil {
+			log.Fatalf("error while getting the reports-dir flag: %v", err)
+		}
+		reportsDirPollInterval, err := cmd.Flags().GetDuration("reports-dir-poll-interval")
+		if err != nil {
+			log.Fatalf("error while getting the reports-dir-poll-interval flag: %v", err)
+		}
+		host, err := cmd.Flags().GetString("host")
+		if err != nil {
+			log.Fatalf("error while getting the host flag: %v", err)
+		}
+		port, err := cmd.Flags().GetInt("port")
+		if err != nil {
+			log.Fatalf("error while getting the port flag: %v", err)
+		}
+		tlsCert, err := cmd.Flags().GetString("tls-cert")
+		if err != nil {
+			log.Fatalf("error while getting the tls-cert flag: %v", err)
+		}
+		tlsKey, err := cmd.Flags().GetString("tls-key")
+		if err != nil {
+			log.Fatalf("error while getting the tls-key flag: %v", err)
+		}
+		basePath, err := cmd.Flags().GetString("base-path")
+		if err != nil {
+			log.Fatalf("error while getting the base-path flag: %v", err)
+		}
+		bodyLimit, err := cmd.Flags().GetInt("body-limit")
+		if err != nil {
+			log.Fatalf("error while getting the body-limit flag: %v", err)
+		}
+		readTimeout, err := cmd.Flags().GetDuration("read-timeout")
+		if err != nil {
+			log.Fatalf("error while getting the read-timeout flag: %v", err)
+		}
+		writeTimeout, err := cmd.Flags().GetDuration("write-timeout")
+		if err != nil {
+			log.Fatalf("error while getting the write-timeout flag: %v", err)
 		}
 
-		if !found {
-			utils.Log("The config file does not exist. Running the `init` command...")
-			runInit.Run(cmd, args)
+		shutdownTimeout, err := cmd.Flags().GetDuration("shutdown-timeout")
+		if err != nil {
+			log.Fatalf("error while getting the shutdown-timeout flag: %v", err)
 		}
 
+		if (tlsCert == "") != (tlsKey == "") {
+			log.Fatalf("--tls-cert and --tls-key must be given together")
+		}
+		readTimeoutSeconds := secondsOrExit("read-timeout", readTimeout)
+		writeTimeoutSeconds := secondsOrExit("write-timeout", writeTimeout)
+		shutdownTimeoutSeconds := secondsOrExit("shutdown-timeout", shutdownTimeout)
+
+		// Check if the config file exists.
+		ensureConfigFileExists(cmd, args)
+
 		// Start the server.
 		utils.Log("Starting the server...")
-		server.StartServer()
+		errOpt := server.StartServer(types.ServerOptions{
+			ReportsDir:             reportsDir,
+			ReportsDirPollInterval: reportsDirPollInterval,
+			Server: types.ServerConfig{
+				Host:                   host,
+				Port:                   port,
+				TlsCertPath:            tlsCert,
+				TlsKeyPath:             tlsKey,
+				BasePath:               basePath,
+				BodyLimitBytes:         bodyLimit,
+				ReadTimeoutSeconds:     readTimeoutSeconds,
+				WriteTimeoutSeconds:    writeTimeoutSeconds,
+				ShutdownTimeoutSeconds: shutdownTimeoutSeconds,
+			},
+		})
+		if errOpt.IsSome() {
+			log.Fatalf("error while running the server: %v", errOpt.Unwrap())
+		}
 	},
 }
 
@@ -158,29 +235,10 @@ var listReportsCmd = &cobra.Command{
 	Long:  "Lists all reports",
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check if the config file exists.
-		found, errOpt := utils.DoesConfigFileExists()
-		if errOpt.IsSome() {
-			log.Fatalf("error while checking if the config file exists: %v", errOpt.Unwrap())
-		}
-
-		if !found {
-			utils.Log("The config file does not exist. Running the `init` command...")
-			runInit.Run(cmd, args)
-		}
+		ensureConfigFileExists(cmd, args)
 
 		// Establish a connection with internal database.
-		dataDir, errOpt := utils.GetDataDirBasedOnOS()
-		if errOpt.IsSome() {
-			log.Fatalf("error while getting the data directory: %v", errOpt.Unwrap())
-		}
-
-		var internalDbConn datasource.DataSource
-		internalDbConn = datasource.NewSqliteDb(dataDir + "/internal.db")
-
-		errOpt = internalDbConn.Connect()
-		if errOpt.IsSome() {
-			log.Fatalf("error while connecting to the database: %v", errOpt.Unwrap())
-		}
+		internalDbConn := connectToInternalDb()
 		defer internalDbConn.Disconnect()
 
 		// List all reports.
@@ -197,6 +255,9 @@ var listReportsCmd = &cobra.Command{
 }
 
 func Execute() {
+	// Add the flags shared by every command.
+	rootCmd.PersistentFlags().String("config", "", "The config file to use instead of the one of the OS")
+
 	// Add the flags to the runInit command.
 	runInit.Flags().StringP("db-dialect", "d", "", "The dialect of the database")
 	runInit.Flags().StringP("db-username", "u", "", "The username for the database")
@@ -205,12 +266,102 @@ func Execute() {
 	runInit.Flags().StringP("db-port", "P", "", "The port for the database")
 	runInit.Flags().StringP("db-name", "D", "", "The database name")
 
+	// Add the flags to the start command.
+	startServerCmd.Flags().String("reports-dir", "", "Load read-only reports from a bundle directory and reload them when the files change")
+	startServerCmd.Flags().Duration("reports-dir-poll-interval", 2*time.Second, "How often the reports directory is checked for changes")
+	startServerCmd.Flags().String("host", "", "The address to listen on. Defaults to server_config.host or 0.0.0.0")
+	startServerCmd.Flags().Int("port", 0, "The port to listen on. Defaults to server_config.port or 3200")
+	startServerCmd.Flags().String("tls-cert", "", "The PEM certificate to listen with over HTTPS, along with --tls-key")
+	startServerCmd.Flags().String("tls-key", "", "The PEM private key of --tls-cert")
+	startServerCmd.Flags().String("base-path", "", "The URL path prefix of every route, e.g. /goreports behind a reverse proxy")
+	startServerCmd.Flags().Int("body-limit", 0, "The largest request body in bytes, the asset uploads aside. Defaults to server_config.body_limit_bytes or 4 MiB")
+	startServerCmd.Flags().Duration("read-timeout", 0, "How long reading a request can take. Defaults to server_config.read_timeout_seconds or no limit")
+	startServerCmd.Flags().Duration("write-timeout", 0, "How long writing a response can take. Defaults to server_config.write_timeout_seconds or no limit")
+	startServerCmd.Flags().Duration("shutdown-timeout", 0, "How long the requests and renders in flight are waited for on SIGINT or SIGTERM. Defaults to server_config.shutdown_timeout_seconds or 25s")
+
+	// Add the flags to the render command.
+	renderCmd.Flags().StringArray("param", []string{}, "A parameter passed to the report as name=value (repeatable)")
+	renderCmd.Flags().String("params-file", "", "A JSON file holding the parameters passed to the report")
+	renderCmd.Flags().String("printing-options-file", "", "A JSON file holding the printing options of the report")
+	renderCmd.Flags().StringP("out", "o", "", "The file to write the rendered report to. Defaults to stdout")
+	renderCmd.Flags().StringP("format", "f", "", "The output format: pdf, html or csv. Defaults to the --out extension or pdf")
+	renderCmd.Flags().String("reports-dir", "", "Look the report up in a bundle directory before the internal database")
+	renderCmd.Flags().String("locale", "", "The locale to format values and translate the report in, e.g. fr or ar")
+
+	// Add the flags to the batch command.
+	batchCmd.Flags().String("items-file", "", "A JSON file holding an array of parameter sets, one per rendered document")
+	batchCmd.Flags().String("query", "", "A query whose rows are the parameter sets, instead of --items-file")
+	batchCmd.Flags().StringArray("param", []string{}, "A parameter passed to every item and to the query as name=value (repeatable)")
+	batchCmd.Flags().StringP("out", "o", "", "The .pdf or .zip file to write the batch to. Defaults to a PDF on stdout")
+	batchCmd.Flags().StringP("format", "f", "", "The format of the files of a ZIP archive: pdf, html or csv. Defaults to pdf")
+	batchCmd.Flags().String("name", "", "A handlebars template of the file names and bookmarks, e.g. statement-{{customer_id}}")
+	batchCmd.Flags().Int("concurrency", core.DefaultBatchConcurrency, "How many items are rendered at the same time")
+	batchCmd.Flags().String("locale", "", "The locale to format values and translate the report in, e.g. fr or ar")
+	batchCmd.Flags().String("reports-dir", "", "Look the report up in a bundle directory before the internal database")
+
+	// Add the flags to the validate command.
+	validateCmd.Flags().Bool("skip-queries", false, "Don't prepare the queries against the database")
+	validateCmd.Flags().String("reports-dir", "", "Look the report up in a bundle directory before the internal database")
+
+	// Add the flags and subcommands to the report command.
+	addReportFlags(reportAddCmd)
+	addReportFlags(reportUpdateCmd)
+	reportShowCmd.Flags().Bool("templates", false, "Also print the body, header and footer templates")
+	reportCmd.AddCommand(
+		reportAddCmd,
+		reportUpdateCmd,
+		reportShowCmd,
+		reportDeleteCmd,
+		reportRenameCmd,
+	)
+
+	// Add the flags and subcommands to the asset command.
+	assetAddCmd.Flags().String("report", "", "The report the asset belongs to. Defaults to a global asset")
+	assetAddCmd.Flags().String("name", "", "The name templates reference the asset by. Defaults to the file name")
+	assetListCmd.Flags().String("report", "", "List the assets of this report. Defaults to the global assets")
+	assetDeleteCmd.Flags().String("report", "", "The report the asset belongs to. Defaults to a global asset")
+	assetCmd.AddCommand(
+		assetAddCmd,
+		assetListCmd,
+		assetDeleteCmd,
+	)
+
+	// Add the flags and subcommands to the partial command.
+	partialSaveCmd.Flags().StringP("description", "d", "", "The description of the partial")
+	partialSaveCmd.Flags().StringP("body", "b", "", "The .html/.hbs file holding the template")
+	partialSaveCmd.Flags().BoolP("edit", "e", false, "Open the template in $EDITOR")
+	partialDeleteCmd.Flags().Bool("force", false, "Delete the partial even if reports use it")
+	partialCmd.AddCommand(
+		partialSaveCmd,
+		partialListCmd,
+		partialShowCmd,
+		partialDeleteCmd,
+	)
+
+	// Add the subcommands to the config command.
+	configCmd.AddCommand(configShowCmd)
+
+	// Add the flags to the export and import commands.
+	exportCmd.Flags().StringArrayP("report", "r", []string{}, "The report to export (repeatable). Defaults to every report")
+	exportCmd.Flags().String("manifest-format", "yaml", "The format of the manifest: yaml or json")
+	importCmd.Flags().String("mode", bundle.ModeCreateOnly, "create-only or overwrite")
+	importCmd.Flags().Bool("dry-run", false, "Print what would change without writing anything")
+
 	// Add the commands to the root command.
 	rootCmd.AddCommand(
 		versionCmd,
 		runInit,
 		startServerCmd,
 		listReportsCmd,
+		renderCmd,
+		batchCmd,
+		validateCmd,
+		reportCmd,
+		assetCmd,
+		partialCmd,
+		exportCmd,
+		importCmd,
+		configCmd,
 	)
 
 	if err := rootCmd.Execute(); err != nil {
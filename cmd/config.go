@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	Long: `Inspects the configuration GoReports runs with. The values are, from the lowest precedence to the highest:
+  1. the defaults.
+  2. the config file: --config, or else $GOREPORTS_CONFIG_PATH, or else config.json in the config directory of the OS.
+  3. the GOREPORTS_* environment variables, named after the JSON path of the value, e.g. GOREPORTS_DB_CONFIG_PASSWORD
+     for db_config.password. Maps and lists are given as JSON.
+  4. the same variables suffixed with _FILE, holding the path of a file the value is read from, like the Docker and
+     Kubernetes secrets. A variable and its _FILE variant can't both be set.
+The config file can be missing when GOREPORTS_DB_CONFIG_DIALECT is set.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the configuration",
+	Long: `Prints the configuration GoReports runs with, once the environment variables are applied, and the values they
+set. The secrets are masked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, errOpt := utils.GetConfigFilePathBasedOnOS()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the config file path: %v", errOpt.Unwrap())
+		}
+		found, errOpt := utils.DoesConfigFileExists()
+		if errOpt.IsSome() {
+			log.Fatalf("error while checking if the config file exists: %v", errOpt.Unwrap())
+		}
+
+		config, overrides, errOpt := utils.LoadConfig()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+		}
+
+		if found {
+			utils.Log("Config file: " + configPath)
+		} else {
+			utils.Log("Config file: " + configPath + " (not found)")
+		}
+
+		if len(overrides) == 0 {
+			utils.Log("No value is set by the environment.")
+		} else {
+			utils.Log("Set by the environment:")
+			for _, override := range overrides {
+				utils.Log("  " + override.Path + " <- " + override.Variable)
+			}
+		}
+
+		encoded, err := json.MarshalIndent(utils.MaskSecrets(config), "", "  ")
+		if err != nil {
+			log.Fatalf("error while encoding the config: %v", err)
+		}
+		utils.Log(string(encoded))
+	},
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/okira-e/goreports/bundle"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// ensureConfigFileExists runs the `init` command if GoReports wasn't initialized yet, unless the environment configures
+// it.
+func ensureConfigFileExists(cmd *cobra.Command, args []string) {
+	found, errOpt := utils.DoesConfigFileExists()
+	if errOpt.IsSome() {
+		log.Fatalf("error while checking if the config file exists: %v", errOpt.Unwrap())
+	}
+
+	if !found && utils.IsConfiguredByEnv() {
+		// The environment replaces the config file, only the data directory is needed.
+		dataDir, errOpt := utils.GetDataDirBasedOnOS()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the data directory: %v", errOpt.Unwrap())
+		}
+
+		err := os.MkdirAll(dataDir, 0755)
+		if err != nil {
+			log.Fatalf("error while creating the data directory: %v", err)
+		}
+	} else if !found {
+		utils.Log("The config file does not exist. Running the `init` command...")
+		runInit.Run(cmd, args)
+	}
+}
+
+// connectToInternalDb establishes a connection with the internal database and brings its schema up to date.
+// The caller is responsible for disconnecting.
+func connectToInternalDb() datasource.DataSource {
+	dataDir, errOpt := utils.GetDataDirBasedOnOS()
+	if errOpt.IsSome() {
+		log.Fatalf("error while getting the data directory: %v", errOpt.Unwrap())
+	}
+
+	var internalDbConn datasource.DataSource
+	internalDbConn = datasource.NewSqliteDb(dataDir + "/internal.db")
+
+	errOpt = internalDbConn.Connect()
+	if errOpt.IsSome() {
+		log.Fatalf("error while connecting to the database: %v", errOpt.Unwrap())
+	}
+
+	errOpt = internalDb.MigrateInternalDb(&internalDbConn)
+	if errOpt.IsSome() {
+		log.Fatalf("error while migrating the internal database: %v", errOpt.Unwrap())
+	}
+
+	return internalDbConn
+}
+
+// connectToExternalDb establishes a connection with the external database described in the config file.
+// The caller is responsible for disconnecting.
+func connectToExternalDb() datasource.DataSource {
+	config, errOpt := utils.GetConfigData()
+	if errOpt.IsSome() {
+		log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+	}
+
+	connection, errOpt := datasource.ConnectionOf(config.DbConfig)
+	if errOpt.IsSome() {
+		log.Fatalf("error while getting the connection string: %v", errOpt.Unwrap())
+	}
+
+	var externalDb datasource.DataSource
+	externalDb = datasource.NewExternalDb(connection, config.DbConfig.Pool)
+
+	errOpt = externalDb.Connect()
+	if errOpt.IsSome() {
+		log.Fatalf("error while connecting to the external database: %v", errOpt.Unwrap())
+	}
+
+	return externalDb
+}
+
+// loadReportsDirectoryOrExit loads the reports directory, if given.
+func loadReportsDirectoryOrExit(reportsDir string) safego.Option[*bundle.Directory] {
+	if reportsDir == "" {
+		return safego.None[*bundle.Directory]()
+	}
+
+	directory, errOpt := bundle.LoadDirectory(reportsDir)
+	if errOpt.IsSome() {
+		log.Fatalf("error while loading the reports directory: %v", errOpt.Unwrap())
+	}
+
+	return safego.Some(directory)
+}
+
+// findReportOrExit looks the report up in the reports directory, if given, and then in the internal database.
+func findReportOrExit(directory safego.Option[*bundle.Directory], name string) types.Report {
+	if directory.IsSome() {
+		reportOpt := directory.Unwrap().Get(name)
+		if reportOpt.IsSome() {
+			return reportOpt.Unwrap()
+		}
+	}
+
+	internalDbConn := connectToInternalDb()
+	defer internalDbConn.Disconnect()
+
+	return getReportOrExit(&internalDbConn, name)
+}
+
+// parseParamFlagsOrExit adds the name=value parameters of the --param flags to params. The values are parsed as JSON
+// when possible, so 2 is a number and John is a string.
+func parseParamFlagsOrExit(paramFlags []string, params map[string]any) {
+	for _, paramFlag := range paramFlags {
+		name, value, found := strings.Cut(paramFlag, "=")
+		if !found || name == "" {
+			log.Fatalf("invalid parameter %q, expected name=value", paramFlag)
+		}
+
+		var parsedValue any
+		if json.Unmarshal([]byte(value), &parsedValue) != nil {
+			parsedValue = value
+		}
+		params[name] = parsedValue
+	}
+}
+
+// secondsOrExit converts the duration of a flag to the whole seconds the server config holds. The other durations are
+// rejected rather than truncated, 500ms would otherwise become 0, which means no limit.
+func secondsOrExit(flagName string, duration time.Duration) int {
+	if duration < 0 || duration%time.Second != 0 {
+		log.Fatalf("--%s must be a whole number of seconds, e.g. 30s or 5m, got %s", flagName, duration)
+	}
+
+	return int(duration / time.Second)
+}
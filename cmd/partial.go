@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"strings"
+)
+
+var partialCmd = &cobra.Command{
+	Use:   "partial",
+	Short: "Manage the partials and layouts shared between reports",
+	Long: `Saves, lists, shows and deletes the templates shared between reports.
+Report bodies include a partial with {{> name}}, or extend it as a layout with {{#extends "name"}}.`,
+}
+
+var partialSaveCmd = &cobra.Command{
+	Use:   "save <partial-name>",
+	Short: "Create or update a partial",
+	Long: `Creates a partial from a template file, or replaces the template of an existing one, and lists the reports using it.
+The template is opened in $EDITOR when --body is omitted or --edit is set.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		partial := types.Partial{Name: args[0]}
+		existingPartialOpt, errOpt := internalDb.GetPartial(&internalDbConn, args[0])
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the partial: %v", errOpt.Unwrap())
+		}
+		if existingPartialOpt.IsSome() {
+			partial = existingPartialOpt.Unwrap()
+		}
+
+		if cmd.Flags().Changed("description") {
+			partial.Description, _ = cmd.Flags().GetString("description")
+		}
+		if cmd.Flags().Changed("body") {
+			partial.Body = readTemplateFile(cmd, "body")
+		}
+		edit, _ := cmd.Flags().GetBool("edit")
+		if edit || (partial.Body == "" && !cmd.Flags().Changed("body")) {
+			body, errOpt := utils.EditInEditor(partial.Body, ".hbs")
+			if errOpt.IsSome() {
+				log.Fatalf("error while editing the partial: %v", errOpt.Unwrap())
+			}
+			partial.Body = body
+		}
+		if strings.TrimSpace(partial.Body) == "" {
+			log.Fatalf("the partial body can't be empty")
+		}
+
+		partials, errOpt := internalDb.ListPartials(&internalDbConn)
+		if errOpt.IsSome() {
+			log.Fatalf("error while listing the partials: %v", errOpt.Unwrap())
+		}
+		errOpt = core.ValidatePartial(partial, partials)
+		if errOpt.IsSome() {
+			log.Fatalf("error while validating the partial: %v", errOpt.Unwrap())
+		}
+
+		partial.UpdatedAt = utils.GetTimestamp()
+		if partial.CreatedAt == 0 {
+			partial.CreatedAt = partial.UpdatedAt
+		}
+
+		errOpt = internalDb.SavePartial(&internalDbConn, partial)
+		if errOpt.IsSome() {
+			log.Fatalf("error while saving the partial: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Saved partial " + partial.Name + ".")
+		printPartialDependents(&internalDbConn, partial.Name)
+	},
+}
+
+var partialListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the partials",
+	Long:  `Lists the partials and layouts with their description.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		partials, errOpt := internalDb.ListPartials(&internalDbConn)
+		if errOpt.IsSome() {
+			log.Fatalf("error while listing the partials: %v", errOpt.Unwrap())
+		}
+
+		for _, partial := range partials {
+			utils.Log(partial.Name + "\t" + partial.Description)
+		}
+	},
+}
+
+var partialShowCmd = &cobra.Command{
+	Use:   "show <partial-name>",
+	Short: "Show a partial",
+	Long:  `Prints the template of a partial and the reports using it.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		partial := getPartialOrExit(&internalDbConn, args[0])
+
+		utils.Log("Name:        " + partial.Name)
+		utils.Log("Description: " + partial.Description)
+		printPartialDependents(&internalDbConn, partial.Name)
+		utils.Log("")
+		utils.Log(partial.Body)
+	},
+}
+
+var partialDeleteCmd = &cobra.Command{
+	Use:   "delete <partial-name>",
+	Short: "Delete a partial",
+	Long:  `Deletes a partial. It fails while reports use it, unless --force is set.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			log.Fatalf("error while getting the force flag: %v", err)
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		partial := getPartialOrExit(&internalDbConn, args[0])
+
+		dependents := dependentReportsOrExit(&internalDbConn, partial.Name)
+		if len(dependents) > 0 && !force {
+			log.Fatalf("partial %s is used by %s, pass --force to delete it anyway", partial.Name, strings.Join(dependents, ", "))
+		}
+
+		errOpt := internalDb.DeletePartial(&internalDbConn, partial.Name)
+		if errOpt.IsSome() {
+			log.Fatalf("error while deleting the partial: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Deleted partial " + partial.Name + ".")
+	},
+}
+
+// getPartialOrExit returns the partial with the given name, or exits if it doesn't exist.
+func getPartialOrExit(internalDbConn *datasource.DataSource, name string) types.Partial {
+	partialOpt, errOpt := internalDb.GetPartial(internalDbConn, name)
+	if errOpt.IsSome() {
+		log.Fatalf("error while getting the partial: %v", errOpt.Unwrap())
+	}
+	if partialOpt.IsNone() {
+		log.Fatalf("partial %s was not found", name)
+	}
+
+	return partialOpt.Unwrap()
+}
+
+// dependentReportsOrExit returns the names of the stored reports using the partial.
+func dependentReportsOrExit(internalDbConn *datasource.DataSource, name string) []string {
+	reports, errOpt := internalDb.ListReports(internalDbConn)
+	if errOpt.IsSome() {
+		log.Fatalf("error while listing the reports: %v", errOpt.Unwrap())
+	}
+	partials, errOpt := internalDb.ListPartials(internalDbConn)
+	if errOpt.IsSome() {
+		log.Fatalf("error while listing the partials: %v", errOpt.Unwrap())
+	}
+
+	return core.DependentReports(name, reports, partials)
+}
+
+// printPartialDependents prints the reports using the partial.
+func printPartialDependents(internalDbConn *datasource.DataSource, name string) {
+	dependents := dependentReportsOrExit(internalDbConn, name)
+	if len(dependents) == 0 {
+		utils.Log("Used by:     no report")
+		return
+	}
+
+	utils.Log("Used by:     " + strings.Join(dependents, ", "))
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render <report-name>",
+	Short: "Render a report without starting the server",
+	Long: `Renders a stored report against the configured database and writes it to a file, or to stdout if --out is omitted.
+Parameters are passed with --param name=value (repeatable) and/or --params-file params.json. Values passed with --param
+are parsed as JSON when possible, so --param customer_id=2 is a number and --param name=John is a string.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reportName := args[0]
+
+		paramFlags, err := cmd.Flags().GetStringArray("param")
+		if err != nil {
+			log.Fatalf("error while getting the param flag: %v", err)
+		}
+		paramsFile, err := cmd.Flags().GetString("params-file")
+		if err != nil {
+			log.Fatalf("error while getting the params-file flag: %v", err)
+		}
+		printingOptionsFile, err := cmd.Flags().GetString("printing-options-file")
+		if err != nil {
+			log.Fatalf("error while getting the printing-options-file flag: %v", err)
+		}
+		outPath, err := cmd.Flags().GetString("out")
+		if err != nil {
+			log.Fatalf("error while getting the out flag: %v", err)
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			log.Fatalf("error while getting the format flag: %v", err)
+		}
+		reportsDir, err := cmd.Flags().GetString("reports-dir")
+		if err != nil {
+			log.Fatalf("error while getting the reports-dir flag: %v", err)
+		}
+		locale, err := cmd.Flags().GetString("locale")
+		if err != nil {
+			log.Fatalf("error while getting the locale flag: %v", err)
+		}
+
+		// Infer the format from the output file extension if it wasn't given.
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(outPath), ".")
+			if !utils.ContainsString(core.SupportedFormats, format) {
+				format = core.FormatPdf
+			}
+		}
+		if !utils.ContainsString(core.SupportedFormats, format) {
+			log.Fatalf("unsupported format %s, expected one of: %s", format, strings.Join(core.SupportedFormats, ", "))
+		}
+
+		// Collect the parameters. Parameters passed with --param override the ones of the params file.
+		params := map[string]any{}
+		if paramsFile != "" {
+			errOpt := utils.ReadJSONFile(paramsFile, &params)
+			if errOpt.IsSome() {
+				log.Fatalf("error while reading the params file: %v", errOpt.Unwrap())
+			}
+		}
+		parseParamFlagsOrExit(paramFlags, params)
+
+		var requestedPrintingOptions *types.PrintingOptions
+		if printingOptionsFile != "" {
+			requestedPrintingOptions = &types.PrintingOptions{}
+			errOpt := utils.ReadJSONFile(printingOptionsFile, requestedPrintingOptions)
+			if errOpt.IsSome() {
+				log.Fatalf("error while reading the printing options file: %v", errOpt.Unwrap())
+			}
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		reportsDirectory := loadReportsDirectoryOrExit(reportsDir)
+		report := findReportOrExit(reportsDirectory, reportName)
+
+		config, errOpt := utils.GetConfigData()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+		}
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		externalDb := connectToExternalDb()
+		defer externalDb.Disconnect()
+
+		renderer := core.Renderer{
+			InternalDb:       &internalDbConn,
+			ExternalDb:       &externalDb,
+			DefaultLocale:    config.DefaultLocale,
+			ReportsDirectory: reportsDirectory,
+			QueryLimits:      config.DbConfig.Limits,
+			AllowWrites:      config.DbConfig.AllowWrites,
+			CheckStatements:  config.DbConfig.CheckStatements,
+		}
+		printingOptions := core.ResolvePrintingOptions(report, requestedPrintingOptions)
+
+		renderedBuffer, errOpt := renderer.Render(context.Background(), report, params, printingOptions, format, locale)
+		if errOpt.IsSome() {
+			log.Fatalf("error while rendering the report: %v", errOpt.Unwrap())
+		}
+
+		if outPath == "" {
+			_, err = os.Stdout.Write(renderedBuffer.Bytes())
+			if err != nil {
+				log.Fatalf("error while writing the report to stdout: %v", err)
+			}
+			return
+		}
+
+		err = os.WriteFile(outPath, renderedBuffer.Bytes(), 0644)
+		if err != nil {
+			log.Fatalf("error while writing the report: %v", err)
+		}
+
+		utils.Log("Rendered " + reportName + " to " + outPath)
+	},
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Manage the stored reports",
+	Long: `Creates, edits, shows, renames and deletes the reports stored in the internal database.
+Templates are read from files (e.g. body.html, header.hbs) so they can live next to your code.`,
+}
+
+var reportAddCmd = &cobra.Command{
+	Use:   "add <report-name>",
+	Short: "Create a report",
+	Long: `Creates a report from template files. The body is opened in $EDITOR when --body is omitted or --edit is set.
+It isn't created if goreports validate finds errors in it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		existingReportOpt, errOpt := internalDb.GetReport(&internalDbConn, args[0])
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the report: %v", errOpt.Unwrap())
+		}
+		if existingReportOpt.IsSome() {
+			log.Fatalf("report %s already exists, use `goreports report update` to change it", args[0])
+		}
+
+		report := types.Report{
+			Name:      args[0],
+			CreatedAt: utils.GetTimestamp(),
+		}
+		applyReportFlags(cmd, &report)
+
+		if report.Title == "" {
+			log.Fatalf("the report title is required, pass it with --title")
+		}
+		if strings.TrimSpace(report.Body) == "" {
+			log.Fatalf("the report body is required, pass it with --body or --edit")
+		}
+
+		validateReportOrExit(report)
+
+		errOpt = internalDb.SaveReport(&internalDbConn, report)
+		if errOpt.IsSome() {
+			log.Fatalf("error while saving the report: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Created report " + report.Name + ".")
+		printReportSummary(report, false)
+	},
+}
+
+var reportUpdateCmd = &cobra.Command{
+	Use:   "update <report-name>",
+	Short: "Update a report",
+	Long: `Updates the parts of a report given as flags and keeps the rest. --edit opens the current body in $EDITOR.
+It isn't updated if goreports validate finds errors in it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		report := getReportOrExit(&internalDbConn, args[0])
+		report.UpdatedAt = utils.GetTimestamp()
+		applyReportFlags(cmd, &report)
+
+		if strings.TrimSpace(report.Body) == "" {
+			log.Fatalf("the report body can't be empty")
+		}
+
+		validateReportOrExit(report)
+
+		errOpt := internalDb.UpdateReport(&internalDbConn, report)
+		if errOpt.IsSome() {
+			log.Fatalf("error while updating the report: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Updated report " + report.Name + ".")
+		printReportSummary(report, false)
+	},
+}
+
+var reportShowCmd = &cobra.Command{
+	Use:   "show <report-name>",
+	Short: "Show a report",
+	Long:  `Prints the details of a report. --templates also prints its body, header and footer.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		showTemplates, err := cmd.Flags().GetBool("templates")
+		if err != nil {
+			log.Fatalf("error while getting the templates flag: %v", err)
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		printReportSummary(getReportOrExit(&internalDbConn, args[0]), showTemplates)
+	},
+}
+
+var reportDeleteCmd = &cobra.Command{
+	Use:   "delete <report-name>",
+	Short: "Delete a report",
+	Long:  `Deletes a report from the internal database.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		report := getReportOrExit(&internalDbConn, args[0])
+
+		errOpt := internalDb.DeleteReport(&internalDbConn, report.Name)
+		if errOpt.IsSome() {
+			log.Fatalf("error while deleting the report: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Deleted report " + report.Name + ".")
+	},
+}
+
+var reportRenameCmd = &cobra.Command{
+	Use:   "rename <report-name> <new-report-name>",
+	Short: "Rename a report",
+	Long:  `Renames a report. Render requests must use the new name afterwards.`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ensureConfigFileExists(cmd, args)
+
+		internalDbConn := connectToInternalDb()
+		defer internalDbConn.Disconnect()
+
+		report := getReportOrExit(&internalDbConn, args[0])
+
+		existingReportOpt, errOpt := internalDb.GetReport(&internalDbConn, args[1])
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the report: %v", errOpt.Unwrap())
+		}
+		if existingReportOpt.IsSome() {
+			log.Fatalf("report %s already exists", args[1])
+		}
+
+		errOpt = internalDb.RenameReport(&internalDbConn, report.Name, args[1])
+		if errOpt.IsSome() {
+			log.Fatalf("error while renaming the report: %v", errOpt.Unwrap())
+		}
+
+		utils.Log("Renamed report " + report.Name + " to " + args[1] + ".")
+	},
+}
+
+// addReportFlags adds the flags shared by `report add` and `report update`.
+func addReportFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("title", "t", "", "The title of the report")
+	cmd.Flags().StringP("description", "d", "", "The description of the report")
+	cmd.Flags().StringP("body", "b", "", "The .html/.hbs file holding the body template")
+	cmd.Flags().String("header", "", "The .html/.hbs file holding the header template")
+	cmd.Flags().String("footer", "", "The .html/.hbs file holding the footer template")
+	cmd.Flags().BoolP("edit", "e", false, "Open the body in $EDITOR")
+}
+
+// applyReportFlags overwrites the fields of the report with the flags that were set.
+func applyReportFlags(cmd *cobra.Command, report *types.Report) {
+	if cmd.Flags().Changed("title") {
+		report.Title, _ = cmd.Flags().GetString("title")
+	}
+	if cmd.Flags().Changed("description") {
+		report.Description, _ = cmd.Flags().GetString("description")
+	}
+	if cmd.Flags().Changed("body") {
+		report.Body = readTemplateFile(cmd, "body")
+	}
+	if cmd.Flags().Changed("header") {
+		report.Header = readTemplateFile(cmd, "header")
+	}
+	if cmd.Flags().Changed("footer") {
+		report.Footer = readTemplateFile(cmd, "footer")
+	}
+
+	edit, _ := cmd.Flags().GetBool("edit")
+	if edit || (report.Body == "" && !cmd.Flags().Changed("body")) {
+		body, errOpt := utils.EditInEditor(report.Body, ".html")
+		if errOpt.IsSome() {
+			log.Fatalf("error while editing the report body: %v", errOpt.Unwrap())
+		}
+		report.Body = body
+	}
+}
+
+// readTemplateFile reads the template file given to the flag.
+func readTemplateFile(cmd *cobra.Command, flagName string) string {
+	path, err := cmd.Flags().GetString(flagName)
+	if err != nil {
+		log.Fatalf("error while getting the %s flag: %v", flagName, err)
+	}
+	if path == "" {
+		return ""
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("error while reading the %s file: %v", flagName, err)
+	}
+
+	return string(content)
+}
+
+// getReportOrExit returns the report with the given name, or exits if it doesn't exist.
+func getReportOrExit(internalDbConn *datasource.DataSource, name string) types.Report {
+	reportOpt, errOpt := internalDb.GetReport(internalDbConn, name)
+	if errOpt.IsSome() {
+		log.Fatalf("error while getting the report: %v", errOpt.Unwrap())
+	}
+	if reportOpt.IsNone() {
+		log.Fatalf("report %s was not found", name)
+	}
+
+	return reportOpt.Unwrap()
+}
+
+// printReportSummary prints the details of a report in a readable form.
+func printReportSummary(report types.Report, showTemplates bool) {
+	formatTimestamp := func(timestamp int64) string {
+		if timestamp == 0 {
+			return "never"
+		}
+		return time.Unix(0, timestamp).Format("2006-01-02 15:04:05")
+	}
+	describeTemplate := func(template string) string {
+		if template == "" {
+			return "none"
+		}
+		return fmt.Sprintf("%d lines, %d bytes", strings.Count(strings.TrimRight(template, "\n"), "\n")+1, len(template))
+	}
+
+	utils.Log("Name:        " + report.Name)
+	utils.Log("Title:       " + report.Title)
+	utils.Log("Description: " + report.Description)
+	utils.Log("Created at:  " + formatTimestamp(report.CreatedAt))
+	utils.Log("Updated at:  " + formatTimestamp(report.UpdatedAt))
+	utils.Log("Body:        " + describeTemplate(report.Body))
+	utils.Log("Header:      " + describeTemplate(report.Header))
+	utils.Log("Footer:      " + describeTemplate(report.Footer))
+
+	if showTemplates {
+		for _, section := range []struct{ name, template string }{
+			{"body", report.Body},
+			{"header", report.Header},
+			{"footer", report.Footer},
+		} {
+			if section.template == "" {
+				continue
+			}
+			utils.Log()
+			utils.Log("----- " + section.name + " -----")
+			utils.Log(section.template)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"strings"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <report-name|file>",
+	Short: "Check a report template without rendering it",
+	Long: `Checks the handlebars and the [P[...]]/[Q[...]] directives of a stored report, or of a template file, and prepares
+every query against the configured database without executing it. Problems are printed as file:line:column: message
+and the command exits with status 1 if there are any, warnings included. Use --skip-queries to validate without a database.
+The queries that aren't a single SELECT statement are reported too when check_statements is set in the config.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		target := args[0]
+
+		skipQueries, err := cmd.Flags().GetBool("skip-queries")
+		if err != nil {
+			log.Fatalf("error while getting the skip-queries flag: %v", err)
+		}
+		reportsDir, err := cmd.Flags().GetString("reports-dir")
+		if err != nil {
+			log.Fatalf("error while getting the reports-dir flag: %v", err)
+		}
+
+		ensureConfigFileExists(cmd, args)
+
+		var report types.Report
+		if info, err := os.Stat(target); err == nil && !info.IsDir() {
+			content, err := os.ReadFile(target)
+			if err != nil {
+				log.Fatalf("error while reading %s: %v", target, err)
+			}
+			report = types.Report{Name: target, Body: string(content)}
+		} else {
+			report = findReportOrExit(loadReportsDirectoryOrExit(reportsDir), target)
+		}
+
+		var ds *datasource.DataSource
+		if !skipQueries {
+			externalDb := connectToExternalDb()
+			defer externalDb.Disconnect()
+			ds = &externalDb
+		}
+
+		config, errOpt := utils.GetConfigData()
+		if errOpt.IsSome() {
+			log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+		}
+
+		result := core.ValidateReport(report, ds, config.DbConfig.CheckStatements)
+
+		if len(result.Parameters) > 0 {
+			utils.Log("Parameters: " + strings.Join(result.Parameters, ", "))
+		}
+		printValidationProblems(report.Name, result)
+
+		// The warnings fail the command too: the queries it was asked to check weren't.
+		if len(result.Problems) > 0 {
+			utils.Log(fmt.Sprintf("%d problem(s) found in %s.", len(result.Problems), report.Name))
+			os.Exit(1)
+		}
+
+		utils.Log(report.Name + " is valid.")
+	},
+}
+
+// printValidationProblems prints the problems of a validation result as file:line:column: message.
+func printValidationProblems(name string, result types.ValidationResult) {
+	for _, problem := range result.Problems {
+		message := problem.Message
+		if problem.Severity == types.ProblemSeverityWarning {
+			message = "warning: " + message
+		}
+		fmt.Printf("%s:%d:%d: %s\n", name, problem.Line, problem.Column, message)
+	}
+}
+
+// validateReportOrExit checks the report against the configured database before it is saved, and exits if it is
+// invalid. The warnings, e.g. an unreachable database, are printed but don't prevent the save.
+func validateReportOrExit(report types.Report) {
+	config, errOpt := utils.GetConfigData()
+	if errOpt.IsSome() {
+		log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
+	}
+
+	externalDb := connectToExternalDb()
+	defer externalDb.Disconnect()
+
+	result := core.ValidateReport(report, &externalDb, config.DbConfig.CheckStatements)
+	printValidationProblems(report.Name, result)
+
+	if !result.Valid {
+		log.Fatalf("report %s is invalid, it wasn't saved", report.Name)
+	}
+}
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The outputs of a batch render.
+const (
+	BatchOutputPdf = "pdf"
+	BatchOutputZip = "zip"
+)
+
+// DefaultBatchConcurrency is how many items of a batch are rendered at the same time when the batch doesn't say.
+const DefaultBatchConcurrency = 4
+
+// MaxBatchConcurrency bounds the items rendered at the same time, each one can run a wkhtmltopdf process.
+const MaxBatchConcurrency = 32
+
+// unsafeFileNameRegex matches the characters replaced in the names of the files of a batch.
+var unsafeFileNameRegex = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f]+`)
+
+var disablePdfcpuConfigDir sync.Once
+
+// renderedItem is an item of a batch once rendered.
+type renderedItem struct {
+	result   types.BatchItemResult
+	document *bytes.Buffer
+}
+
+// RenderBatch renders the report once per parameter set of the batch, with a bounded number of items at the same time,
+// and returns a single PDF with a bookmark per item or a ZIP archive with a file per item, as the batch asks.
+// The items that fail are left out of the document and reported in the results, in item order. The ZIP archive also
+// lists them in errors.json. It fails if the batch itself is invalid or no item could be rendered. The items not
+// rendered yet when the context is done fail.
+func (self *Renderer) RenderBatch(ctx context.Context, report types.Report, batch types.BatchRender, printingOptions types.PrintingOptions) (*bytes.Buffer, []types.BatchItemResult, safego.Option[error]) {
+	if batch.Output == "" {
+		batch.Output = BatchOutputPdf
+	}
+	if batch.Output != BatchOutputPdf && batch.Output != BatchOutputZip {
+		return &bytes.Buffer{}, nil, safego.Some[error](&TemplateError{Message: fmt.Sprintf("Unsupported batch output %s, expected pdf or zip.", batch.Output)})
+	}
+	if batch.Format == "" || batch.Output == BatchOutputPdf {
+		batch.Format = FormatPdf
+	}
+	if !utils.ContainsString(SupportedFormats, batch.Format) {
+		return &bytes.Buffer{}, nil, safego.Some[error](&TemplateError{Message: fmt.Sprintf("Unsupported format %s.", batch.Format)})
+	}
+
+	concurrency := batch.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > MaxBatchConcurrency {
+		concurrency = MaxBatchConcurrency
+	}
+
+	items, errOpt := self.batchItems(ctx, report, batch)
+	if errOpt.IsSome() {
+		return &bytes.Buffer{}, nil, errOpt
+	}
+	if len(items) == 0 {
+		return &bytes.Buffer{}, nil, safego.Some[error](&TemplateError{Message: "The batch has no items."})
+	}
+
+	// Render the items with a pool of workers.
+	rendered := make([]renderedItem, len(items))
+	indexes := make(chan int)
+	var workers sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < len(items); worker++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			for index := range indexes {
+				rendered[index] = self.renderBatchItem(ctx, report, batch, printingOptions, index, items[index])
+			}
+		}()
+	}
+	for index := range items {
+		indexes <- index
+	}
+	close(indexes)
+	workers.Wait()
+
+	results := make([]types.BatchItemResult, len(rendered))
+	succeeded := []renderedItem{}
+	for index, item := range rendered {
+		results[index] = item.result
+		if item.result.Error == "" {
+			succeeded = append(succeeded, item)
+		}
+	}
+	if len(succeeded) == 0 {
+		return &bytes.Buffer{}, results, safego.Some[error](&TemplateError{Message: fmt.Sprintf("None of the %d items could be rendered, the first one failed with: %s", len(results), results[0].Error)})
+	}
+
+	uniqueFileNames(succeeded, batch.Format)
+
+	if batch.Output == BatchOutputZip {
+		document, errOpt := writeBatchZip(succeeded, results)
+		return document, results, errOpt
+	}
+
+	document, errOpt := mergePdfs(succeeded)
+	return document, results, errOpt
+}
+
+// batchItems returns the parameter sets of the batch, from its items or the rows of its query.
+func (self *Renderer) batchItems(ctx context.Context, report types.Report, batch types.BatchRender) ([]map[string]any, safego.Option[error]) {
+	if len(batch.Items) > 0 || batch.ItemsQuery == "" {
+		return batch.Items, safego.None[error]()
+	}
+
+	var missingParameter string
+	query := parameterRegex.ReplaceAllStringFunc(batch.ItemsQuery, func(match string) string {
+		name := parameterRegex.FindStringSubmatch(match)[1]
+		value, ok := batch.Params[name]
+		if !ok {
+			missingParameter = name
+		}
+
+		return fmt.Sprintf("%v", value)
+	})
+	if missingParameter != "" {
+		return nil, safego.Some[error](&TemplateError{Message: fmt.Sprintf("Parameter %s of the items query is not provided.", missingParameter)})
+	}
+
+	rows, errOpt := queryRows(directQuery(ctx, self.ExternalDb, self.policyOf(report.Name)), query)
+	if errOpt.IsSome() {
+		return nil, safego.Some[error](&TemplateError{Message: "The items query failed: " + errOpt.Unwrap().Error()})
+	}
+
+	return rows, safego.None[error]()
+}
+
+// renderBatchItem renders an item of the batch and names it.
+func (self *Renderer) renderBatchItem(ctx context.Context, report types.Report, batch types.BatchRender, printingOptions types.PrintingOptions, index int, item map[string]any) renderedItem {
+	params := map[string]any{}
+	for name, value := range batch.Params {
+		params[name] = value
+	}
+	for name, value := range item {
+		params[name] = value
+	}
+
+	rendered := renderedItem{result: types.BatchItemResult{Index: index, Name: fmt.Sprintf("%s-%d", report.Name, index+1)}}
+
+	if batch.NameTemplate != "" {
+		name, errOpt := utils.ParseHandleBars(batch.NameTemplate, params)
+		if errOpt.IsSome() {
+			rendered.result.Error = "invalid name template: " + errOpt.Unwrap().Error()
+			return rendered
+		}
+		if strings.TrimSpace(name) != "" {
+			rendered.result.Name = strings.TrimSpace(name)
+		}
+	}
+
+	if ctx.Err() != nil {
+		rendered.result.Error = "the batch was canceled: " + ctx.Err().Error()
+		return rendered
+	}
+
+	document, errOpt := self.Render(ctx, report, params, printingOptions, batch.Format, batch.Locale)
+	if errOpt.IsSome() {
+		rendered.result.Error = errOpt.Unwrap().Error()
+		return rendered
+	}
+	rendered.document = document
+
+	return rendered
+}
+
+// uniqueFileNames turns the names of the items into distinct file names with the extension of the format.
+func uniqueFileNames(items []renderedItem, format string) {
+	taken := map[string]bool{}
+	for i := range items {
+		name := strings.Trim(unsafeFileNameRegex.ReplaceAllString(items[i].result.Name, "_"), ". ")
+		name = strings.TrimSuffix(name, "."+format)
+		if name == "" {
+			name = fmt.Sprintf("item-%d", items[i].result.Index+1)
+		}
+
+		fileName := name + "." + format
+		for suffix := 2; taken[fileName]; suffix++ {
+			fileName = fmt.Sprintf("%s-%d.%s", name, suffix, format)
+		}
+		taken[fileName] = true
+
+		items[i].result.Name = fileName
+	}
+}
+
+// writeBatchZip writes the documents in a ZIP archive, with errors.json listing the items that failed if any did.
+func writeBatchZip(items []renderedItem, results []types.BatchItemResult) (*bytes.Buffer, safego.Option[error]) {
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+	now := time.Now()
+
+	for _, item := range items {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: item.result.Name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return &bytes.Buffer{}, safego.Some(err)
+		}
+
+		_, err = io.Copy(file, item.document)
+		if err != nil {
+			return &bytes.Buffer{}, safego.Some(err)
+		}
+	}
+
+	failures := []types.BatchItemResult{}
+	for _, result := range results {
+		if result.Error != "" {
+			failures = append(failures, result)
+		}
+	}
+	if len(failures) > 0 {
+		encoded, err := json.MarshalIndent(failures, "", "  ")
+		if err != nil {
+			return &bytes.Buffer{}, safego.Some(err)
+		}
+
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: "errors.json", Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return &bytes.Buffer{}, safego.Some(err)
+		}
+
+		_, err = file.Write(encoded)
+		if err != nil {
+			return &bytes.Buffer{}, safego.Some(err)
+		}
+	}
+
+	err := archive.Close()
+	if err != nil {
+		return &bytes.Buffer{}, safego.Some(err)
+	}
+
+	return buffer, safego.None[error]()
+}
+
+// mergePdfs concatenates the PDF documents of the items, with a bookmark to the first page of every item.
+func mergePdfs(items []renderedItem) (*bytes.Buffer, safego.Option[error]) {
+	// pdfcpu would read and write its configuration in the home directory otherwise.
+	disablePdfcpuConfigDir.Do(api.DisableConfigDir)
+
+	documents := make([]io.ReadSeeker, len(items))
+	bookmarks := make([]pdfcpu.Bookmark, len(items))
+	page := 1
+	for i, item := range items {
+		documents[i] = bytes.NewReader(item.document.Bytes())
+
+		pages, err := api.PageCount(bytes.NewReader(item.document.Bytes()), nil)
+		if err != nil {
+			return &bytes.Buffer{}, safego.Some(fmt.Errorf("error while reading the document of %s: %v", item.result.Name, err))
+		}
+
+		bookmarks[i] = pdfcpu.Bookmark{
+			Title:    strings.TrimSuffix(item.result.Name, path.Ext(item.result.Name)),
+			PageFrom: page,
+		}
+		page += pages
+	}
+
+	merged := &bytes.Buffer{}
+	err := api.MergeRaw(documents, merged, false, nil)
+	if err != nil {
+		return &bytes.Buffer{}, safego.Some(fmt.Errorf("error while merging the documents: %v", err))
+	}
+
+	bookmarked := &bytes.Buffer{}
+	err = api.AddBookmarks(bytes.NewReader(merged.Bytes()), bookmarked, bookmarks, true, nil)
+	if err != nil {
+		return &bytes.Buffer{}, safego.Some(fmt.Errorf("error while adding the bookmarks: %v", err))
+	}
+
+	return bookmarked, safego.None[error]()
+}
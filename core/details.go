@@ -0,0 +1,215 @@
+package core
+
+import (
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxDetailDepth is how deep {{#detail}} blocks can be nested in each other.
+const MaxDetailDepth = 5
+
+// detailBatchSize is the largest number of keys put in the IN list of a batched query.
+const detailBatchSize = 500
+
+// rowReferenceRegex matches the [R[field]] references of a detail query to the fields of the enclosing row.
+var rowReferenceRegex = regexp.MustCompile(`\[R\[([A-Za-z0-9_]+)\]\]`)
+
+// batchableConditionRegex matches the `column = [R[field]]` condition of a detail query that can be batched into
+// `column IN (...)`.
+var batchableConditionRegex = regexp.MustCompile(`((?:[A-Za-z_][A-Za-z0-9_]*\.)?"?([A-Za-z_][A-Za-z0-9_]*)"?)\s*=\s*\[R\[([A-Za-z0-9_]+)\]\]`)
+
+// detailLoader runs the queries of the {{#detail}} blocks of a render. The template is rendered once per nesting level:
+// the blocks whose rows aren't loaded yet render nothing and record what they need, then load runs one query per
+// detail query for every recorded row, and the template is rendered again with the rows.
+type detailLoader struct {
+	query queryFunc
+	// batches holds the rows of the batched queries by query and by the key of the enclosing row.
+	batches map[string]map[string][]map[string]any
+	// singles holds the rows of the queries that can't be batched by SQL.
+	singles map[string][]map[string]any
+	// pendingKeys holds the enclosing row values the batched queries still need, by query.
+	pendingKeys map[string][]any
+	// pendingQueries holds the SQL of the queries that can't be batched still to run.
+	pendingQueries map[string]bool
+}
+
+func newDetailLoader(query queryFunc) *detailLoader {
+	return &detailLoader{
+		query:          query,
+		batches:        map[string]map[string][]map[string]any{},
+		singles:        map[string][]map[string]any{},
+		pendingKeys:    map[string][]any{},
+		pendingQueries: map[string]bool{},
+	}
+}
+
+// rows returns the rows of the detail query for the enclosing row, or false if they aren't loaded yet.
+func (self *detailLoader) rows(query string, enclosingRow any) ([]map[string]any, bool, error) {
+	references := rowReferenceRegex.FindAllStringSubmatch(query, -1)
+	row, _ := enclosingRow.(map[string]any)
+	if len(references) > 0 && row == nil {
+		return nil, false, fmt.Errorf("[R[...]] references a field of the enclosing row, use the block inside {{#each}} over query rows")
+	}
+
+	condition := batchableConditionRegex.FindStringSubmatch(query)
+	if condition != nil && len(references) == 1 {
+		value, ok := fieldOf(row, condition[3])
+		if !ok {
+			return nil, false, fmt.Errorf("the enclosing row has no %s field", condition[3])
+		}
+
+		key := detailKey(value)
+		if rows, ok := self.batches[query][key]; ok {
+			return rows, true, nil
+		}
+
+		for _, pendingValue := range self.pendingKeys[query] {
+			if detailKey(pendingValue) == key {
+				return nil, false, nil
+			}
+		}
+		self.pendingKeys[query] = append(self.pendingKeys[query], value)
+
+		return nil, false, nil
+	}
+
+	var err error
+	sql := rowReferenceRegex.ReplaceAllStringFunc(query, func(reference string) string {
+		name := rowReferenceRegex.FindStringSubmatch(reference)[1]
+		value, ok := fieldOf(row, name)
+		if !ok && err == nil {
+			err = fmt.Errorf("the enclosing row has no %s field", name)
+		}
+
+		return sqlLiteral(value)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if rows, ok := self.singles[sql]; ok {
+		return rows, true, nil
+	}
+	self.pendingQueries[sql] = true
+
+	return nil, false, nil
+}
+
+// hasPending reports whether blocks are waiting for rows that aren't loaded yet.
+func (self *detailLoader) hasPending() bool {
+	return len(self.pendingKeys) > 0 || len(self.pendingQueries) > 0
+}
+
+// load runs the pending queries, the batched ones once per detailBatchSize keys.
+func (self *detailLoader) load() safego.Option[error] {
+	queries := make([]string, 0, len(self.pendingKeys))
+	for query := range self.pendingKeys {
+		queries = append(queries, query)
+	}
+	sort.Strings(queries)
+
+	for _, query := range queries {
+		condition := batchableConditionRegex.FindStringSubmatch(query)
+		column := condition[2]
+		keys := self.pendingKeys[query]
+
+		if self.batches[query] == nil {
+			self.batches[query] = map[string][]map[string]any{}
+		}
+		groups := self.batches[query]
+
+		for start := 0; start < len(keys); start += detailBatchSize {
+			end := start + detailBatchSize
+			if end > len(keys) {
+				end = len(keys)
+			}
+
+			literals := make([]string, 0, end-start)
+			for _, key := range keys[start:end] {
+				literals = append(literals, sqlLiteral(key))
+				groups[detailKey(key)] = []map[string]any{}
+			}
+
+			sql := strings.Replace(query, condition[0], condition[1]+" IN ("+strings.Join(literals, ", ")+")", 1)
+			rows, errOpt := queryRows(self.query, sql)
+			if errOpt.IsSome() {
+				return errOpt
+			}
+
+			for _, row := range rows {
+				value, ok := fieldOf(row, column)
+				if !ok {
+					return safego.Some(fmt.Errorf("the detail query must select %s to match its rows with the enclosing rows: %s", column, query))
+				}
+
+				key := detailKey(value)
+				groups[key] = append(groups[key], row)
+			}
+		}
+	}
+
+	for sql := range self.pendingQueries {
+		rows, errOpt := queryRows(self.query, sql)
+		if errOpt.IsSome() {
+			return errOpt
+		}
+
+		self.singles[sql] = rows
+	}
+
+	self.pendingKeys = map[string][]any{}
+	self.pendingQueries = map[string]bool{}
+
+	return safego.None[error]()
+}
+
+// fieldOf returns the field of the row, matching its name without case if there's no exact match as some databases
+// change the case of column names.
+func fieldOf(row map[string]any, name string) (any, bool) {
+	if value, ok := row[name]; ok {
+		return value, true
+	}
+
+	for field, value := range row {
+		if strings.EqualFold(field, name) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// detailKey returns the key rows are matched with their enclosing rows by.
+func detailKey(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "\x00null"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// sqlLiteral writes a value of a row as an SQL literal.
+func sqlLiteral(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if value {
+			return "TRUE"
+		}
+		return "FALSE"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int, int64:
+		return fmt.Sprintf("%d", value)
+	}
+
+	return "'" + strings.ReplaceAll(fmt.Sprintf("%v", value), "'", "''") + "'"
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"github.com/okira-e/goreports/helpers"
+	"github.com/okira-e/goreports/types"
+	"html"
+	"strings"
+)
+
+// newLocalization returns the localization of a render in the locale, given the translation tables of the report by
+// locale. A key missing from the locale (fr-CA) falls back to its language (fr), then to the default locale and its
+// language.
+func newLocalization(locale string, defaultLocale string, tables map[string]map[string]string) types.Localization {
+	if defaultLocale == "" {
+		defaultLocale = helpers.DefaultLocale
+	}
+
+	localization := types.Localization{
+		Locale:       locale,
+		Translations: map[string]string{},
+	}
+
+	// Merge the tables from the least to the most specific one.
+	fallbacks := []string{helpers.LanguageOf(defaultLocale), defaultLocale}
+	if locale != "" {
+		fallbacks = append(fallbacks, helpers.LanguageOf(locale), locale)
+	}
+	for _, fallback := range fallbacks {
+		for tableLocale, table := range tables {
+			if !strings.EqualFold(tableLocale, fallback) {
+				continue
+			}
+			for key, value := range table {
+				localization.Translations[key] = value
+			}
+		}
+	}
+
+	return localization
+}
+
+// htmlAttributesOf returns the lang and dir attributes of the html element of a document in the locale, or nothing
+// when there's no locale.
+func htmlAttributesOf(localization types.Localization) string {
+	if localization.Locale == "" {
+		return ""
+	}
+
+	dir := "ltr"
+	if helpers.IsRightToLeft(localization.Locale) {
+		dir = "rtl"
+	}
+
+	return " lang=\"" + html.EscapeString(localization.Locale) + "\" dir=\"" + dir + "\""
+}
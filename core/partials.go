@@ -0,0 +1,145 @@
+package core
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// partialNameRegex matches the names a template can include a partial by.
+var partialNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// partialReferenceRegex matches {{> name}} and {{#extends "name"}}.
+var partialReferenceRegex = regexp.MustCompile(`\{\{~?\s*(?:>\s*"?([A-Za-z0-9_-]+)|#\s*extends\s+["']([A-Za-z0-9_-]+)["'])`)
+
+// PartialReferences returns the names of the partials a template includes or extends, in order of appearance.
+func PartialReferences(template string) []string {
+	names := []string{}
+	for _, match := range partialReferenceRegex.FindAllStringSubmatch(template, -1) {
+		name := match[1] + match[2]
+		if !utils.ContainsString(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// ValidatePartial checks a partial before it's saved with the others: its name, its handlebars and that it doesn't
+// include itself, directly or through other partials.
+func ValidatePartial(partial types.Partial, partials []types.Partial) safego.Option[error] {
+	if !partialNameRegex.MatchString(partial.Name) {
+		return safego.Some(fmt.Errorf("invalid partial name %q, use letters, digits, '_' and '-'", partial.Name))
+	}
+
+	_, err := raymond.Parse(partial.Body)
+	if err != nil {
+		return safego.Some(fmt.Errorf("invalid handlebars: %v", err))
+	}
+
+	sources := partialSources(partials)
+	sources[partial.Name] = partial.Body
+
+	cycle := findPartialCycle(sources)
+	if len(cycle) > 0 {
+		return safego.Some(fmt.Errorf("the partials include each other: %s", strings.Join(cycle, " > ")))
+	}
+
+	return safego.None[error]()
+}
+
+// DependentReports returns the names of the reports whose body uses the partial, directly or through other partials.
+func DependentReports(name string, reports []types.Report, partials []types.Partial) []string {
+	sources := partialSources(partials)
+
+	dependents := []string{}
+	for _, report := range reports {
+		if usesPartial(report.Body, name, sources, map[string]bool{}) {
+			dependents = append(dependents, report.Name)
+		}
+	}
+	sort.Strings(dependents)
+
+	return dependents
+}
+
+// usesPartial reports whether the template uses the partial. visited holds the partials already looked into.
+func usesPartial(template string, name string, sources map[string]string, visited map[string]bool) bool {
+	for _, reference := range PartialReferences(template) {
+		if reference == name {
+			return true
+		}
+		if visited[reference] {
+			continue
+		}
+
+		visited[reference] = true
+		if usesPartial(sources[reference], name, sources, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// partialSources returns the bodies of the partials by name.
+func partialSources(partials []types.Partial) map[string]string {
+	sources := map[string]string{}
+	for _, partial := range partials {
+		sources[partial.Name] = partial.Body
+	}
+
+	return sources
+}
+
+// findPartialCycle returns a chain of partials that include each other, e.g. [a b a], or nil if there's none.
+// Rendering such partials would never end.
+func findPartialCycle(sources map[string]string) []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	done := map[string]bool{}
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		for i, pathName := range path {
+			if pathName == name {
+				return append(append([]string{}, path[i:]...), name)
+			}
+		}
+		if done[name] {
+			return nil
+		}
+
+		path = append(path, name)
+		for _, reference := range PartialReferences(sources[name]) {
+			if _, ok := sources[reference]; !ok {
+				continue
+			}
+			if cycle := visit(reference); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		done[name] = true
+
+		return nil
+	}
+
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
@@ -2,17 +2,19 @@ package core
 
 import (
 	"bytes"
+	"context"
 	pdf "github.com/SebastiaanKlippert/go-wkhtmltopdf"
 	"github.com/okira-e/goreports/safego"
 	"github.com/okira-e/goreports/types"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
 // GeneratePDFFromHtml generates a PDF from HTML.
 // It takes in HTML as a string and printing options of type types.PrintingOptions
-// It returns a buffer and an error.
-func GeneratePDFFromHtml(reportParams types.ReportAttributesForPdfGenerator, printingOptions types.PrintingOptions) (*bytes.Buffer, safego.Option[error]) {
+// It returns a buffer and an error. wkhtmltopdf is killed when the context is done.
+func GeneratePDFFromHtml(ctx context.Context, reportParams types.ReportAttributesForPdfGenerator, printingOptions types.PrintingOptions) (*bytes.Buffer, safego.Option[error]) {
 	// Create new PDF generator
 	pdfGenerator, err := pdf.NewPDFGenerator()
 	if err != nil {
@@ -40,25 +42,32 @@ func GeneratePDFFromHtml(reportParams types.ReportAttributesForPdfGenerator, pri
 
 	pdfGenerator.Title.Set(reportParams.Title)
 
-	page := pdf.NewPageReader(strings.NewReader(reportParams.Body))
+	body := reportParams.Body
+	if reportParams.HtmlAttributes != "" {
+		body = wrapInDocument(body, reportParams.HtmlAttributes)
+	}
+
+	page := pdf.NewPageReader(strings.NewReader(body))
 
 	// Setup repeating header if provided.
 	if reportParams.Header.IsSome() && (!printingOptions.PageNumbers.Enabled || !strings.Contains(printingOptions.PageNumbers.Position, "top")) {
-		err = os.WriteFile("./core/header_temp.html", []byte("<!doctype html>"+reportParams.Header.Unwrap()), 0644)
-		if err != nil {
-			return &bytes.Buffer{}, safego.Some(err)
+		headerPath, errOpt := writeTempDocument("header", wrapInDocument(reportParams.Header.Unwrap(), reportParams.HtmlAttributes))
+		if errOpt.IsSome() {
+			return &bytes.Buffer{}, errOpt
 		}
+		defer os.Remove(headerPath)
 
-		page.HeaderHTML.Set("file:///" + os.Getenv("PWD") + "/core/header_temp.html")
+		page.HeaderHTML.Set("file://" + headerPath)
 	}
 	// Setup repeating footer if provided and page numbers are not enabled.
 	if reportParams.Footer.IsSome() && (!printingOptions.PageNumbers.Enabled || !strings.Contains(printingOptions.PageNumbers.Position, "bottom")) {
-		err = os.WriteFile("./core/footer_temp.html", []byte("<!doctype html>"+reportParams.Footer.Unwrap()), 0644)
-		if err != nil {
-			return &bytes.Buffer{}, safego.Some(err)
+		footerPath, errOpt := writeTempDocument("footer", wrapInDocument(reportParams.Footer.Unwrap(), reportParams.HtmlAttributes))
+		if errOpt.IsSome() {
+			return &bytes.Buffer{}, errOpt
 		}
+		defer os.Remove(footerPath)
 
-		page.FooterHTML.Set("file:///" + os.Getenv("PWD") + "/core/footer_temp.html")
+		page.FooterHTML.Set("file://" + footerPath)
 	}
 
 	// Setup page numbers if enabled.
@@ -86,14 +95,55 @@ func GeneratePDFFromHtml(reportParams types.ReportAttributesForPdfGenerator, pri
 
 	// Create PDF document in internal buffer
 	pdfGenerator.AddPage(page)
-	err = pdfGenerator.Create()
+	err = pdfGenerator.CreateContext(ctx)
 	if err != nil {
 		return &bytes.Buffer{}, safego.Some(err)
 	}
 
-	os.Remove("./core/header_temp.html")
-	os.Remove("./core/footer_temp.html")
-
 	// Send file in response
 	return pdfGenerator.Buffer(), safego.None[error]()
 }
+
+// wrapInDocument makes a standalone HTML document of a fragment. The header and footer are printed from their own
+// documents, so they need to be complete.
+func wrapInDocument(fragment string, htmlAttributes string) string {
+	if htmlAttributes == "" {
+		return "<!doctype html>" + fragment
+	}
+
+	return "<!doctype html><html" + htmlAttributes + "><head><meta charset=\"utf-8\"></head><body>" + fragment + "</body></html>"
+}
+
+// writeTempDocument writes the document to a temporary file of its own, so that concurrent renders don't overwrite
+// each other's header and footer, and returns its absolute path. The caller is responsible for removing it.
+func writeTempDocument(kind string, document string) (string, safego.Option[error]) {
+	file, err := os.CreateTemp("", "goreports-"+kind+"-*.html")
+	if err != nil {
+		return "", safego.Some(err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(document)
+	if err != nil {
+		os.Remove(file.Name())
+		return "", safego.Some(err)
+	}
+
+	path, err := filepath.Abs(file.Name())
+	if err != nil {
+		os.Remove(file.Name())
+		return "", safego.Some(err)
+	}
+
+	return path, safego.None[error]()
+}
+
+// CheckPDFGenerator returns an error if the PDF documents can't be rendered because wkhtmltopdf is not found.
+func CheckPDFGenerator() safego.Option[error] {
+	_, err := pdf.NewPDFGenerator()
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	return safego.None[error]()
+}
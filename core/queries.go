@@ -0,0 +1,209 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"log"
+	"time"
+)
+
+// queryResult is the result of a query of a report.
+type queryResult struct {
+	// Columns are the column names, in select order.
+	Columns []string `json:"columns"`
+	// Rows are the rows as written by utils.JsonifyQueryRows, JSON objects separated by "," entries.
+	Rows []string `json:"rows"`
+}
+
+// queryFunc runs the query of a report.
+type queryFunc func(query string) (queryResult, safego.Option[error])
+
+// DefaultQueryTimeout is how long a report query can run when the config doesn't say.
+const DefaultQueryTimeout = time.Minute
+
+// queryPolicy is how the queries of a report run.
+type queryPolicy struct {
+	limits types.QueryLimits
+	// allowWrites runs the queries outside of read-only transactions.
+	allowWrites bool
+	// checkStatements rejects the queries that aren't a single SELECT statement before running them. The queries with
+	// more than one statement are always rejected.
+	checkStatements bool
+}
+
+// directQuery returns a queryFunc running the queries against the data source as the policy says, until the context is
+// done.
+func directQuery(ctx context.Context, ds *datasource.DataSource, policy queryPolicy) queryFunc {
+	return func(query string) (queryResult, safego.Option[error]) {
+		checkStatement := checkSingleStatement
+		if policy.checkStatements {
+			checkStatement = checkSelectStatement
+		}
+		errOpt := checkStatement(query)
+		if errOpt.IsSome() {
+			return queryResult{}, safego.Some[error](&TemplateError{Message: "The query is rejected: " + errOpt.Unwrap().Error()})
+		}
+
+		var queryCtx context.Context
+		var cancel context.CancelFunc
+		if policy.limits.TimeoutSeconds > 0 {
+			queryCtx, cancel = context.WithTimeout(ctx, time.Duration(policy.limits.TimeoutSeconds)*time.Second)
+		} else {
+			queryCtx, cancel = context.WithCancel(ctx)
+		}
+		defer cancel()
+
+		result := queryResult{}
+		read := func(rows *sql.Rows) safego.Option[error] {
+			columns, err := rows.Columns()
+			if err != nil {
+				return safego.Some(err)
+			}
+
+			data, errOpt := utils.JsonifyQueryRows(rows, policy.limits.MaxRows)
+			if errOpt.IsSome() {
+				return errOpt
+			}
+
+			result = queryResult{Columns: columns, Rows: data}
+
+			return safego.None[error]()
+		}
+
+		if policy.allowWrites {
+			var rows *sql.Rows
+			rows, errOpt = (*ds).QueryContext(queryCtx, query)
+			if errOpt.IsNone() {
+				errOpt = read(rows)
+				rows.Close()
+			}
+		} else {
+			errOpt = (*ds).ReadOnlyQueryContext(queryCtx, read, query)
+		}
+		if errOpt.IsSome() {
+			return queryResult{}, explainQueryError(ctx, queryCtx, errOpt.Unwrap(), policy.limits)
+		}
+
+		return result, safego.None[error]()
+	}
+}
+
+// explainQueryError tells the query stopped because of its limits or because the render was canceled, the drivers only
+// report that the context is done.
+func explainQueryError(ctx context.Context, queryCtx context.Context, err error, limits types.QueryLimits) safego.Option[error] {
+	switch {
+	case errors.Is(err, utils.ErrTooManyRows):
+		return safego.Some(fmt.Errorf("the query returned more than %d rows, the most the queries of this report can return", limits.MaxRows))
+	case ctx.Err() != nil:
+		return safego.Some(fmt.Errorf("the render was canceled: %v", ctx.Err()))
+	case errors.Is(queryCtx.Err(), context.DeadlineExceeded):
+		return safego.Some(fmt.Errorf("the query was canceled after %ds, the timeout of the queries of this report", limits.TimeoutSeconds))
+	}
+
+	return safego.Some(err)
+}
+
+// limitsOf returns the limits of the queries of the report, with the defaults applied.
+func (self *Renderer) limitsOf(reportName string) types.QueryLimits {
+	limits := self.QueryLimits.QueryLimits
+	if reportLimits, ok := self.QueryLimits.Reports[reportName]; ok {
+		if reportLimits.TimeoutSeconds != 0 {
+			limits.TimeoutSeconds = reportLimits.TimeoutSeconds
+		}
+		if reportLimits.MaxRows != 0 {
+			limits.MaxRows = reportLimits.MaxRows
+		}
+	}
+
+	if limits.TimeoutSeconds == 0 {
+		limits.TimeoutSeconds = int(DefaultQueryTimeout / time.Second)
+	}
+
+	return limits
+}
+
+// policyOf returns how the queries of the report run.
+func (self *Renderer) policyOf(reportName string) queryPolicy {
+	return queryPolicy{
+		limits:          self.limitsOf(reportName),
+		allowWrites:     self.AllowWrites,
+		checkStatements: self.CheckStatements,
+	}
+}
+
+// queryFor returns the queryFunc of the queries of the report: they run against the external database as the policy of
+// the report says, through the query cache if one is set. The cache failing is logged and doesn't fail the render.
+func (self *Renderer) queryFor(ctx context.Context, reportName string) queryFunc {
+	query := directQuery(ctx, self.ExternalDb, self.policyOf(reportName))
+	if self.QueryCache.IsNone() {
+		return query
+	}
+	cache := self.QueryCache.Unwrap()
+
+	return func(sql string) (queryResult, safego.Option[error]) {
+		ttl := cache.TtlOf(reportName, sql)
+		if ttl <= 0 {
+			return query(sql)
+		}
+
+		key := cache.Key(sql)
+		cached, found, errOpt := cache.Get(reportName, key)
+		if errOpt.IsSome() {
+			log.Printf("error while reading the query cache: %v", errOpt.Unwrap())
+		}
+		if found {
+			result := queryResult{}
+			if json.Unmarshal(cached, &result) == nil {
+				return result, safego.None[error]()
+			}
+		}
+
+		result, errOpt := query(sql)
+		if errOpt.IsSome() {
+			return queryResult{}, errOpt
+		}
+
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return queryResult{}, safego.Some(err)
+		}
+		errOpt = cache.Set(reportName, key, encoded, ttl)
+		if errOpt.IsSome() {
+			log.Printf("error while writing the query cache: %v", errOpt.Unwrap())
+		}
+
+		return result, safego.None[error]()
+	}
+}
+
+// queryRows runs the query and returns its rows.
+func queryRows(query queryFunc, sql string) ([]map[string]any, safego.Option[error]) {
+	result, errOpt := query(sql)
+	if errOpt.IsSome() {
+		return nil, errOpt
+	}
+
+	rows := []map[string]any{}
+	for _, encodedRow := range result.Rows {
+		if encodedRow == "," {
+			continue
+		}
+
+		var row map[string]any
+		err := json.Unmarshal([]byte(encodedRow), &row)
+		if err != nil {
+			return nil, safego.Some(err)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, safego.None[error]()
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"github.com/okira-e/goreports/datasource"
+	"strings"
+	"testing"
+)
+
+func TestDirectQueryRejectsSeveralStatements(t *testing.T) {
+	cases := []struct {
+		name            string
+		query           string
+		checkStatements bool
+		allowWrites     bool
+		wantRejected    string
+	}{
+		{name: "commit then write", query: "SELECT 1; COMMIT; DELETE FROM payments", wantRejected: "more than one statement"},
+		{name: "commit then write with writes allowed", query: "SELECT 1; COMMIT; DELETE FROM payments", allowWrites: true, wantRejected: "more than one statement"},
+		{name: "commit then write with statements checked", query: "SELECT 1; COMMIT; DELETE FROM payments", checkStatements: true, wantRejected: "more than one statement"},
+		{name: "trailing semicolon", query: "SELECT 1;"},
+		{name: "semicolon in a literal", query: "SELECT 'a; DELETE FROM payments'"},
+		{name: "write without check statements", query: "DELETE FROM payments"},
+		{name: "write with statements checked", query: "DELETE FROM payments", checkStatements: true, wantRejected: "only SELECT statements are allowed"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fake := &fakeDataSource{}
+			var ds datasource.DataSource = fake
+			query := directQuery(context.Background(), &ds, queryPolicy{checkStatements: testCase.checkStatements, allowWrites: testCase.allowWrites})
+
+			_, errOpt := query(testCase.query)
+
+			if testCase.wantRejected == "" {
+				if len(fake.queries) != 1 {
+					t.Errorf("got the queries %v run, want the query run", fake.queries)
+				}
+				return
+			}
+			if errOpt.IsNone() || !strings.Contains(errOpt.Unwrap().Error(), testCase.wantRejected) {
+				t.Errorf("got %v, want the query rejected because of %q", errOpt, testCase.wantRejected)
+			}
+			if len(fake.queries) != 0 {
+				t.Errorf("got the queries %v run, want none", fake.queries)
+			}
+		})
+	}
+}
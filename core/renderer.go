@@ -0,0 +1,548 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/okira-e/goreports/assets"
+	"github.com/okira-e/goreports/bundle"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/outputcache"
+	"github.com/okira-e/goreports/querycache"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxSubreportDepth is how deep sub-reports can embed other sub-reports.
+const MaxSubreportDepth = 5
+
+// The formats a report can be rendered into.
+const (
+	FormatPdf  = "pdf"
+	FormatHtml = "html"
+	FormatCsv  = "csv"
+)
+
+// SupportedFormats lists the formats accepted by Renderer.Render.
+var SupportedFormats = []string{FormatPdf, FormatHtml, FormatCsv}
+
+// ContentTypeOf returns the MIME type of a rendered format.
+func ContentTypeOf(format string) string {
+	switch format {
+	case FormatHtml:
+		return "text/html; charset=utf-8"
+	case FormatCsv:
+		return "text/csv; charset=utf-8"
+	}
+
+	return "application/pdf"
+}
+
+// TemplateError is returned when a report can't be rendered because of its template or the given parameters.
+type TemplateError struct {
+	Message string
+}
+
+func (self *TemplateError) Error() string {
+	return self.Message
+}
+
+// IsTemplateError reports whether the error was caused by the template or the given parameters.
+func IsTemplateError(err error) bool {
+	var templateError *TemplateError
+
+	return errors.As(err, &templateError)
+}
+
+// Renderer renders reports with the databases and settings of a GoReports instance.
+type Renderer struct {
+	// InternalDb holds the translations and the assets of the reports.
+	InternalDb *datasource.DataSource
+	// ExternalDb is queried by the [Q[...]] directives.
+	ExternalDb *datasource.DataSource
+	// DefaultLocale is the locale the translations fall back to.
+	DefaultLocale string
+	// ReportsDirectory holds the read-only reports the sub-reports are looked up in before the internal database.
+	ReportsDirectory safego.Option[*bundle.Directory]
+	// QueryLimits bound the queries of the reports.
+	QueryLimits types.QueryLimitsConfig
+	// AllowWrites runs the queries of the reports outside of read-only transactions.
+	AllowWrites bool
+	// CheckStatements rejects the queries of the reports that aren't a single SELECT statement.
+	CheckStatements bool
+	// QueryCache caches the results of the queries of the reports, if set.
+	QueryCache safego.Option[*querycache.Cache]
+	// OutputCache caches the rendered documents, if set.
+	OutputCache safego.Option[*outputcache.Cache]
+}
+
+// Render evaluates the directives of the report and renders it into the given format:
+//   - pdf: the body is rendered with handlebars and printed by wkhtmltopdf.
+//   - html: the rendered header, body and footer in a standalone HTML document.
+//   - csv: the rows of every multi-row query, one table after the other separated by an empty line.
+//
+// The helpers format values in the locale, {{t}} writes the translations of the report in that locale and the
+// documents are written from right to left in the locales that need it. An empty locale renders in the default one.
+// The body can include the stored partials, extend the stored layouts and embed the bodies of other reports with
+// {{subreport}}. The asset:// references of the documents are replaced with the data of the assets.
+// The queries run within the limits of the report and are canceled, as is wkhtmltopdf, when the context is done.
+func (self *Renderer) Render(ctx context.Context, report types.Report, params map[string]any, printingOptions types.PrintingOptions, format string, locale string) (*bytes.Buffer, safego.Option[error]) {
+	document, _, errOpt := self.RenderWithETag(ctx, report, params, printingOptions, format, locale)
+
+	return document, errOpt
+}
+
+// RenderWithETag renders the report like Render and also returns the entity tag of the document. If an output cache is
+// set, the document is reused while the report, its partials, its translations, the sub-reports and the assets it
+// embeds and the render inputs are the same, until the TTL of the report.
+func (self *Renderer) RenderWithETag(ctx context.Context, report types.Report, params map[string]any, printingOptions types.PrintingOptions, format string, locale string) (*bytes.Buffer, string, safego.Option[error]) {
+	if self.OutputCache.IsNone() || self.OutputCache.Unwrap().TtlOf(report.Name) <= 0 {
+		document, _, errOpt := self.render(ctx, report, params, printingOptions, format, locale)
+		if errOpt.IsSome() {
+			return &bytes.Buffer{}, "", errOpt
+		}
+
+		return document, outputcache.ETagOf(document.Bytes()), safego.None[error]()
+	}
+	cache := self.OutputCache.Unwrap()
+
+	key, errOpt := self.outputKey(report, params, printingOptions, format, locale)
+	if errOpt.IsSome() {
+		return &bytes.Buffer{}, "", errOpt
+	}
+	isCurrent := func(dependencies outputcache.Dependencies) bool {
+		checksum, errOpt := self.dependenciesChecksum(dependencies.Names)
+
+		return errOpt.IsNone() && checksum == dependencies.Checksum
+	}
+	if cached, etag, found := cache.Get(key, isCurrent); found {
+		return bytes.NewBuffer(append([]byte{}, cached...)), etag, safego.None[error]()
+	}
+
+	document, dependencyNames, errOpt := self.render(ctx, report, params, printingOptions, format, locale)
+	if errOpt.IsSome() {
+		return &bytes.Buffer{}, "", errOpt
+	}
+	checksum, errOpt := self.dependenciesChecksum(dependencyNames)
+	if errOpt.IsSome() {
+		return &bytes.Buffer{}, "", errOpt
+	}
+	etag := outputcache.ETagOf(document.Bytes())
+	dependencies := outputcache.Dependencies{Names: dependencyNames, Checksum: checksum}
+	cache.Set(key, report.Name, append([]byte{}, document.Bytes()...), etag, dependencies, cache.TtlOf(report.Name))
+
+	return document, etag, safego.None[error]()
+}
+
+// outputKey returns the key of the document in the output cache: the hash of the revision of the report, made of the
+// report, the partials and its translations, and of the render inputs. The sub-reports and the assets embedded are only
+// known once the document is rendered, they are checked by dependenciesChecksum.
+func (self *Renderer) outputKey(report types.Report, params map[string]any, printingOptions types.PrintingOptions, format string, locale string) (string, safego.Option[error]) {
+	partials, errOpt := internalDb.ListPartials(self.InternalDb)
+	if errOpt.IsSome() {
+		return "", errOpt
+	}
+	translations, errOpt := internalDb.ListTranslations(self.InternalDb, report.Name)
+	if errOpt.IsSome() {
+		return "", errOpt
+	}
+
+	// The partials are listed by name and the translations by locale, and maps are encoded with sorted keys.
+	encoded, err := json.Marshal(map[string]any{
+		"report":          report,
+		"partials":        partialSources(partials),
+		"translations":    translations,
+		"params":          params,
+		"printingOptions": printingOptions,
+		"format":          format,
+		"locale":          locale,
+		"defaultLocale":   self.DefaultLocale,
+	})
+	if err != nil {
+		return "", safego.Some(err)
+	}
+	hash := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(hash[:]), safego.None[error]()
+}
+
+// render renders the report, see Render. It also returns the names of the dependencies of the document, see
+// dependenciesChecksum.
+func (self *Renderer) render(ctx context.Context, report types.Report, params map[string]any, printingOptions types.PrintingOptions, format string, locale string) (*bytes.Buffer, []string, safego.Option[error]) {
+	if !utils.ContainsString(SupportedFormats, format) {
+		return &bytes.Buffer{}, nil, safego.Some[error](&TemplateError{Message: fmt.Sprintf("Unsupported format %s.", format)})
+	}
+
+	if format == FormatCsv {
+		params, errOpt := applyParameterDefaults(report, params)
+		if errOpt.IsSome() {
+			return &bytes.Buffer{}, nil, errOpt
+		}
+
+		_, queries, columns, errMsgOpt := parseTemplate(report.Body, params, self.queryFor(ctx, report.Name))
+		if errMsgOpt.IsSome() {
+			return &bytes.Buffer{}, nil, safego.Some[error](&TemplateError{Message: errMsgOpt.Unwrap()})
+		}
+
+		document, errOpt := writeQueriesAsCsv(queries, columns)
+
+		return document, nil, errOpt
+	}
+
+	partials, errOpt := internalDb.ListPartials(self.InternalDb)
+	if errOpt.IsSome() {
+		return &bytes.Buffer{}, nil, errOpt
+	}
+	sources := partialSources(partials)
+	if cycle := findPartialCycle(sources); cycle != nil {
+		return &bytes.Buffer{}, nil, safego.Some[error](&TemplateError{Message: "The partials include each other: " + strings.Join(cycle, " > ")})
+	}
+
+	state := &renderState{
+		ctx:           ctx,
+		locale:        locale,
+		partials:      sources,
+		reports:       map[string]types.Report{report.Name: report},
+		localizations: map[string]types.Localization{},
+		subreports:    map[string]string{},
+		dependencies:  map[string]bool{},
+	}
+	compiledTemplate, localization, errOpt := self.renderBody(report.Name, params, []string{report.Name}, state)
+	if errOpt.IsSome() {
+		return &bytes.Buffer{}, nil, errOpt
+	}
+
+	// Embed the assets of the header and the footer, the ones of the body are embedded by renderBody.
+	for _, document := range []*string{&report.Header, &report.Footer} {
+		state.addAssetDependencies(report.Name, *document)
+		*document, errOpt = assets.ResolveReferences(self.InternalDb, report.Name, *document)
+		if errOpt.IsSome() {
+			return &bytes.Buffer{}, nil, safego.Some[error](&TemplateError{Message: errOpt.Unwrap().Error()})
+		}
+	}
+
+	dependencyNames := make([]string, 0, len(state.dependencies))
+	for name := range state.dependencies {
+		dependencyNames = append(dependencyNames, name)
+	}
+	sort.Strings(dependencyNames)
+
+	if format == FormatHtml {
+		document := "<!doctype html><html" + htmlAttributesOf(localization) + "><head><meta charset=\"utf-8\"><title>" + html.EscapeString(report.Title) + "</title></head><body>" +
+			report.Header + compiledTemplate + report.Footer +
+			"</body></html>"
+
+		return bytes.NewBufferString(document), dependencyNames, safego.None[error]()
+	}
+
+	// Generate the document
+	header, footer := safego.None[string](), safego.None[string]()
+
+	if report.Header != "" {
+		header = safego.Some(report.Header)
+	}
+	if report.Footer != "" {
+		footer = safego.Some(report.Footer)
+	}
+
+	reportGeneratorParams := types.ReportAttributesForPdfGenerator{
+		Title:          report.Title,
+		Body:           compiledTemplate,
+		Header:         header,
+		Footer:         footer,
+		HtmlAttributes: htmlAttributesOf(localization),
+	}
+
+	document, errOpt := GeneratePDFFromHtml(ctx, reportGeneratorParams, printingOptions)
+
+	return document, dependencyNames, errOpt
+}
+
+// renderState is shared by a report being rendered and the sub-reports it embeds.
+type renderState struct {
+	// ctx cancels the queries of the render.
+	ctx      context.Context
+	locale   string
+	partials map[string]string
+	// reports and localizations cache the reports and their localization by name, for the sub-reports embedded once
+	// per row.
+	reports       map[string]types.Report
+	localizations map[string]types.Localization
+	// subreports caches the rendered sub-reports by name and parameters.
+	subreports map[string]string
+	// dependencies holds the names of the sub-reports and the assets embedded, see dependenciesChecksum.
+	dependencies map[string]bool
+}
+
+// The prefixes of the dependency names of a document.
+const (
+	reportDependencyPrefix = "report:"
+	assetDependencyPrefix  = "asset:"
+)
+
+// addAssetDependencies adds the assets the asset:// references of the document of the report point to.
+func (self *renderState) addAssetDependencies(reportName string, document string) {
+	for _, name := range assets.ReferencesOf(document) {
+		self.dependencies[assetDependencyPrefix+reportName+"\x00"+name] = true
+	}
+}
+
+// dependenciesChecksum returns the checksum of the current revision of the dependencies of a document: the sub-reports
+// with their translations, and the assets as the report embedding them sees them.
+func (self *Renderer) dependenciesChecksum(names []string) (string, safego.Option[error]) {
+	revisions := make([]any, 0, len(names))
+
+	for _, name := range names {
+		if reportName, found := strings.CutPrefix(name, reportDependencyPrefix); found {
+			reportOpt, errOpt := self.findReport(reportName)
+			if errOpt.IsSome() {
+				return "", errOpt
+			}
+			translations, errOpt := internalDb.ListTranslations(self.InternalDb, reportName)
+			if errOpt.IsSome() {
+				return "", errOpt
+			}
+			// A missing report is encoded as null.
+			var report *types.Report
+			if reportOpt.IsSome() {
+				found := reportOpt.Unwrap()
+				report = &found
+			}
+			revisions = append(revisions, []any{report, translations})
+		} else if reference, found := strings.CutPrefix(name, assetDependencyPrefix); found {
+			reportName, assetName, _ := strings.Cut(reference, "\x00")
+			revision, errOpt := assets.RevisionOf(self.InternalDb, reportName, assetName)
+			if errOpt.IsSome() {
+				return "", errOpt
+			}
+			revisions = append(revisions, revision)
+		}
+	}
+
+	encoded, err := json.Marshal(revisions)
+	if err != nil {
+		return "", safego.Some(err)
+	}
+	hash := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(hash[:]), safego.None[error]()
+}
+
+// renderBody evaluates the directives of the body of the report and renders it with handlebars, with its sub-reports
+// and its assets embedded. chain lists the reports being rendered, from the top level one to this one.
+func (self *Renderer) renderBody(reportName string, params map[string]any, chain []string, state *renderState) (string, types.Localization, safego.Option[error]) {
+	report, ok := state.reports[reportName]
+	if !ok {
+		reportOpt, errOpt := self.findReport(reportName)
+		if errOpt.IsSome() {
+			return "", types.Localization{}, errOpt
+		}
+		if reportOpt.IsNone() {
+			return "", types.Localization{}, safego.Some[error](&TemplateError{Message: fmt.Sprintf("Report %s was not found.", reportName)})
+		}
+
+		report = reportOpt.Unwrap()
+		state.reports[reportName] = report
+		state.dependencies[reportDependencyPrefix+reportName] = true
+	}
+
+	localization, ok := state.localizations[reportName]
+	if !ok {
+		tables, errOpt := internalDb.ListTranslations(self.InternalDb, reportName)
+		if errOpt.IsSome() {
+			return "", types.Localization{}, errOpt
+		}
+
+		localization = newLocalization(state.locale, self.DefaultLocale, tables)
+		state.localizations[reportName] = localization
+	}
+
+	params, errOpt := applyParameterDefaults(report, params)
+	if errOpt.IsSome() {
+		return "", types.Localization{}, errOpt
+	}
+
+	handlebarsTemplate, queries, _, errMsgOpt := parseTemplate(report.Body, params, self.queryFor(state.ctx, report.Name))
+	if errMsgOpt.IsSome() {
+		return "", types.Localization{}, safego.Some[error](&TemplateError{Message: errMsgOpt.Unwrap()})
+	}
+
+	renderSubreport := func(name string, params map[string]any) (string, error) {
+		if utils.ContainsString(chain, name) {
+			return "", fmt.Errorf("the reports embed each other: %s > %s", strings.Join(chain, " > "), name)
+		}
+		if len(chain) > MaxSubreportDepth {
+			return "", fmt.Errorf("sub-reports can't be nested more than %d levels deep: %s > %s", MaxSubreportDepth, strings.Join(chain, " > "), name)
+		}
+
+		// The same sub-report is often embedded with the same parameters, e.g. once per row or once per pass of the
+		// detail blocks.
+		encodedParams, err := json.Marshal(params)
+		if err != nil {
+			return "", err
+		}
+		cacheKey := name + "\x00" + string(encodedParams)
+		if body, ok := state.subreports[cacheKey]; ok {
+			return body, nil
+		}
+
+		body, _, errOpt := self.renderBody(name, params, append(append([]string{}, chain...), name), state)
+		if errOpt.IsSome() {
+			return "", errOpt.Unwrap()
+		}
+		state.subreports[cacheKey] = body
+
+		return body, nil
+	}
+
+	// Parse the template in handlebars, once more for every level of {{#detail}} blocks whose rows have to be loaded.
+	details := newDetailLoader(self.queryFor(state.ctx, report.Name))
+	privateData := map[string]any{
+		"locale":          localization.Locale,
+		"translations":    localization.Translations,
+		"renderSubreport": renderSubreport,
+		"loadDetail":      details.rows,
+	}
+
+	var compiledTemplate string
+	for level := 0; ; level++ {
+		compiledTemplate, errOpt = utils.ParseHandleBarsWithPartials(handlebarsTemplate, queries, privateData, state.partials)
+		if errOpt.IsSome() {
+			return "", types.Localization{}, errOpt
+		}
+		if !details.hasPending() {
+			break
+		}
+		if level == MaxDetailDepth {
+			return "", types.Localization{}, safego.Some[error](&TemplateError{Message: fmt.Sprintf("{{#detail}} blocks can't be nested more than %d levels deep.", MaxDetailDepth)})
+		}
+
+		errOpt = details.load()
+		if errOpt.IsSome() {
+			return "", types.Localization{}, errOpt
+		}
+	}
+
+	// Embed the assets.
+	state.addAssetDependencies(report.Name, compiledTemplate)
+	compiledTemplate, errOpt = assets.ResolveReferences(self.InternalDb, report.Name, compiledTemplate)
+	if errOpt.IsSome() {
+		return "", types.Localization{}, safego.Some[error](&TemplateError{Message: errOpt.Unwrap().Error()})
+	}
+
+	return compiledTemplate, localization, safego.None[error]()
+}
+
+// findReport returns the report with the given name from the reports directory, or else from the internal database.
+func (self *Renderer) findReport(name string) (safego.Option[types.Report], safego.Option[error]) {
+	if self.ReportsDirectory.IsSome() {
+		reportOpt := self.ReportsDirectory.Unwrap().Get(name)
+		if reportOpt.IsSome() {
+			return reportOpt, safego.None[error]()
+		}
+	}
+
+	return internalDb.GetReport(self.InternalDb, name)
+}
+
+// ResolvePrintingOptions returns the requested printing options, falling back to the defaults of the report.
+func ResolvePrintingOptions(report types.Report, requested *types.PrintingOptions) types.PrintingOptions {
+	if requested != nil {
+		return *requested
+	}
+	if report.PrintingOptions != nil {
+		return *report.PrintingOptions
+	}
+
+	return types.PrintingOptions{}
+}
+
+// applyParameterDefaults returns a copy of the params completed with the defaults of the report parameters.
+// It fails if a required parameter is missing.
+func applyParameterDefaults(report types.Report, params map[string]any) (map[string]any, safego.Option[error]) {
+	completedParams := make(map[string]any, len(params))
+	for name, value := range params {
+		completedParams[name] = value
+	}
+
+	for _, parameter := range report.Parameters {
+		if _, ok := completedParams[parameter.Name]; ok {
+			continue
+		}
+
+		if parameter.Default != nil {
+			completedParams[parameter.Name] = parameter.Default
+		} else if parameter.Required {
+			return completedParams, safego.Some[error](&TemplateError{Message: fmt.Sprintf("Parameter %s is not provided.", parameter.Name)})
+		}
+	}
+
+	return completedParams, safego.None[error]()
+}
+
+// writeQueriesAsCsv writes every multi-row query result as a CSV table, in the order of the queries in the template.
+func writeQueriesAsCsv(queries map[string]any, columns map[string][]string) (*bytes.Buffer, safego.Option[error]) {
+	queryKeyNames := make([]string, 0, len(columns))
+	for queryKeyName := range columns {
+		queryKeyNames = append(queryKeyNames, queryKeyName)
+	}
+	// data_2 must come before data_10.
+	sort.Slice(queryKeyNames, func(i, j int) bool {
+		if len(queryKeyNames[i]) != len(queryKeyNames[j]) {
+			return len(queryKeyNames[i]) < len(queryKeyNames[j])
+		}
+		return queryKeyNames[i] < queryKeyNames[j]
+	})
+
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+
+	for i, queryKeyName := range queryKeyNames {
+		if i > 0 {
+			writer.Flush()
+			buffer.WriteString("\n")
+		}
+
+		err := writer.Write(columns[queryKeyName])
+		if err != nil {
+			return &bytes.Buffer{}, safego.Some(err)
+		}
+
+		for _, row := range queries[queryKeyName].([]map[string]any) {
+			record := make([]string, len(columns[queryKeyName]))
+			for j, column := range columns[queryKeyName] {
+				switch value := row[column].(type) {
+				case nil:
+				case float64:
+					// Avoid the exponent notation of %v for large numbers.
+					record[j] = strconv.FormatFloat(value, 'f', -1, 64)
+				default:
+					record[j] = fmt.Sprintf("%v", value)
+				}
+			}
+
+			err = writer.Write(record)
+			if err != nil {
+				return &bytes.Buffer{}, safego.Some(err)
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return &bytes.Buffer{}, safego.Some(err)
+	}
+
+	return buffer, safego.None[error]()
+}
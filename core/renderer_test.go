@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"github.com/okira-e/goreports/assets"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/outputcache"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputCacheFollowsTheDependencies(t *testing.T) {
+	internalDbConn := newInternalDb(t)
+	renderer := &Renderer{
+		InternalDb:  &internalDbConn,
+		OutputCache: safego.Some(outputcache.NewCache(types.OutputCacheConfig{Enabled: true})),
+	}
+
+	saveReport(t, &internalDbConn, types.Report{Name: "lines", Title: "Lines", Body: `<p>lines v1</p><p>{{t "total"}}</p><img src="asset://stamp.png">`})
+	saveAsset(t, &internalDbConn, "", "logo.png", "logo v1")
+	saveAsset(t, &internalDbConn, "lines", "stamp.png", "stamp v1")
+	report := types.Report{Name: "invoice", Title: "Invoice", Body: `<img src="asset://logo.png">{{subreport "lines"}}`}
+	saveReport(t, &internalDbConn, report)
+
+	render := func() (string, string) {
+		t.Helper()
+
+		document, etag, errOpt := renderer.RenderWithETag(context.Background(), report, map[string]any{}, types.PrintingOptions{}, FormatHtml, "")
+		if errOpt.IsSome() {
+			t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+		}
+
+		return document.String(), etag
+	}
+
+	first, firstEtag := render()
+	if !strings.Contains(first, "lines v1") {
+		t.Fatalf("got %s, want the sub-report embedded", first)
+	}
+	if _, etag := render(); etag != firstEtag {
+		t.Fatalf("got the ETag %s, want the cached document %s", etag, firstEtag)
+	}
+	if stats := renderer.OutputCache.Unwrap().Stats(); stats.Hits != 1 {
+		t.Fatalf("got %d hits, want 1", stats.Hits)
+	}
+
+	changes := []struct {
+		name   string
+		change func()
+	}{
+		{name: "sub-report", change: func() {
+			errOpt := internalDb.UpdateReport(&internalDbConn, types.Report{Name: "lines", Title: "Lines", Body: `<p>lines v2</p><p>{{t "total"}}</p><img src="asset://stamp.png">`})
+			if errOpt.IsSome() {
+				t.Fatal(errOpt.Unwrap())
+			}
+		}},
+		{name: "translations of the sub-report", change: func() {
+			errOpt := internalDb.SaveTranslations(&internalDbConn, types.ReportTranslations{ReportName: "lines", Locale: "en", Translations: map[string]string{"total": "Grand total"}})
+			if errOpt.IsSome() {
+				t.Fatal(errOpt.Unwrap())
+			}
+		}},
+		{name: "global asset", change: func() { saveAsset(t, &internalDbConn, "", "logo.png", "logo v2") }},
+		{name: "asset of the sub-report", change: func() { saveAsset(t, &internalDbConn, "lines", "stamp.png", "stamp v2") }},
+		{name: "asset shadowing a global one", change: func() { saveAsset(t, &internalDbConn, "invoice", "logo.png", "logo of the invoice") }},
+	}
+
+	previous, previousEtag := first, firstEtag
+	for _, change := range changes {
+		change.change()
+
+		document, etag := render()
+		if document == previous || etag == previousEtag {
+			t.Errorf("%s: the cached document was served after the %s changed", change.name, change.name)
+		}
+		if _, cachedEtag := render(); cachedEtag != etag {
+			t.Errorf("%s: got the ETag %s, want the document cached again", change.name, cachedEtag)
+		}
+		previous, previousEtag = document, etag
+	}
+}
+
+func newInternalDb(t *testing.T) datasource.DataSource {
+	t.Helper()
+
+	var internalDbConn datasource.DataSource
+	internalDbConn = datasource.NewSqliteDb(filepath.Join(t.TempDir(), "internal.db"))
+
+	errOpt := internalDbConn.Connect()
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+	t.Cleanup(func() { internalDbConn.Disconnect() })
+
+	errOpt = internalDb.MigrateInternalDb(&internalDbConn)
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+
+	return internalDbConn
+}
+
+func saveReport(t *testing.T, internalDbConn *datasource.DataSource, report types.Report) {
+	t.Helper()
+
+	errOpt := internalDb.SaveReport(internalDbConn, report)
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+}
+
+func saveAsset(t *testing.T, internalDbConn *datasource.DataSource, reportName string, name string, data string) {
+	t.Helper()
+
+	asset, errOpt := assets.NewAsset(reportName, name, []byte(data), 0)
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+	errOpt = internalDb.SaveAsset(internalDbConn, asset)
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+}
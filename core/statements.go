@@ -0,0 +1,159 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"strings"
+)
+
+// selectKeywords are the keywords a SELECT statement can start with.
+var selectKeywords = []string{"SELECT", "WITH", "VALUES", "TABLE"}
+
+// writeKeywords are the keywords that write to the database, or lock its rows, from within a SELECT statement: in a
+// common table expression, in SELECT ... INTO or in SELECT ... FOR UPDATE. They are reserved in every supported dialect,
+// so they can only be column or table names when quoted.
+var writeKeywords = []string{"INSERT", "UPDATE", "DELETE", "MERGE", "INTO"}
+
+var errMultipleStatements = errors.New("the query has more than one statement, only a single SELECT statement is allowed")
+
+// checkSingleStatement returns an error if the query has more than one statement. Unlike checkSelectStatement, it is run
+// on every query: the drivers run the statements of a query one after the other, and a COMMIT among them would end the
+// read-only transaction, letting the next statements write.
+func checkSingleStatement(query string) safego.Option[error] {
+	_, statements := scanKeywords(query)
+	if statements > 1 {
+		return safego.Some(errMultipleStatements)
+	}
+
+	return safego.None[error]()
+}
+
+// checkSelectStatement returns an error if the query isn't a single SELECT statement, the only statements the reports
+// are meant to run. It reads the keywords of the query outside of its comments, quoted literals and quoted identifiers.
+func checkSelectStatement(query string) safego.Option[error] {
+	keywords, statements := scanKeywords(query)
+	if len(keywords) == 0 {
+		return safego.Some(errors.New("the query is empty"))
+	}
+	if statements > 1 {
+		return safego.Some(errMultipleStatements)
+	}
+
+	first := strings.ToUpper(keywords[0])
+	if !containsKeyword(selectKeywords, first) {
+		return safego.Some(fmt.Errorf("the query starts with %s, only SELECT statements are allowed", first))
+	}
+
+	for _, keyword := range keywords {
+		if containsKeyword(writeKeywords, strings.ToUpper(keyword)) {
+			return safego.Some(fmt.Errorf("the query contains %s, only SELECT statements are allowed", strings.ToUpper(keyword)))
+		}
+	}
+
+	return safego.None[error]()
+}
+
+// scanKeywords returns the words of the query that aren't in comments, quoted literals or quoted identifiers, and the
+// number of statements it has.
+func scanKeywords(query string) ([]string, int) {
+	keywords := []string{}
+	statements := 0
+	// ended is set after a semicolon, the next word starts another statement.
+	ended := true
+
+	for i := 0; i < len(query); {
+		char := query[i]
+
+		switch {
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				return keywords, statements
+			}
+			i += end + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				return keywords, statements
+			}
+			i += end + 4
+		case char == '\'' || char == '"' || char == '`' || char == '[':
+			closing := char
+			if char == '[' {
+				closing = ']'
+			}
+			i = skipQuoted(query, i+1, closing)
+		case char == '$':
+			// A dollar-quoted string of PostgreSQL: $$...$$ or $tag$...$tag$.
+			tagEnd := strings.IndexByte(query[i+1:], '$')
+			if tagEnd == -1 || !isWord(query[i+1:i+1+tagEnd]) {
+				i++
+				break
+			}
+			tag := query[i : i+tagEnd+2]
+			end := strings.Index(query[i+len(tag):], tag)
+			if end == -1 {
+				return keywords, statements
+			}
+			i += len(tag) + end + len(tag)
+		case char == ';':
+			ended = true
+			i++
+		case isWordStart(char):
+			start := i
+			for i < len(query) && (isWordStart(query[i]) || (query[i] >= '0' && query[i] <= '9') || query[i] == '$') {
+				i++
+			}
+			if ended {
+				statements++
+				ended = false
+			}
+			keywords = append(keywords, query[start:i])
+		default:
+			i++
+		}
+	}
+
+	return keywords, statements
+}
+
+// skipQuoted returns the position after the closing quote of the quoted text starting at start. Doubled quotes escape
+// the quote. Backslashes aren't read as escapes, as they aren't in standard SQL: a query the database reads differently
+// can only have more of its text read as keywords, and be rejected.
+func skipQuoted(query string, start int, closing byte) int {
+	for i := start; i < len(query); i++ {
+		switch {
+		case query[i] == closing && i+1 < len(query) && query[i+1] == closing:
+			i++
+		case query[i] == closing:
+			return i + 1
+		}
+	}
+
+	return len(query)
+}
+
+func isWordStart(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+func isWord(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if !isWordStart(text[i]) && (text[i] < '0' || text[i] > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsKeyword(keywords []string, keyword string) bool {
+	for _, candidate := range keywords {
+		if candidate == keyword {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/okira-e/goreports/datasource"
@@ -15,6 +16,14 @@ import (
 // The template can contain parameters and queries. Parameters are evaluated first, then queries.
 // If a parameter is not provided, the function returns an error message.
 func ParseTemplate(template string, params map[string]any, ds *datasource.DataSource) (string, map[string]any, safego.Option[string]) {
+	template, queries, _, errMsgOpt := parseTemplate(template, params, directQuery(context.Background(), ds, queryPolicy{}))
+
+	return template, queries, errMsgOpt
+}
+
+// parseTemplate does the work of ParseTemplate and also returns the column names, in select order,
+// of every multi-row query result.
+func parseTemplate(template string, params map[string]any, runQuery queryFunc) (string, map[string]any, map[string][]string, safego.Option[string]) {
 	//// Parameters evaluation ////
 
 	// Extract every [P[...]] expression.
@@ -24,7 +33,7 @@ func ParseTemplate(template string, params map[string]any, ds *datasource.DataSo
 	for _, parameter := range parameters {
 		// Check if the parameter is provided.
 		if _, ok := params[parameter]; !ok {
-			return "", map[string]any{}, safego.Some(fmt.Sprintf("Parameter %s is not provided.", parameter))
+			return "", map[string]any{}, map[string][]string{}, safego.Some(fmt.Sprintf("Parameter %s is not provided.", parameter))
 		}
 
 		// Replace the parameter in the original template with the generated name.
@@ -39,16 +48,18 @@ func ParseTemplate(template string, params map[string]any, ds *datasource.DataSo
 
 	// Evaluate every query.
 	queries := map[string]any{}
+	columns := map[string][]string{}
 	queryCounter := 0
 	for _, query := range res {
-		rows, errOpt := (*ds).Query(query)
+		result, errOpt := runQuery(query)
 		if errOpt.IsSome() {
-			return "", map[string]any{}, safego.Some(errOpt.Unwrap().Error())
+			return "", map[string]any{}, map[string][]string{}, safego.Some(errOpt.Unwrap().Error())
 		}
+		queryColumns := result.Columns
 
 		// The result for a single query in the template.
 		// Can be a single row or multiple rows.
-		queryResults := utils.JsonifyQueryData(rows)
+		queryResults := result.Rows
 
 		// Unmarshal the query result(s).
 		if len(queryResults) == 1 {
@@ -56,7 +67,7 @@ func ParseTemplate(template string, params map[string]any, ds *datasource.DataSo
 
 			err := json.Unmarshal([]byte(queryResults[0]), &data)
 			if err != nil {
-				return "", map[string]any{}, safego.Some(err.Error())
+				return "", map[string]any{}, map[string][]string{}, safego.Some(err.Error())
 			}
 
 			// Get the first (and only) value from the map.
@@ -73,6 +84,7 @@ func ParseTemplate(template string, params map[string]any, ds *datasource.DataSo
 			// Initialize the outer map.
 			queryKeyName := "data_" + strconv.Itoa(queryCounter)
 			queries[queryKeyName] = []map[string]any{}
+			columns[queryKeyName] = queryColumns
 
 			// Iterate through the query results.
 			// I can't use `i` because of the `continue` statement.
@@ -86,7 +98,7 @@ func ParseTemplate(template string, params map[string]any, ds *datasource.DataSo
 
 				err := json.Unmarshal([]byte(queryResult), &data)
 				if err != nil {
-					return "", map[string]any{}, safego.Some(err.Error())
+					return "", map[string]any{}, map[string][]string{}, safego.Some(err.Error())
 				}
 
 				// Append the data to the outer map.
@@ -103,5 +115,5 @@ func ParseTemplate(template string, params map[string]any, ds *datasource.DataSo
 		queryCounter++
 	}
 
-	return template, queries, safego.None[string]()
+	return template, queries, columns, safego.None[string]()
 }
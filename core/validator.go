@@ -0,0 +1,269 @@
+package core
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// directive is a [P[...]] or [Q[...]] expression found in a template.
+type directive struct {
+	// kind is 'P' or 'Q'.
+	kind         byte
+	start        int
+	contentStart int
+	end          int
+	line         int
+	column       int
+	// invalid is set on the directives that nest, or are nested in, another directive in a way that isn't allowed.
+	invalid bool
+}
+
+func (self *directive) content(template string) string {
+	return template[self.contentStart : self.end-2]
+}
+
+// parameterRegex matches a [P[...]] directive the way parseTemplate does.
+var parameterRegex = regexp.MustCompile(`\[P\[(.+?)\]\]`)
+
+// raymondErrorRegex matches the position prefix of the errors returned by raymond.Parse.
+var raymondErrorRegex = regexp.MustCompile(`^Parse error on line (\d+):\n`)
+
+// ValidateReport checks the body of the report without rendering it:
+//   - the [P[...]] and [Q[...]] directives must be closed on the line they're opened on and can't be nested, except for
+//     parameters inside queries.
+//   - the template, with its directives replaced, must be valid handlebars.
+//   - every query must be accepted by the datasource. Queries are only prepared, parameters are replaced with their
+//     default, or 0 if they don't have one. The check is skipped if ds is nil.
+//   - every query must be a single statement, and a single SELECT statement if checkStatements is set.
+//
+// The problems are errors, except when the database is unreachable: the queries can't be checked then, which is
+// reported as a warning that leaves the report valid.
+func ValidateReport(report types.Report, ds *datasource.DataSource, checkStatements bool) types.ValidationResult {
+	template := report.Body
+	result := types.ValidationResult{
+		Parameters: []string{},
+		Problems:   []types.ValidationProblem{},
+	}
+
+	directives := scanDirectives(template, &result)
+
+	// Collect the parameters and mask the top level directives for handlebars.
+	masked := []byte(template)
+	for _, directive := range directives {
+		if directive.kind == 'P' && !directive.invalid {
+			name := directive.content(template)
+			if !utils.ContainsString(result.Parameters, name) {
+				result.Parameters = append(result.Parameters, name)
+			}
+		}
+
+		if directive.kind == 'Q' && !directive.invalid {
+			for _, name := range extractParameters(directive.content(template)) {
+				if !utils.ContainsString(result.Parameters, name) {
+					result.Parameters = append(result.Parameters, name)
+				}
+			}
+		}
+
+		// A single identifier of the same length keeps the positions of the handlebars errors right.
+		masked[directive.start] = directive.kind + ('a' - 'A')
+		for i := directive.start + 1; i < directive.end; i++ {
+			masked[i] = ' '
+		}
+	}
+
+	_, err := raymond.Parse(string(masked))
+	if err != nil {
+		problem := types.ValidationProblem{Severity: types.ProblemSeverityError, Message: err.Error()}
+		if match := raymondErrorRegex.FindStringSubmatch(err.Error()); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Message = strings.ReplaceAll(strings.TrimPrefix(err.Error(), match[0]), "\n", ": ")
+		}
+		problem.Message = "Invalid handlebars: " + problem.Message
+
+		result.Problems = append(result.Problems, problem)
+	}
+
+	checkStatement := checkSingleStatement
+	if checkStatements {
+		checkStatement = checkSelectStatement
+	}
+	for _, directive := range directives {
+		if directive.kind != 'Q' || directive.invalid {
+			continue
+		}
+
+		errOpt := checkStatement(substituteSampleParameters(directive.content(template), report.Parameters))
+		if errOpt.IsSome() {
+			result.Problems = append(result.Problems, types.ValidationProblem{
+				Line:     directive.line,
+				Column:   directive.column,
+				Severity: types.ProblemSeverityError,
+				Message:  "The query is rejected: " + errOpt.Unwrap().Error(),
+			})
+		}
+	}
+
+	if ds != nil {
+		reportUnreachable := func(err error) {
+			result.Problems = append(result.Problems, types.ValidationProblem{
+				Severity: types.ProblemSeverityWarning,
+				Message:  "The queries can't be checked, the database is unreachable: " + err.Error(),
+			})
+		}
+
+		errOpt := (*ds).Ping()
+		if errOpt.IsSome() {
+			reportUnreachable(errOpt.Unwrap())
+			directives = nil
+		}
+
+		for _, directive := range directives {
+			if directive.kind != 'Q' || directive.invalid {
+				continue
+			}
+
+			query := substituteSampleParameters(directive.content(template), report.Parameters)
+
+			errOpt := (*ds).Prepare(query)
+			if errOpt.IsSome() {
+				// The connection may have been lost since the ping, which says nothing about the query.
+				pingErrOpt := (*ds).Ping()
+				if pingErrOpt.IsSome() {
+					reportUnreachable(pingErrOpt.Unwrap())
+					break
+				}
+
+				result.Problems = append(result.Problems, types.ValidationProblem{
+					Line:     directive.line,
+					Column:   directive.column,
+					Severity: types.ProblemSeverityError,
+					Message:  "The query is rejected by the database: " + errOpt.Unwrap().Error(),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(result.Problems, func(i, j int) bool {
+		if result.Problems[i].Line != result.Problems[j].Line {
+			return result.Problems[i].Line < result.Problems[j].Line
+		}
+		return result.Problems[i].Column < result.Problems[j].Column
+	})
+	result.Valid = true
+	for _, problem := range result.Problems {
+		if problem.Severity == types.ProblemSeverityError {
+			result.Valid = false
+		}
+	}
+
+	return result
+}
+
+// scanDirectives returns the closed top level directives of the template, in order, and adds the syntax problems it
+// finds to the result.
+func scanDirectives(template string, result *types.ValidationResult) []*directive {
+	var directives []*directive
+	var open []*directive
+
+	addProblem := func(directive *directive, message string) {
+		result.Problems = append(result.Problems, types.ValidationProblem{
+			Line:     directive.line,
+			Column:   directive.column,
+			Severity: types.ProblemSeverityError,
+			Message:  message,
+		})
+	}
+	reportUnclosed := func() {
+		for _, directive := range open {
+			addProblem(directive, fmt.Sprintf("Unclosed [%c[ directive. Directives must end with ]] on the line they start on.", directive.kind))
+		}
+		open = nil
+	}
+
+	line, column := 1, 1
+	for i := 0; i < len(template); {
+		if strings.HasPrefix(template[i:], "[P[") || strings.HasPrefix(template[i:], "[Q[") {
+			current := &directive{kind: template[i+1], start: i, contentStart: i + 3, line: line, column: column}
+
+			if len(open) > 0 {
+				parent := open[len(open)-1]
+				if parent.kind != 'Q' || current.kind != 'P' {
+					addProblem(current, fmt.Sprintf("[%c[ directive nested in the [%c[ directive at line %d, column %d. Only parameters can be used inside queries.", current.kind, parent.kind, parent.line, parent.column))
+					current.invalid = true
+					for _, directive := range open {
+						directive.invalid = true
+					}
+				}
+			}
+
+			open = append(open, current)
+			i += 3
+			column += 3
+			continue
+		}
+
+		if strings.HasPrefix(template[i:], "]]") && len(open) > 0 {
+			current := open[len(open)-1]
+			open = open[:len(open)-1]
+			current.end = i + 2
+
+			if strings.TrimSpace(current.content(template)) == "" {
+				addProblem(current, fmt.Sprintf("Empty [%c[ directive.", current.kind))
+				current.invalid = true
+			}
+
+			if len(open) == 0 {
+				directives = append(directives, current)
+			}
+
+			i += 2
+			column += 2
+			continue
+		}
+
+		if template[i] == '\n' {
+			reportUnclosed()
+			line++
+			column = 1
+		} else if template[i]&0xC0 != 0x80 {
+			// Count runes, not the continuation bytes of UTF-8 characters.
+			column++
+		}
+		i++
+	}
+	reportUnclosed()
+
+	return directives
+}
+
+// extractParameters returns the names of the [P[...]] parameters of a query.
+func extractParameters(query string) []string {
+	var names []string
+	for _, match := range parameterRegex.FindAllStringSubmatch(query, -1) {
+		names = append(names, match[1])
+	}
+
+	return names
+}
+
+// substituteSampleParameters replaces the parameters of a query with their default value, or 0.
+func substituteSampleParameters(query string, parameters []types.ReportParameter) string {
+	return parameterRegex.ReplaceAllStringFunc(query, func(match string) string {
+		name := parameterRegex.FindStringSubmatch(match)[1]
+		for _, parameter := range parameters {
+			if parameter.Name == name && parameter.Default != nil {
+				return fmt.Sprintf("%v", parameter.Default)
+			}
+		}
+
+		return "0"
+	})
+}
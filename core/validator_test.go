@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"strings"
+	"testing"
+)
+
+// fakeDataSource fails the pings and the prepared queries it is told to, and records the queries run.
+type fakeDataSource struct {
+	datasource.DataSource
+	pingErr error
+	// pingErrAfterPrepare is returned by the pings following a failed prepare, as if the connection was lost.
+	pingErrAfterPrepare error
+	prepareErr          error
+	prepared            bool
+	// queries are the queries run.
+	queries []string
+}
+
+func (self *fakeDataSource) Ping() safego.Option[error] {
+	if self.pingErr != nil {
+		return safego.Some(self.pingErr)
+	}
+	if self.prepared && self.pingErrAfterPrepare != nil {
+		return safego.Some(self.pingErrAfterPrepare)
+	}
+
+	return safego.None[error]()
+}
+
+func (self *fakeDataSource) Prepare(query string) safego.Option[error] {
+	self.prepared = true
+	if self.prepareErr != nil {
+		return safego.Some(self.prepareErr)
+	}
+
+	return safego.None[error]()
+}
+
+func (self *fakeDataSource) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, safego.Option[error]) {
+	self.queries = append(self.queries, query)
+
+	return nil, safego.Some(errors.New("no rows"))
+}
+
+func (self *fakeDataSource) ReadOnlyQueryContext(ctx context.Context, read func(*sql.Rows) safego.Option[error], query string, args ...any) safego.Option[error] {
+	self.queries = append(self.queries, query)
+
+	return safego.Some(errors.New("no rows"))
+}
+
+func TestValidateReportSeverities(t *testing.T) {
+	connectionRefused := errors.New("dial tcp 127.0.0.1:5432: connect: connection refused")
+	syntaxError := errors.New(`pq: syntax error at or near "FORM"`)
+
+	cases := []struct {
+		name            string
+		body            string
+		ds              fakeDataSource
+		checkStatements bool
+		wantValid       bool
+		// wantProblems holds the severity and the start of the message of every problem.
+		wantProblems [][2]string
+	}{
+		{name: "valid", body: `[Q[SELECT 1]]`, wantValid: true},
+		{name: "unreachable database", body: `[Q[SELECT 1]]`, ds: fakeDataSource{pingErr: connectionRefused}, wantValid: true, wantProblems: [][2]string{{types.ProblemSeverityWarning, "The queries can't be checked, the database is unreachable"}}},
+		{name: "connection lost while preparing", body: "[Q[SELECT 1]]\n[Q[SELECT 2]]", ds: fakeDataSource{prepareErr: connectionRefused, pingErrAfterPrepare: connectionRefused}, wantValid: true, wantProblems: [][2]string{{types.ProblemSeverityWarning, "The queries can't be checked, the database is unreachable"}}},
+		{name: "query rejected", body: `[Q[SELECT * FORM t]]`, ds: fakeDataSource{prepareErr: syntaxError}, wantValid: false, wantProblems: [][2]string{{types.ProblemSeverityError, "The query is rejected by the database"}}},
+		{name: "unreachable database and template error", body: "{{#if a}}\n[Q[SELECT 1]]", ds: fakeDataSource{pingErr: connectionRefused}, wantValid: false, wantProblems: [][2]string{{types.ProblemSeverityWarning, "The queries can't be checked"}, {types.ProblemSeverityError, "Invalid handlebars"}}},
+		{name: "unreachable database and directive error", body: `[Q[SELECT 1`, ds: fakeDataSource{pingErr: connectionRefused}, wantValid: false, wantProblems: [][2]string{{types.ProblemSeverityWarning, "The queries can't be checked"}, {types.ProblemSeverityError, "Unclosed [Q[ directive"}}},
+		{name: "several statements", body: `[Q[SELECT 1; COMMIT; DELETE FROM payments]]`, wantValid: false, wantProblems: [][2]string{{types.ProblemSeverityError, "The query is rejected: the query has more than one statement"}}},
+		{name: "write without check statements", body: `[Q[DELETE FROM payments]]`, wantValid: true},
+		{name: "unreachable database and statement error", body: `[Q[DELETE FROM t]]`, ds: fakeDataSource{pingErr: connectionRefused}, checkStatements: true, wantValid: false, wantProblems: [][2]string{{types.ProblemSeverityWarning, "The queries can't be checked"}, {types.ProblemSeverityError, "The query is rejected:"}}},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var ds datasource.DataSource = &testCase.ds
+
+			result := ValidateReport(types.Report{Body: testCase.body}, &ds, testCase.checkStatements)
+
+			if result.Valid != testCase.wantValid {
+				t.Errorf("got valid %v, want %v: %+v", result.Valid, testCase.wantValid, result.Problems)
+			}
+			if len(result.Problems) != len(testCase.wantProblems) {
+				t.Fatalf("got problems %+v, want %v", result.Problems, testCase.wantProblems)
+			}
+			for i, problem := range result.Problems {
+				if problem.Severity != testCase.wantProblems[i][0] || !strings.HasPrefix(problem.Message, testCase.wantProblems[i][1]) {
+					t.Errorf("got problem %+v, want %v", problem, testCase.wantProblems[i])
+				}
+			}
+		})
+	}
+}
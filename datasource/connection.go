@@ -0,0 +1,264 @@
+package datasource
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Connection is how to connect to an external database.
+type Connection struct {
+	// Driver is the name of the database/sql driver of the dialect.
+	Driver string
+	// Dsn is the connection string given to the driver.
+	Dsn string
+}
+
+// The TLS modes of the connections.
+const (
+	TlsModeDisable    = "disable"
+	TlsModeRequire    = "require"
+	TlsModeVerifyCa   = "verify-ca"
+	TlsModeVerifyFull = "verify-full"
+)
+
+// mysqlTlsConfigName is the name the TLS config of the MySQL connections is registered under.
+const mysqlTlsConfigName = "goreports"
+
+// ConnectionOf returns how to connect to the database described by the config.
+// It returns an error if the dialect or the TLS mode is not supported, or if the TLS files can't be read.
+func ConnectionOf(config types.DbConfig) (Connection, safego.Option[error]) {
+	switch config.Tls.Mode {
+	case "", TlsModeDisable, TlsModeRequire, TlsModeVerifyCa, TlsModeVerifyFull:
+	default:
+		return Connection{}, safego.Some(fmt.Errorf("unsupported TLS mode %s, expected disable, require, verify-ca or verify-full", config.Tls.Mode))
+	}
+
+	switch config.Dialect {
+	case "postgres":
+		if config.Dsn != "" {
+			return Connection{Driver: "postgres", Dsn: config.Dsn}, safego.None[error]()
+		}
+
+		return Connection{Driver: "postgres", Dsn: postgresDsn(config)}, safego.None[error]()
+	case "mysql", "mariadb":
+		if config.Dsn != "" {
+			return Connection{Driver: "mysql", Dsn: config.Dsn}, safego.None[error]()
+		}
+
+		dsn, errOpt := mysqlDsn(config)
+		if errOpt.IsSome() {
+			return Connection{}, errOpt
+		}
+
+		return Connection{Driver: "mysql", Dsn: dsn}, safego.None[error]()
+	case "mssql":
+		if config.Dsn != "" {
+			return Connection{Driver: "sqlserver", Dsn: config.Dsn}, safego.None[error]()
+		}
+
+		dsn, errOpt := mssqlDsn(config)
+		if errOpt.IsSome() {
+			return Connection{}, errOpt
+		}
+
+		return Connection{Driver: "sqlserver", Dsn: dsn}, safego.None[error]()
+	}
+
+	return Connection{}, safego.Some(errors.New("unsupported dialect " + config.Dialect + ", expected postgres, mysql, mariadb or mssql"))
+}
+
+// postgresDsn returns the lib/pq URL of the database. The parameters it doesn't know are set on the connections, like
+// search_path.
+func postgresDsn(config types.DbConfig) string {
+	params := url.Values{}
+	params.Set("sslmode", TlsModeDisable)
+	if config.Tls.Mode != "" {
+		params.Set("sslmode", config.Tls.Mode)
+	}
+	if config.Tls.CaCertPath != "" {
+		params.Set("sslrootcert", config.Tls.CaCertPath)
+	}
+	if config.Tls.ClientCertPath != "" {
+		params.Set("sslcert", config.Tls.ClientCertPath)
+	}
+	if config.Tls.ClientKeyPath != "" {
+		params.Set("sslkey", config.Tls.ClientKeyPath)
+	}
+	if config.ConnectTimeoutSeconds > 0 {
+		params.Set("connect_timeout", strconv.Itoa(config.ConnectTimeoutSeconds))
+	}
+	if config.Schema != "" {
+		params.Set("search_path", config.Schema)
+	}
+	for name, value := range config.Options {
+		params.Set(name, value)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     userOf(config),
+		Host:     hostPort(config.Host, config.Port, 5432),
+		Path:     "/" + config.Database,
+		RawQuery: params.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// mysqlDsn returns the go-sql-driver DSN of the database. The TLS config of the modes the driver doesn't have is
+// registered with the driver.
+func mysqlDsn(config types.DbConfig) (string, safego.Option[error]) {
+	dsn := mysql.NewConfig()
+	dsn.User = config.Username
+	dsn.Passwd = config.Password
+	dsn.Net = "tcp"
+	dsn.Addr = hostPort(config.Host, config.Port, 3306)
+	dsn.DBName = config.Database
+	dsn.Timeout = time.Duration(config.ConnectTimeoutSeconds) * time.Second
+	dsn.Params = map[string]string{}
+	if config.Charset != "" {
+		dsn.Params["charset"] = config.Charset
+	}
+	for name, value := range config.Options {
+		dsn.Params[name] = value
+	}
+
+	switch {
+	case config.Tls.Mode == "" || config.Tls.Mode == TlsModeDisable:
+	case config.Tls.Mode == TlsModeRequire && config.Tls.ClientCertPath == "":
+		dsn.TLSConfig = "skip-verify"
+	case config.Tls.Mode == TlsModeVerifyFull && config.Tls.CaCertPath == "" && config.Tls.ClientCertPath == "":
+		dsn.TLSConfig = "true"
+	default:
+		tlsConfig, errOpt := tlsConfigOf(config.Tls, config.Host)
+		if errOpt.IsSome() {
+			return "", errOpt
+		}
+
+		err := mysql.RegisterTLSConfig(mysqlTlsConfigName, tlsConfig)
+		if err != nil {
+			return "", safego.Some(err)
+		}
+		dsn.TLSConfig = mysqlTlsConfigName
+	}
+
+	return dsn.FormatDSN(), safego.None[error]()
+}
+
+// mssqlDsn returns the go-mssqldb URL of the database. verify-ca isn't supported: the driver either checks the
+// certificate of the server along with its host name, or doesn't check it at all.
+func mssqlDsn(config types.DbConfig) (string, safego.Option[error]) {
+	params := url.Values{}
+	if config.Database != "" {
+		params.Set("database", config.Database)
+	}
+	switch config.Tls.Mode {
+	case TlsModeDisable:
+		params.Set("encrypt", "disable")
+	case TlsModeRequire:
+		params.Set("encrypt", "true")
+		params.Set("TrustServerCertificate", "true")
+	case TlsModeVerifyCa:
+		return "", safego.Some(errors.New("the verify-ca TLS mode isn't supported by SQL Server, use verify-full to check the certificate and the host name of the server, or require to only encrypt"))
+	case TlsModeVerifyFull:
+		params.Set("encrypt", "true")
+		params.Set("TrustServerCertificate", "false")
+	}
+	if config.Tls.CaCertPath != "" {
+		params.Set("certificate", config.Tls.CaCertPath)
+	}
+	if config.ConnectTimeoutSeconds > 0 {
+		params.Set("dial timeout", strconv.Itoa(config.ConnectTimeoutSeconds))
+	}
+	for name, value := range config.Options {
+		params.Set(name, value)
+	}
+
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     userOf(config),
+		Host:     hostPort(config.Host, config.Port, 1433),
+		RawQuery: params.Encode(),
+	}
+
+	return dsn.String(), safego.None[error]()
+}
+
+// tlsConfigOf returns the TLS config of the mode, verifying the certificate of the server with the CA of the config,
+// or else with the CAs of the system.
+func tlsConfigOf(config types.DbTlsConfig, host string) (*tls.Config, safego.Option[error]) {
+	tlsConfig := &tls.Config{ServerName: host}
+
+	if config.CaCertPath != "" {
+		pem, err := os.ReadFile(config.CaCertPath)
+		if err != nil {
+			return nil, safego.Some(err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, safego.Some(errors.New("no certificate found in " + config.CaCertPath))
+		}
+	}
+
+	if config.ClientCertPath != "" {
+		certificate, err := tls.LoadX509KeyPair(config.ClientCertPath, config.ClientKeyPath)
+		if err != nil {
+			return nil, safego.Some(err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	switch config.Mode {
+	case TlsModeRequire:
+		tlsConfig.InsecureSkipVerify = true
+	case TlsModeVerifyCa:
+		// The chain is verified without the host name, which crypto/tls can't do on its own.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("the server has no certificate")
+			}
+
+			options := x509.VerifyOptions{Roots: tlsConfig.RootCAs, Intermediates: x509.NewCertPool()}
+			for _, certificate := range state.PeerCertificates[1:] {
+				options.Intermediates.AddCert(certificate)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(options)
+
+			return err
+		}
+	}
+
+	return tlsConfig, safego.None[error]()
+}
+
+// userOf returns the credentials of the URL of the database, none if there is no username.
+func userOf(config types.DbConfig) *url.Userinfo {
+	if config.Username == "" {
+		return nil
+	}
+
+	return url.UserPassword(config.Username, config.Password)
+}
+
+// hostPort joins the host and the port, or the default port of the dialect if it is 0.
+func hostPort(host string, port int, defaultPort int) string {
+	if port == 0 {
+		port = defaultPort
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
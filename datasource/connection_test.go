@@ -0,0 +1,578 @@
+package datasource
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/denisenkom/go-mssqldb/msdsn"
+	"github.com/go-sql-driver/mysql"
+	"github.com/okira-e/goreports/types"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectionOfPostgres(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   types.DbConfig
+		wantHost string
+		// wantParams are the parameters expected in the query of the URL, the others aren't checked.
+		wantParams map[string]string
+		// missingParams aren't expected in the query of the URL.
+		missingParams []string
+	}{
+		{
+			name:          "default port",
+			config:        types.DbConfig{Dialect: "postgres", Host: "db.example.com", Database: "sales"},
+			wantHost:      "db.example.com:5432",
+			wantParams:    map[string]string{"sslmode": "disable"},
+			missingParams: []string{"connect_timeout", "search_path", "sslrootcert"},
+		},
+		{
+			name:     "port",
+			config:   types.DbConfig{Dialect: "postgres", Host: "db.example.com", Port: 6432},
+			wantHost: "db.example.com:6432",
+		},
+		{
+			name:     "ipv6 host",
+			config:   types.DbConfig{Dialect: "postgres", Host: "::1"},
+			wantHost: "[::1]:5432",
+		},
+		{
+			name:       "tls disable",
+			config:     types.DbConfig{Dialect: "postgres", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeDisable}},
+			wantHost:   "h:5432",
+			wantParams: map[string]string{"sslmode": "disable"},
+		},
+		{
+			name:       "tls require",
+			config:     types.DbConfig{Dialect: "postgres", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeRequire}},
+			wantHost:   "h:5432",
+			wantParams: map[string]string{"sslmode": "require"},
+		},
+		{
+			name:       "tls verify-ca",
+			config:     types.DbConfig{Dialect: "postgres", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyCa, CaCertPath: "/certs/ca.pem"}},
+			wantHost:   "h:5432",
+			wantParams: map[string]string{"sslmode": "verify-ca", "sslrootcert": "/certs/ca.pem"},
+		},
+		{
+			name: "tls verify-full",
+			config: types.DbConfig{Dialect: "postgres", Host: "h", Tls: types.DbTlsConfig{
+				Mode:           TlsModeVerifyFull,
+				CaCertPath:     "/certs/ca.pem",
+				ClientCertPath: "/certs/client.pem",
+				ClientKeyPath:  "/certs/client-key.pem",
+			}},
+			wantHost:   "h:5432",
+			wantParams: map[string]string{"sslmode": "verify-full", "sslrootcert": "/certs/ca.pem", "sslcert": "/certs/client.pem", "sslkey": "/certs/client-key.pem"},
+		},
+		{
+			name:       "schema and timeout",
+			config:     types.DbConfig{Dialect: "postgres", Host: "h", Schema: "reporting", ConnectTimeoutSeconds: 10},
+			wantHost:   "h:5432",
+			wantParams: map[string]string{"search_path": "reporting", "connect_timeout": "10"},
+		},
+		{
+			name:       "options",
+			config:     types.DbConfig{Dialect: "postgres", Host: "h", Options: map[string]string{"application_name": "goreports", "sslmode": "prefer"}},
+			wantHost:   "h:5432",
+			wantParams: map[string]string{"application_name": "goreports", "sslmode": "prefer"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			connection := mustConnectionOf(t, testCase.config)
+			if connection.Driver != "postgres" {
+				t.Errorf("got the driver %s, want postgres", connection.Driver)
+			}
+
+			dsn, err := url.Parse(connection.Dsn)
+			if err != nil {
+				t.Fatalf("error while parsing %s: %v", connection.Dsn, err)
+			}
+			if dsn.Scheme != "postgres" {
+				t.Errorf("got the scheme %s, want postgres", dsn.Scheme)
+			}
+			if dsn.Host != testCase.wantHost {
+				t.Errorf("got the host %s, want %s", dsn.Host, testCase.wantHost)
+			}
+			checkParams(t, dsn.Query(), testCase.wantParams, testCase.missingParams)
+		})
+	}
+}
+
+func TestConnectionOfPostgresCredentials(t *testing.T) {
+	connection := mustConnectionOf(t, types.DbConfig{Dialect: "postgres", Host: "h", Username: "report@er", Password: "p@ss:w/rd?#", Database: "sales"})
+
+	dsn, err := url.Parse(connection.Dsn)
+	if err != nil {
+		t.Fatalf("error while parsing %s: %v", connection.Dsn, err)
+	}
+	password, _ := dsn.User.Password()
+	if dsn.User.Username() != "report@er" || password != "p@ss:w/rd?#" {
+		t.Errorf("got the credentials %s:%s, want report@er:p@ss:w/rd?#", dsn.User.Username(), password)
+	}
+	if dsn.Path != "/sales" {
+		t.Errorf("got the path %s, want /sales", dsn.Path)
+	}
+
+	connection = mustConnectionOf(t, types.DbConfig{Dialect: "postgres", Host: "h"})
+	if strings.Contains(connection.Dsn, "@") {
+		t.Errorf("got %s, want no credentials without a username", connection.Dsn)
+	}
+}
+
+func TestConnectionOfMysql(t *testing.T) {
+	caCertPath := writeCaCert(t)
+
+	cases := []struct {
+		name          string
+		config        types.DbConfig
+		wantAddr      string
+		wantTlsConfig string
+		wantTimeout   time.Duration
+		// wantReadTimeout is set by the readTimeout parameter, which the driver doesn't keep with the others.
+		wantReadTimeout time.Duration
+		wantParams      map[string]string
+	}{
+		{
+			name:     "default port",
+			config:   types.DbConfig{Dialect: "mysql", Host: "db.example.com", Database: "sales"},
+			wantAddr: "db.example.com:3306",
+		},
+		{
+			name:     "mariadb",
+			config:   types.DbConfig{Dialect: "mariadb", Host: "db.example.com", Port: 3307},
+			wantAddr: "db.example.com:3307",
+		},
+		{
+			name:          "tls disable",
+			config:        types.DbConfig{Dialect: "mysql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeDisable}},
+			wantAddr:      "h:3306",
+			wantTlsConfig: "",
+		},
+		{
+			name:          "tls require",
+			config:        types.DbConfig{Dialect: "mysql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeRequire}},
+			wantAddr:      "h:3306",
+			wantTlsConfig: "skip-verify",
+		},
+		{
+			name:          "tls verify-ca",
+			config:        types.DbConfig{Dialect: "mysql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyCa, CaCertPath: caCertPath}},
+			wantAddr:      "h:3306",
+			wantTlsConfig: mysqlTlsConfigName,
+		},
+		{
+			name:          "tls verify-full",
+			config:        types.DbConfig{Dialect: "mysql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyFull}},
+			wantAddr:      "h:3306",
+			wantTlsConfig: "true",
+		},
+		{
+			name:          "tls verify-full with a ca",
+			config:        types.DbConfig{Dialect: "mysql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyFull, CaCertPath: caCertPath}},
+			wantAddr:      "h:3306",
+			wantTlsConfig: mysqlTlsConfigName,
+		},
+		{
+			name:        "charset and timeout",
+			config:      types.DbConfig{Dialect: "mysql", Host: "h", Charset: "utf8mb4", ConnectTimeoutSeconds: 10},
+			wantAddr:    "h:3306",
+			wantTimeout: 10 * time.Second,
+			wantParams:  map[string]string{"charset": "utf8mb4"},
+		},
+		{
+			name:            "options",
+			config:          types.DbConfig{Dialect: "mysql", Host: "h", Charset: "utf8", Options: map[string]string{"charset": "latin1", "time_zone": "'+00:00'", "readTimeout": "30s"}},
+			wantAddr:        "h:3306",
+			wantReadTimeout: 30 * time.Second,
+			wantParams:      map[string]string{"charset": "latin1", "time_zone": "'+00:00'"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			connection := mustConnectionOf(t, testCase.config)
+			if connection.Driver != "mysql" {
+				t.Errorf("got the driver %s, want mysql", connection.Driver)
+			}
+
+			dsn, err := mysql.ParseDSN(connection.Dsn)
+			if err != nil {
+				t.Fatalf("error while parsing %s: %v", connection.Dsn, err)
+			}
+			if dsn.Net != "tcp" || dsn.Addr != testCase.wantAddr {
+				t.Errorf("got the address %s(%s), want tcp(%s)", dsn.Net, dsn.Addr, testCase.wantAddr)
+			}
+			if dsn.TLSConfig != testCase.wantTlsConfig {
+				t.Errorf("got the TLS config %q, want %q", dsn.TLSConfig, testCase.wantTlsConfig)
+			}
+			if dsn.Timeout != testCase.wantTimeout {
+				t.Errorf("got the timeout %s, want %s", dsn.Timeout, testCase.wantTimeout)
+			}
+			if dsn.ReadTimeout != testCase.wantReadTimeout {
+				t.Errorf("got the read timeout %s, want %s", dsn.ReadTimeout, testCase.wantReadTimeout)
+			}
+			for name, want := range testCase.wantParams {
+				if dsn.Params[name] != want {
+					t.Errorf("got the parameter %s=%q, want %q", name, dsn.Params[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectionOfMysqlCredentials(t *testing.T) {
+	connection := mustConnectionOf(t, types.DbConfig{Dialect: "mysql", Host: "h", Username: "reporter", Password: "p@ss:w/rd?#", Database: "sales"})
+
+	dsn, err := mysql.ParseDSN(connection.Dsn)
+	if err != nil {
+		t.Fatalf("error while parsing %s: %v", connection.Dsn, err)
+	}
+	if dsn.User != "reporter" || dsn.Passwd != "p@ss:w/rd?#" || dsn.DBName != "sales" {
+		t.Errorf("got %s:%s/%s, want reporter:p@ss:w/rd?#/sales", dsn.User, dsn.Passwd, dsn.DBName)
+	}
+}
+
+func TestConnectionOfMssql(t *testing.T) {
+	// The driver reads the CA certificate when parsing the URL.
+	caCertPath := writeCaCert(t)
+
+	cases := []struct {
+		name       string
+		config     types.DbConfig
+		wantHost   string
+		wantPort   uint64
+		wantParams map[string]string
+		// missingParams aren't expected in the query of the URL.
+		missingParams []string
+	}{
+		{
+			name:          "default port",
+			config:        types.DbConfig{Dialect: "mssql", Host: "db.example.com"},
+			wantHost:      "db.example.com",
+			wantPort:      1433,
+			missingParams: []string{"database", "encrypt", "trustservercertificate", "dial timeout"},
+		},
+		{
+			name:       "database",
+			config:     types.DbConfig{Dialect: "mssql", Host: "h", Port: 14330, Database: "sales"},
+			wantHost:   "h",
+			wantPort:   14330,
+			wantParams: map[string]string{"database": "sales"},
+		},
+		{
+			name:       "tls disable",
+			config:     types.DbConfig{Dialect: "mssql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeDisable}},
+			wantHost:   "h",
+			wantPort:   1433,
+			wantParams: map[string]string{"encrypt": "disable"},
+		},
+		{
+			name:       "tls require",
+			config:     types.DbConfig{Dialect: "mssql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeRequire}},
+			wantHost:   "h",
+			wantPort:   1433,
+			wantParams: map[string]string{"encrypt": "true", "trustservercertificate": "true"},
+		},
+		{
+			name:       "tls verify-full",
+			config:     types.DbConfig{Dialect: "mssql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyFull, CaCertPath: caCertPath}},
+			wantHost:   "h",
+			wantPort:   1433,
+			wantParams: map[string]string{"encrypt": "true", "trustservercertificate": "false", "certificate": caCertPath},
+		},
+		{
+			name:       "timeout",
+			config:     types.DbConfig{Dialect: "mssql", Host: "h", ConnectTimeoutSeconds: 10},
+			wantHost:   "h",
+			wantPort:   1433,
+			wantParams: map[string]string{"dial timeout": "10"},
+		},
+		{
+			name:       "options",
+			config:     types.DbConfig{Dialect: "mssql", Host: "h", Options: map[string]string{"app name": "goreports", "database": "other"}},
+			wantHost:   "h",
+			wantPort:   1433,
+			wantParams: map[string]string{"app name": "goreports", "database": "other"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			connection := mustConnectionOf(t, testCase.config)
+			if connection.Driver != "sqlserver" {
+				t.Errorf("got the driver %s, want sqlserver", connection.Driver)
+			}
+
+			dsn, params, err := msdsn.Parse(connection.Dsn)
+			if err != nil {
+				t.Fatalf("error while parsing %s: %v", connection.Dsn, err)
+			}
+			if dsn.Host != testCase.wantHost || dsn.Port != testCase.wantPort {
+				t.Errorf("got the address %s:%d, want %s:%d", dsn.Host, dsn.Port, testCase.wantHost, testCase.wantPort)
+			}
+			for name, want := range testCase.wantParams {
+				if params[name] != want {
+					t.Errorf("got the parameter %s=%q, want %q", name, params[name], want)
+				}
+			}
+			for _, name := range testCase.missingParams {
+				if value, found := params[name]; found {
+					t.Errorf("got the parameter %s=%q, want none", name, value)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectionOfMssqlCredentials(t *testing.T) {
+	connection := mustConnectionOf(t, types.DbConfig{Dialect: "mssql", Host: "h", Username: "reporter", Password: "p@ss:w/rd?#"})
+
+	dsn, _, err := msdsn.Parse(connection.Dsn)
+	if err != nil {
+		t.Fatalf("error while parsing %s: %v", connection.Dsn, err)
+	}
+	if dsn.User != "reporter" || dsn.Password != "p@ss:w/rd?#" {
+		t.Errorf("got the credentials %s:%s, want reporter:p@ss:w/rd?#", dsn.User, dsn.Password)
+	}
+}
+
+func TestConnectionOfRawDsn(t *testing.T) {
+	cases := []struct {
+		dialect    string
+		dsn        string
+		wantDriver string
+	}{
+		{dialect: "postgres", dsn: "host=db user=goreports sslmode=verify-full", wantDriver: "postgres"},
+		{dialect: "mysql", dsn: "goreports:secret@tcp(db:3306)/sales?parseTime=true", wantDriver: "mysql"},
+		{dialect: "mariadb", dsn: "goreports:secret@unix(/run/mysqld.sock)/sales", wantDriver: "mysql"},
+		{dialect: "mssql", dsn: "sqlserver://goreports:secret@db?database=sales", wantDriver: "sqlserver"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.dialect, func(t *testing.T) {
+			// The connection fields are ignored, the invalid TLS files included.
+			config := types.DbConfig{
+				Dialect: testCase.dialect,
+				Host:    "ignored",
+				Port:    1,
+				Tls:     types.DbTlsConfig{Mode: TlsModeVerifyFull, CaCertPath: "/missing/ca.pem"},
+				Options: map[string]string{"ignored": "true"},
+				Dsn:     testCase.dsn,
+			}
+
+			connection := mustConnectionOf(t, config)
+			if connection.Driver != testCase.wantDriver || connection.Dsn != testCase.dsn {
+				t.Errorf("got %s %q, want %s %q", connection.Driver, connection.Dsn, testCase.wantDriver, testCase.dsn)
+			}
+		})
+	}
+}
+
+func TestConnectionOfErrors(t *testing.T) {
+	invalidCaCertPath := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalidCaCertPath, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		config  types.DbConfig
+		wantErr string
+	}{
+		{name: "unsupported dialect", config: types.DbConfig{Dialect: "oracle"}, wantErr: "unsupported dialect oracle"},
+		{name: "unsupported tls mode", config: types.DbConfig{Dialect: "postgres", Tls: types.DbTlsConfig{Mode: "prefer"}}, wantErr: "unsupported TLS mode prefer"},
+		{name: "mssql verify-ca", config: types.DbConfig{Dialect: "mssql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyCa}}, wantErr: "the verify-ca TLS mode isn't supported by SQL Server"},
+		{name: "mysql missing ca", config: types.DbConfig{Dialect: "mysql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyCa, CaCertPath: "/missing/ca.pem"}}, wantErr: "/missing/ca.pem"},
+		{name: "mysql invalid ca", config: types.DbConfig{Dialect: "mysql", Host: "h", Tls: types.DbTlsConfig{Mode: TlsModeVerifyCa, CaCertPath: invalidCaCertPath}}, wantErr: "no certificate found in " + invalidCaCertPath},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, errOpt := ConnectionOf(testCase.config)
+			if errOpt.IsNone() {
+				t.Fatalf("got no error, want one containing %q", testCase.wantErr)
+			}
+			if !strings.Contains(errOpt.Unwrap().Error(), testCase.wantErr) {
+				t.Errorf("got the error %q, want it to contain %q", errOpt.Unwrap(), testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestTlsConfigOf(t *testing.T) {
+	ca, caKey := newCa(t)
+	caCertPath := writeCert(t, ca)
+	otherCa, otherCaKey := newCa(t)
+
+	serverCert := newServerCert(t, ca, caKey, "db.internal")
+	untrustedCert := newServerCert(t, otherCa, otherCaKey, "db.internal")
+
+	t.Run("verify-ca checks the chain without the host name", func(t *testing.T) {
+		tlsConfig, errOpt := tlsConfigOf(types.DbTlsConfig{Mode: TlsModeVerifyCa, CaCertPath: caCertPath}, "db.example.com")
+		if errOpt.IsSome() {
+			t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+		}
+
+		err := tlsConfig.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{serverCert}})
+		if err != nil {
+			t.Errorf("got %v, want the certificate of another host signed by the CA to be accepted", err)
+		}
+		err = tlsConfig.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrustedCert}})
+		if err == nil {
+			t.Error("got no error, want the certificate signed by another CA to be rejected")
+		}
+		err = tlsConfig.VerifyConnection(tls.ConnectionState{})
+		if err == nil {
+			t.Error("got no error, want a server without a certificate to be rejected")
+		}
+	})
+
+	t.Run("verify-full checks the host name", func(t *testing.T) {
+		tlsConfig, errOpt := tlsConfigOf(types.DbTlsConfig{Mode: TlsModeVerifyFull, CaCertPath: caCertPath}, "db.example.com")
+		if errOpt.IsSome() {
+			t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+		}
+
+		// crypto/tls verifies the chain and the host name itself.
+		if tlsConfig.InsecureSkipVerify || tlsConfig.VerifyConnection != nil || tlsConfig.ServerName != "db.example.com" {
+			t.Errorf("got InsecureSkipVerify=%v and ServerName=%q, want the default verification of db.example.com", tlsConfig.InsecureSkipVerify, tlsConfig.ServerName)
+		}
+		_, err := serverCert.Verify(x509.VerifyOptions{Roots: tlsConfig.RootCAs, DNSName: tlsConfig.ServerName})
+		if err == nil {
+			t.Error("got no error, want the certificate of another host to be rejected")
+		}
+	})
+
+	t.Run("require doesn't check the certificate", func(t *testing.T) {
+		tlsConfig, errOpt := tlsConfigOf(types.DbTlsConfig{Mode: TlsModeRequire}, "db.example.com")
+		if errOpt.IsSome() {
+			t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+		}
+
+		if !tlsConfig.InsecureSkipVerify || tlsConfig.VerifyConnection != nil {
+			t.Error("got a verified connection, want the certificate not to be checked")
+		}
+	})
+}
+
+func mustConnectionOf(t *testing.T, config types.DbConfig) Connection {
+	t.Helper()
+
+	connection, errOpt := ConnectionOf(config)
+	if errOpt.IsSome() {
+		t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+	}
+
+	return connection
+}
+
+// checkParams checks the parameters of a URL query.
+func checkParams(t *testing.T, params url.Values, want map[string]string, missing []string) {
+	t.Helper()
+
+	for name, value := range want {
+		if params.Get(name) != value {
+			t.Errorf("got the parameter %s=%q, want %q", name, params.Get(name), value)
+		}
+	}
+	for _, name := range missing {
+		if params.Has(name) {
+			t.Errorf("got the parameter %s=%q, want none", name, params.Get(name))
+		}
+	}
+}
+
+// writeCaCert writes a self-signed CA certificate to a temporary file and returns its path.
+func writeCaCert(t *testing.T) string {
+	t.Helper()
+
+	ca, _ := newCa(t)
+
+	return writeCert(t, ca)
+}
+
+// newCa returns a self-signed CA certificate and its key.
+func newCa(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "GoReports test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	return newCert(t, template, nil, nil)
+}
+
+// newServerCert returns a certificate of the host signed by the CA.
+func newServerCert(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, host string) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	cert, _ := newCert(t, template, ca, caKey)
+
+	return cert
+}
+
+// newCert creates the certificate signed by the parent, or self-signed if there is no parent.
+func newCert(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+// writeCert writes the certificate to a temporary PEM file and returns its path.
+func writeCert(t *testing.T, cert *x509.Certificate) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
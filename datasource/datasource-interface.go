@@ -1,6 +1,7 @@
 package datasource
 
 import (
+	"context"
 	"database/sql"
 	"github.com/okira-e/goreports/safego"
 )
@@ -9,6 +10,21 @@ type DataSource interface {
 	Connect() safego.Option[error]
 	Disconnect() safego.Option[error]
 	Ping() safego.Option[error]
+	// PingContext is Ping canceled when the context is done.
+	PingContext(context.Context) safego.Option[error]
+	// Stats returns the statistics of the connection pool.
+	Stats() sql.DBStats
 	Query(string, ...any) (*sql.Rows, safego.Option[error])
+	// QueryContext is Query canceled when the context is done.
+	QueryContext(context.Context, string, ...any) (*sql.Rows, safego.Option[error])
 	Exec(string, ...any) safego.Option[error]
+	// ReadOnlyQueryContext is QueryContext run in a transaction that can't write to the database. The rows are given to
+	// read, and the transaction is rolled back once it returns.
+	ReadOnlyQueryContext(context.Context, func(*sql.Rows) safego.Option[error], string, ...any) safego.Option[error]
+	// ExecContext is Exec canceled when the context is done.
+	ExecContext(context.Context, string, ...any) safego.Option[error]
+	// ExecAll executes the statements in a single transaction. None of them is applied if one fails.
+	ExecAll(...Statement) safego.Option[error]
+	// Prepare checks a query against the database without executing it.
+	Prepare(string) safego.Option[error]
 }
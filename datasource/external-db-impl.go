@@ -1,37 +1,47 @@
 package datasource
 
 import (
+	"context"
 	"database/sql"
 	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
 	"github.com/okira-e/goreports/safego"
-	"strings"
+	"github.com/okira-e/goreports/types"
+	"time"
 )
 
 // ExternalDb is a wrapper for the sql.DB type.
 type ExternalDb struct {
-	db            *sql.DB
-	connectionStr string
+	db         *sql.DB
+	connection Connection
+	pool       types.PoolConfig
 }
 
-// NewExternalDb returns a new ExternalDb instance.
-func NewExternalDb(connectionStr string) *ExternalDb {
+// NewExternalDb returns a new ExternalDb instance keeping its connections open as the pool config says.
+func NewExternalDb(connection Connection, pool types.PoolConfig) *ExternalDb {
 	return &ExternalDb{
-		db:            nil,
-		connectionStr: connectionStr,
+		db:         nil,
+		connection: connection,
+		pool:       pool,
 	}
 }
 
-// Connect connects to the database.
+// Connect sets up the connection pool of the database. The connections are opened lazily, the database doesn't have
+// to be up.
 func (self *ExternalDb) Connect() safego.Option[error] {
-	dialect := self.connectionStr[:strings.Index(self.connectionStr, ":")]
-
-	db, err := sql.Open(dialect, self.connectionStr)
+	db, err := sql.Open(self.connection.Driver, self.connection.Dsn)
 	if err != nil {
 		return safego.Some(err)
 	}
 
+	db.SetMaxOpenConns(self.pool.MaxOpenConns)
+	if self.pool.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(self.pool.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(time.Duration(self.pool.ConnMaxLifetimeSeconds) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(self.pool.ConnMaxIdleTimeSeconds) * time.Second)
+
 	self.db = db
 
 	return safego.None[error]()
@@ -49,17 +59,33 @@ func (self *ExternalDb) Disconnect() safego.Option[error] {
 
 // Ping pings the database.
 func (self *ExternalDb) Ping() safego.Option[error] {
-	err := self.db.Ping()
+	return self.PingContext(context.Background())
+}
+
+// PingContext pings the database, connecting to it if needed. The ping is canceled when the context is done.
+func (self *ExternalDb) PingContext(ctx context.Context) safego.Option[error] {
+	err := self.db.PingContext(ctx)
 	if err != nil {
-		safego.Some(err)
+		return safego.Some(err)
 	}
 
 	return safego.None[error]()
 }
 
+// Stats returns the statistics of the connection pool.
+func (self *ExternalDb) Stats() sql.DBStats {
+	return self.db.Stats()
+}
+
 // Query executes a query that returns rows.
 func (self *ExternalDb) Query(query string, args ...any) (*sql.Rows, safego.Option[error]) {
-	rows, err := self.db.Query(query, args...)
+	return self.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext executes a query that returns rows. The query is canceled and the rows are closed when the context is
+// done.
+func (self *ExternalDb) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, safego.Option[error]) {
+	rows, err := self.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, safego.Some(err)
 	}
@@ -67,9 +93,66 @@ func (self *ExternalDb) Query(query string, args ...any) (*sql.Rows, safego.Opti
 	return rows, safego.None[error]()
 }
 
+// ReadOnlyQueryContext executes a query that returns rows in a read-only transaction, and gives the rows to read.
+// The transaction is always rolled back. SQL Server has no read-only transactions, so the writes of the query are only
+// undone by the rollback there.
+func (self *ExternalDb) ReadOnlyQueryContext(ctx context.Context, read func(*sql.Rows) safego.Option[error], query string, args ...any) safego.Option[error] {
+	options := &sql.TxOptions{ReadOnly: self.connection.Driver != "sqlserver"}
+
+	tx, err := self.db.BeginTx(ctx, options)
+	if err != nil {
+		return safego.Some(err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return safego.Some(err)
+	}
+	defer rows.Close()
+
+	return read(rows)
+}
+
 // Exec executes a query that returns a single row.
 func (self *ExternalDb) Exec(query string, args ...any) safego.Option[error] {
-	_, err := self.db.Exec(query, args...)
+	return self.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext executes a query that returns a single row. The query is canceled when the context is done.
+func (self *ExternalDb) ExecContext(ctx context.Context, query string, args ...any) safego.Option[error] {
+	_, err := self.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	return safego.None[error]()
+}
+
+// ExecAll executes the statements in a single transaction, rolled back if any of them fails.
+func (self *ExternalDb) ExecAll(statements ...Statement) safego.Option[error] {
+	return execInTransaction(self.db, statements)
+}
+
+// Prepare checks a query against the database without executing it.
+// The SQL Server driver prepares statements lazily, so sp_describe_first_result_set is used instead to have the server
+// resolve the query.
+func (self *ExternalDb) Prepare(query string) safego.Option[error] {
+	if self.connection.Driver == "sqlserver" {
+		_, err := self.db.Exec("sp_describe_first_result_set @tsql = @p1", query)
+		if err != nil {
+			return safego.Some(err)
+		}
+
+		return safego.None[error]()
+	}
+
+	stmt, err := self.db.Prepare(query)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	err = stmt.Close()
 	if err != nil {
 		return safego.Some(err)
 	}
@@ -1,6 +1,7 @@
 package datasource
 
 import (
+	"context"
 	"database/sql"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/okira-e/goreports/safego"
@@ -24,7 +25,7 @@ func NewSqliteDb(dbPath string) *SqliteDb {
 func (self *SqliteDb) Connect() safego.Option[error] {
 	db, err := sql.Open("sqlite3", self.dbPath)
 	if err != nil {
-		safego.Some(err)
+		return safego.Some(err)
 	}
 
 	self.db = db
@@ -36,7 +37,7 @@ func (self *SqliteDb) Connect() safego.Option[error] {
 func (self *SqliteDb) Disconnect() safego.Option[error] {
 	err := self.db.Close()
 	if err != nil {
-		safego.Some(err)
+		return safego.Some(err)
 	}
 
 	return safego.None[error]()
@@ -44,17 +45,33 @@ func (self *SqliteDb) Disconnect() safego.Option[error] {
 
 // Ping pings the database.
 func (self *SqliteDb) Ping() safego.Option[error] {
-	err := self.db.Ping()
+	return self.PingContext(context.Background())
+}
+
+// PingContext pings the database, connecting to it if needed. The ping is canceled when the context is done.
+func (self *SqliteDb) PingContext(ctx context.Context) safego.Option[error] {
+	err := self.db.PingContext(ctx)
 	if err != nil {
-		safego.Some(err)
+		return safego.Some(err)
 	}
 
 	return safego.None[error]()
 }
 
+// Stats returns the statistics of the connection pool.
+func (self *SqliteDb) Stats() sql.DBStats {
+	return self.db.Stats()
+}
+
 // Query executes a query that returns rows.
 func (self *SqliteDb) Query(query string, args ...any) (*sql.Rows, safego.Option[error]) {
-	rows, err := self.db.Query(query, args...)
+	return self.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext executes a query that returns rows. The query is canceled and the rows are closed when the context is
+// done.
+func (self *SqliteDb) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, safego.Option[error]) {
+	rows, err := self.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, safego.Some(err)
 	}
@@ -62,11 +79,67 @@ func (self *SqliteDb) Query(query string, args ...any) (*sql.Rows, safego.Option
 	return rows, safego.None[error]()
 }
 
+// ReadOnlyQueryContext executes a query that returns rows with the query_only pragma set on the connection, and gives
+// the rows to read. The query runs in a transaction that is always rolled back.
+func (self *SqliteDb) ReadOnlyQueryContext(ctx context.Context, read func(*sql.Rows) safego.Option[error], query string, args ...any) safego.Option[error] {
+	conn, err := self.db.Conn(ctx)
+	if err != nil {
+		return safego.Some(err)
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "PRAGMA query_only = ON")
+	if err != nil {
+		return safego.Some(err)
+	}
+	// The connection goes back to the pool, the other queries must be able to write with it.
+	defer conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return safego.Some(err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return safego.Some(err)
+	}
+	defer rows.Close()
+
+	return read(rows)
+}
+
 // Exec executes a query that returns a single row.
 func (self *SqliteDb) Exec(query string, args ...any) safego.Option[error] {
-	_, err := self.db.Exec(query, args...)
+	return self.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext executes a query that returns a single row. The query is canceled when the context is done.
+func (self *SqliteDb) ExecContext(ctx context.Context, query string, args ...any) safego.Option[error] {
+	_, err := self.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	return safego.None[error]()
+}
+
+// ExecAll executes the statements in a single transaction, rolled back if any of them fails.
+func (self *SqliteDb) ExecAll(statements ...Statement) safego.Option[error] {
+	return execInTransaction(self.db, statements)
+}
+
+// Prepare compiles a query without executing it.
+func (self *SqliteDb) Prepare(query string) safego.Option[error] {
+	stmt, err := self.db.Prepare(query)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	err = stmt.Close()
 	if err != nil {
-		safego.Some(err)
+		return safego.Some(err)
 	}
 
 	return safego.None[error]()
@@ -0,0 +1,36 @@
+package datasource
+
+import (
+	"database/sql"
+	"github.com/okira-e/goreports/safego"
+)
+
+// Statement is a query to execute along with its arguments.
+type Statement struct {
+	Query string
+	Args  []any
+}
+
+// execInTransaction executes the statements in a single transaction, rolled back if any of them fails.
+func execInTransaction(db *sql.DB, statements []Statement) safego.Option[error] {
+	tx, err := db.Begin()
+	if err != nil {
+		return safego.Some(err)
+	}
+	// Rolling back a committed transaction is a no-op.
+	defer tx.Rollback()
+
+	for _, statement := range statements {
+		_, err = tx.Exec(statement.Query, statement.Args...)
+		if err != nil {
+			return safego.Some(err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	return safego.None[error]()
+}
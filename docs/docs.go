@@ -5,39 +5,104 @@ package docs
 import "github.com/swaggo/swag"
 
 const docTemplate = `{
-    "schemes": {{ marshal .Schemes }},
+    "schemes": [[ marshal .Schemes ]],
     "swagger": "2.0",
     "info": {
-        "description": "{{escape .Description}}",
-        "title": "{{.Title}}",
+        "description": "[[escape .Description]]",
+        "title": "[[.Title]]",
         "contact": {},
-        "version": "{{.Version}}"
+        "version": "[[.Version]]"
     },
-    "host": "{{.Host}}",
-    "basePath": "{{.BasePath}}",
+    "host": "[[.Host]]",
+    "basePath": "[[.BasePath]]",
     "paths": {
-        "/report/delete": {
+        "/health": {
+            "get": {
+                "description": "Report the outcome of the last check of every data source, the state of their connection pools and\nwhether wkhtmltopdf is found. The status is 503 when any of them is down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Get the health of the server",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.HealthStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/report/assets": {
+            "get": {
+                "description": "List the assets of a report, or the global assets if reportName is omitted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "List assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.Asset"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/report/assets/delete": {
             "delete": {
-                "description": "Delete a report",
+                "description": "Delete an asset of a report, or a global asset if reportName is omitted",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "reports"
+                    "assets"
                 ],
-                "summary": "Delete a report",
+                "summary": "Delete an asset",
                 "parameters": [
                     {
-                        "description": "The name of the report to be deleted",
-                        "name": "reportName",
+                        "description": "The name of the asset",
+                        "name": "name",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    {
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 ],
                 "responses": {
@@ -47,16 +112,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/report/list": {
+        "/report/assets/download": {
             "get": {
-                "description": "List all stored reports",
+                "description": "Download an asset of a report, or a global asset if reportName is omitted",
                 "produces": [
-                    "text/plain"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "reports"
+                    "assets"
+                ],
+                "summary": "Download an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The name of the asset",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List all reports",
                 "responses": {
                     "200": {
                         "description": "OK"
@@ -64,56 +144,117 @@ const docTemplate = `{
                 }
             }
         },
-        "/report/render": {
+        "/report/assets/upload": {
             "post": {
-                "description": "Render a report",
+                "description": "Store an image, font or stylesheet for a report, or for every report if reportName is omitted.\nThe request body is the content of the file. An asset with the same name is replaced.\nTemplates reference it with {{asset \"name\"}} or asset://name.",
                 "consumes": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "reports"
+                    "assets"
                 ],
-                "summary": "Render a report",
+                "summary": "Upload an asset",
                 "parameters": [
                     {
+                        "type": "string",
+                        "description": "The name of the asset, e.g. logo.png or fonts/inter.woff2",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
                         "description": "The name of the report",
                         "name": "reportName",
-                        "in": "body",
-                        "required": true,
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/types.Asset"
                         }
-                    },
+                    }
+                }
+            }
+        },
+        "/report/cache/invalidate": {
+            "post": {
+                "description": "Delete the cached query results and rendered documents of a report, or of every report when reportName\nis omitted. The queries of the sub-reports are cached under the name of the sub-report.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cache"
+                ],
+                "summary": "Invalidate the caches",
+                "parameters": [
                     {
-                        "description": "The parameters injected inside the report body to be passed at runtime",
-                        "name": "params",
+                        "description": "The report whose query results and documents are deleted. Defaults to every report",
+                        "name": "reportName",
                         "in": "body",
                         "schema": {
-                            "type": "object"
+                            "type": "string"
                         }
-                    },
-                    {
-                        "description": "The printing options to be used in the report",
-                        "name": "printingOptions",
-                        "in": "body",
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/report/cache/outputs/stats": {
+            "get": {
+                "description": "Count the hits, misses and evictions of the rendered document cache since the server started",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cache"
+                ],
+                "summary": "Get the rendered document cache stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.PrintingOptions"
+                            "$ref": "#/definitions/types.OutputCacheStats"
                         }
                     }
+                }
+            }
+        },
+        "/report/cache/stats": {
+            "get": {
+                "description": "Count the hits and misses of the query cache since the server started, overall and per report",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cache"
                 ],
+                "summary": "Get the query cache stats",
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.QueryCacheStats"
+                        }
                     }
                 }
             }
         },
-        "/report/save": {
-            "post": {
-                "description": "Save a report",
+        "/report/delete": {
+            "delete": {
+                "description": "Delete a report",
                 "consumes": [
                     "application/json"
                 ],
@@ -123,91 +264,1048 @@ const docTemplate = `{
                 "tags": [
                     "reports"
                 ],
-                "summary": "Save a report",
+                "summary": "Delete a report",
                 "parameters": [
                     {
-                        "description": "The name of the report",
+                        "description": "The name of the report to be deleted",
                         "name": "reportName",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "string"
                         }
-                    },
-                    {
-                        "description": "The title of the report",
-                        "name": "title",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/report/export": {
+            "get": {
+                "description": "Export reports as a zip bundle holding a manifest and the templates of every report",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "bundles"
+                ],
+                "summary": "Export reports",
+                "parameters": [
                     {
-                        "description": "The description of the report",
-                        "name": "description",
-                        "in": "body",
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "Comma-separated names of the reports to export. Defaults to every report",
+                        "name": "names",
+                        "in": "query"
                     },
                     {
-                        "description": "The body of the report",
-                        "name": "` + "`" + `body` + "`" + `",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
+                        "type": "string",
+                        "description": "The format of the manifest: yaml (default) or json",
+                        "name": "manifestFormat",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/report/import": {
+            "post": {
+                "description": "Import the reports of a zip bundle made by /report/export.\nThe reports to create or update are validated first, the invalid ones are reported and not written.",
+                "consumes": [
+                    "application/zip"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bundles"
+                ],
+                "summary": "Import reports",
+                "parameters": [
                     {
-                        "description": "The header of the report",
-                        "name": "header",
-                        "in": "body",
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "create-only (default) leaves existing reports untouched, overwrite replaces them",
+                        "name": "mode",
+                        "in": "query"
                     },
                     {
-                        "description": "The footer of the report",
-                        "name": "footer",
-                        "in": "body",
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "boolean",
+                        "description": "Describe what would change, with a diff of the templates, without writing anything",
+                        "name": "dryRun",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ImportResult"
+                            }
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "types.PageNumbersOptions": {
-            "type": "object",
-            "properties": {
-                "enabled": {
-                    "type": "boolean"
-                },
-                "position": {
-                    "type": "string"
+        },
+        "/report/list": {
+            "get": {
+                "description": "List all stored reports",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "List all reports",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
                 }
             }
         },
-        "types.PrintingOptions": {
-            "type": "object",
-            "properties": {
-                "landscape": {
-                    "type": "boolean"
-                },
-                "marginBottom": {
-                    "type": "integer"
-                },
-                "marginLeft": {
-                    "type": "integer"
+        "/report/outputs": {
+            "get": {
+                "description": "List the rendered documents archived in the output storage, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "outputs"
+                ],
+                "summary": "List archived outputs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list the outputs whose key starts with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.StoredOutput"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/report/outputs/download": {
+            "get": {
+                "description": "Download a rendered document from the output storage",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "outputs"
+                ],
+                "summary": "Download an archived output",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The key of the output",
+                        "name": "key",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "The output was not found"
+                    }
+                }
+            }
+        },
+        "/report/outputs/purge": {
+            "post": {
+                "description": "Delete the archived outputs that break the configured retention rules",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "outputs"
+                ],
+                "summary": "Purge archived outputs",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/report/partials": {
+            "get": {
+                "description": "List the partials and layouts shared between reports",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "partials"
+                ],
+                "summary": "List partials",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.Partial"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/report/partials/delete": {
+            "delete": {
+                "description": "Delete a partial or layout. It fails with 409 while reports use it, unless force is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "partials"
+                ],
+                "summary": "Delete a partial",
+                "parameters": [
+                    {
+                        "description": "The name of the partial",
+                        "name": "name",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Delete the partial even if reports use it",
+                        "name": "force",
+                        "in": "body",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "409": {
+                        "description": "The reports using the partial",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/report/partials/dependents": {
+            "get": {
+                "description": "List the reports that include or extend a partial, directly or through other partials",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "partials"
+                ],
+                "summary": "List the reports using a partial",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The name of the partial",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/report/partials/save": {
+            "post": {
+                "description": "Create a partial or layout, or replace the one with the same name.\nTemplates include it with {{\u003e name}} or extend it with {{#extends \"name\"}}.\nThe response lists the reports using it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "partials"
+                ],
+                "summary": "Save a partial",
+                "parameters": [
+                    {
+                        "description": "The name of the partial",
+                        "name": "name",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The description of the partial",
+                        "name": "description",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The template of the partial",
+                        "name": "` + "`" + `body` + "`" + `",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/report/render": {
+            "post": {
+                "description": "Render a report",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Render a report",
+                "parameters": [
+                    {
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The parameters injected inside the report body to be passed at runtime",
+                        "name": "params",
+                        "in": "body",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "description": "The printing options to be used in the report. Defaults to the printing options saved with the report",
+                        "name": "printingOptions",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/types.PrintingOptions"
+                        }
+                    },
+                    {
+                        "description": "The locale to format values and translate the report in, e.g. fr or ar",
+                        "name": "locale",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Archive the rendered document in the output storage",
+                        "name": "archive",
+                        "in": "body",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    {
+                        "description": "Render in the background and POST the signed result to this URL when done. Requires webhook_config.secret",
+                        "name": "callbackUrl",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "The ETag of a previous response, answered with 304 if the document is the same",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                }
+            }
+        },
+        "/report/render/batch": {
+            "post": {
+                "description": "Render a report once per parameter set, given as items or as the rows of itemsQuery, and return a single\nPDF with a bookmark per item or a ZIP archive with a file per item.\nThe items that fail are left out. The X-Batch-Failed header counts them and X-Batch-Errors lists them as JSON.\nThe ZIP archive also lists them in errors.json. It responds with 422 and the results if no item could be rendered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/pdf",
+                    "application/zip"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Render a report in batch",
+                "parameters": [
+                    {
+                        "description": "The report and its parameter sets",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.BatchRender"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "422": {
+                        "description": "The results of the items, none of which could be rendered",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.BatchItemResult"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/report/render/cancel": {
+            "post": {
+                "description": "Cancel a render started with a callbackUrl. Its queries and wkhtmltopdf are stopped and the callback\nURL is notified with the canceled status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Cancel a background render",
+                "parameters": [
+                    {
+                        "description": "The id of the render returned when it was started",
+                        "name": "renderId",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "The render is not running"
+                    }
+                }
+            }
+        },
+        "/report/save": {
+            "post": {
+                "description": "Save a report",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Save a report",
+                "parameters": [
+                    {
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The title of the report",
+                        "name": "title",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The description of the report",
+                        "name": "description",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The body of the report",
+                        "name": "` + "`" + `body` + "`" + `",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The header of the report",
+                        "name": "header",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The footer of the report",
+                        "name": "footer",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The default printing options of the report",
+                        "name": "printingOptions",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/types.PrintingOptions"
+                        }
+                    },
+                    {
+                        "description": "The definitions of the report parameters",
+                        "name": "parameters",
+                        "in": "body",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ReportParameter"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "400": {
+                        "description": "The template is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/types.ValidationResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/report/translations": {
+            "get": {
+                "description": "List the translation tables of a report by locale",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "List the translations of a report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "object",
+                                "additionalProperties": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/report/translations/delete": {
+            "delete": {
+                "description": "Delete the translation table of a report in a locale",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Delete the translations of a report",
+                "parameters": [
+                    {
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The locale of the translations",
+                        "name": "locale",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/report/translations/save": {
+            "post": {
+                "description": "Replace the translation table of a report in a locale",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Save the translations of a report",
+                "parameters": [
+                    {
+                        "description": "The name of the report",
+                        "name": "reportName",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The locale of the translations, e.g. fr or fr-CA",
+                        "name": "locale",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The translations by {{t}} key",
+                        "name": "translations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/report/validate": {
+            "post": {
+                "description": "Check the handlebars, the [P[...]]/[Q[...]] directives and the queries of a report without rendering it.\nValidates the saved report named reportName, or the given body when provided.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Validate a report",
+                "parameters": [
+                    {
+                        "description": "The name of a saved report",
+                        "name": "reportName",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The body to validate instead of a saved report",
+                        "name": "` + "`" + `body` + "`" + `",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "The definitions of the parameters of the body",
+                        "name": "parameters",
+                        "in": "body",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ReportParameter"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ValidationResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/report/webhooks/deliveries": {
+            "get": {
+                "description": "List the delivery attempts of render callbacks, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list the deliveries of this render",
+                        "name": "renderId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.WebhookDelivery"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "types.Asset": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "description": "Checksum is the hex encoded SHA-256 of the data.",
+                    "type": "string"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "reportName": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error is empty when the item was rendered.",
+                    "type": "string"
+                },
+                "index": {
+                    "description": "Index is the position of the item in the batch, from 0.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BatchRender": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "description": "Concurrency is how many items are rendered at the same time. Defaults to 4.",
+                    "type": "integer"
+                },
+                "format": {
+                    "description": "Format is the format of the files of a zip output: pdf (default), html or csv.",
+                    "type": "string"
+                },
+                "items": {
+                    "description": "Items are the parameter sets, one per rendered document.",
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": {}
+                    }
+                },
+                "itemsQuery": {
+                    "description": "ItemsQuery is a query whose rows are the parameter sets, used when Items is empty. It can use the [P[...]] Params.",
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "nameTemplate": {
+                    "description": "NameTemplate is a handlebars template of the file names and bookmark titles, rendered with the parameters of\neach item, e.g. statement-{{customer_id}}. Defaults to \u003creport name\u003e-\u003citem number\u003e.",
+                    "type": "string"
+                },
+                "output": {
+                    "description": "Output is pdf, a single document with a bookmark per item, or zip, an archive with a file per item.",
+                    "type": "string"
+                },
+                "params": {
+                    "description": "Params are given to every item. The parameters of an item take precedence.",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "printingOptions": {
+                    "$ref": "#/definitions/types.PrintingOptions"
+                },
+                "reportName": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.DataSourceHealth": {
+            "type": "object",
+            "properties": {
+                "checkedAt": {
+                    "description": "CheckedAt is when the data source was last checked, in nanoseconds since the epoch.",
+                    "type": "integer"
+                },
+                "consecutiveFailures": {
+                    "description": "ConsecutiveFailures is how many checks failed in a row since the data source was last up.",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "idleConnections": {
+                    "type": "integer"
+                },
+                "inUseConnections": {
+                    "type": "integer"
+                },
+                "latencyMs": {
+                    "type": "integer"
+                },
+                "openConnections": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status is \"up\", \"down\", or \"unknown\" until the data source is first checked.",
+                    "type": "string"
+                },
+                "waitCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.HealthStatus": {
+            "type": "object",
+            "properties": {
+                "dataSources": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/types.DataSourceHealth"
+                    }
+                },
+                "renderer": {
+                    "$ref": "#/definitions/types.RendererHealth"
+                },
+                "status": {
+                    "description": "Status is \"up\" when the data sources and the renderer are all up, else \"down\".",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ImportResult": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action is one of \"create\", \"update\", \"skip\" (exists and the mode is create-only), \"unchanged\" or \"invalid\" (the\nreport would be created or updated, but its template has errors and it isn't written).",
+                    "type": "string"
+                },
+                "changedFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "diff": {
+                    "description": "Diff is a unified-style line diff of the changed templates.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "problems": {
+                    "description": "Problems are those found validating a report that would be created or updated, the warnings included.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ValidationProblem"
+                    }
+                }
+            }
+        },
+        "types.OutputCacheStats": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hitRatio": {
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "sizeBytes": {
+                    "description": "SizeBytes is the total size of the cached documents.",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.PageNumbersOptions": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "position": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.Partial": {
+            "type": "object",
+            "required": [
+                "body",
+                "name"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.PrintingOptions": {
+            "type": "object",
+            "properties": {
+                "landscape": {
+                    "type": "boolean"
+                },
+                "marginBottom": {
+                    "type": "integer"
+                },
+                "marginLeft": {
+                    "type": "integer"
                 },
                 "marginRight": {
                     "type": "integer"
@@ -222,6 +1320,165 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "types.QueryCacheReportStats": {
+            "type": "object",
+            "properties": {
+                "hitRatio": {
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.QueryCacheStats": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "type": "string"
+                },
+                "entries": {
+                    "description": "Entries is the number of cached results, expired ones included until they are evicted.",
+                    "type": "integer"
+                },
+                "hitRatio": {
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "reports": {
+                    "description": "Reports holds the lookups of the queries of every report.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/types.QueryCacheReportStats"
+                    }
+                }
+            }
+        },
+        "types.RendererHealth": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is \"up\" when wkhtmltopdf is found, else \"down\".",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ReportParameter": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default is used when a render request doesn't provide the parameter."
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "required": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "Type is informative: string, number, boolean or date.",
+                    "type": "string"
+                }
+            }
+        },
+        "types.StoredOutput": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "lastModified": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ValidationProblem": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "line": {
+                    "description": "Line and Column are 1-based. Column is 0 when only the line is known.",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ValidationResult": {
+            "type": "object",
+            "properties": {
+                "parameters": {
+                    "description": "Parameters lists the [P[...]] parameters of the template, in order of appearance.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "problems": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ValidationProblem"
+                    }
+                },
+                "valid": {
+                    "description": "Valid is set when none of the problems is an error.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "canceled": {
+                    "description": "Canceled is set when the attempt failed because the delivery was canceled.",
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "renderId": {
+                    "type": "string"
+                },
+                "statusCode": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -236,8 +1493,8 @@ var SwaggerInfo = &swag.Spec{
 	Description:      "",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
-	LeftDelim:        "{{",
-	RightDelim:       "}}",
+	LeftDelim:        "[[",
+	RightDelim:       "]]",
 }
 
 func init() {
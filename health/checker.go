@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"log"
+	"sync"
+	"time"
+)
+
+// The defaults of the config.
+const (
+	DefaultInterval   = 30 * time.Second
+	DefaultTimeout    = 5 * time.Second
+	DefaultMaxBackoff = time.Minute
+)
+
+// The statuses of a data source.
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusUnknown = "unknown"
+)
+
+// initialBackoff is the wait after the first failed check, it doubles after every other one.
+const initialBackoff = time.Second
+
+// Checker pings the data sources periodically and keeps the outcome of their last check. The data sources that are
+// down are pinged again sooner, with a backoff, so they are connected to as soon as they are up.
+type Checker struct {
+	interval   time.Duration
+	timeout    time.Duration
+	maxBackoff time.Duration
+
+	mutex       sync.Mutex
+	dataSources map[string]*datasource.DataSource
+	statuses    map[string]types.DataSourceHealth
+	stop        chan struct{}
+}
+
+// NewChecker returns a new Checker instance.
+func NewChecker(config types.HealthCheckConfig) *Checker {
+	checker := &Checker{
+		interval:    DefaultInterval,
+		timeout:     DefaultTimeout,
+		maxBackoff:  DefaultMaxBackoff,
+		dataSources: map[string]*datasource.DataSource{},
+		statuses:    map[string]types.DataSourceHealth{},
+		stop:        make(chan struct{}),
+	}
+	if config.IntervalSeconds > 0 {
+		checker.interval = time.Duration(config.IntervalSeconds) * time.Second
+	}
+	if config.TimeoutSeconds > 0 {
+		checker.timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	if config.MaxBackoffSeconds > 0 {
+		checker.maxBackoff = time.Duration(config.MaxBackoffSeconds) * time.Second
+	}
+
+	return checker
+}
+
+// Watch checks the data source right away, then periodically until Stop is called.
+func (self *Checker) Watch(name string, ds *datasource.DataSource) {
+	self.mutex.Lock()
+	self.dataSources[name] = ds
+	self.statuses[name] = types.DataSourceHealth{Status: StatusUnknown}
+	self.mutex.Unlock()
+
+	go func() {
+		backoff := initialBackoff
+		for {
+			wait := self.interval
+			if !self.check(name, ds) {
+				wait = backoff
+				backoff *= 2
+				if backoff > self.maxBackoff {
+					backoff = self.maxBackoff
+				}
+			} else {
+				backoff = initialBackoff
+			}
+
+			select {
+			case <-self.stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+}
+
+// Stop stops checking the data sources.
+func (self *Checker) Stop() {
+	close(self.stop)
+}
+
+// DataSources returns the outcome of the last check of every data source and the current state of its pool.
+func (self *Checker) DataSources() map[string]types.DataSourceHealth {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	statuses := map[string]types.DataSourceHealth{}
+	for name, status := range self.statuses {
+		stats := (*self.dataSources[name]).Stats()
+		status.OpenConnections = stats.OpenConnections
+		status.InUseConnections = stats.InUse
+		status.IdleConnections = stats.Idle
+		status.WaitCount = stats.WaitCount
+
+		statuses[name] = status
+	}
+
+	return statuses
+}
+
+// IsUp reports whether every data source was up when last checked.
+func (self *Checker) IsUp() bool {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	for _, status := range self.statuses {
+		if status.Status != StatusUp {
+			return false
+		}
+	}
+
+	return true
+}
+
+// check pings the data source, records the outcome and logs the changes of status. It returns whether the data
+// source is up.
+func (self *Checker) check(name string, ds *datasource.DataSource) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), self.timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	errOpt := (*ds).PingContext(ctx)
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	previous := self.statuses[name]
+	status := types.DataSourceHealth{
+		Status:    StatusUp,
+		CheckedAt: utils.GetTimestamp(),
+		LatencyMs: time.Since(startedAt).Milliseconds(),
+	}
+	if errOpt.IsSome() {
+		status.Status = StatusDown
+		status.Error = errOpt.Unwrap().Error()
+		status.ConsecutiveFailures = previous.ConsecutiveFailures + 1
+	}
+	self.statuses[name] = status
+
+	switch {
+	case status.Status == StatusDown && previous.Status != StatusDown:
+		log.Printf("the %s database is down: %s", name, status.Error)
+	case status.Status == StatusUp && previous.Status == StatusDown:
+		log.Printf("the %s database is up again after %d failed check(s)", name, previous.ConsecutiveFailures)
+	}
+
+	return status.Status == StatusUp
+}
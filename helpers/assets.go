@@ -0,0 +1,20 @@
+package helpers
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+	"strings"
+)
+
+// asset writes the asset:// reference of an asset of the report, or a global one. The reference is replaced with the
+// data of the asset once the template is rendered.
+//
+//	<img src="{{asset "logo.png"}}"> <link rel="stylesheet" href="{{asset "styles/invoice.css"}}">
+func asset(name string) raymond.SafeString {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \"'<>") {
+		panic(fmt.Errorf("asset: invalid asset name %q", name))
+	}
+
+	return raymond.SafeString("asset://" + name)
+}
@@ -0,0 +1,247 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/aymerick/raymond"
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/qr"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+)
+
+const (
+	// qrQuietZone and linearQuietZone are the blank modules required around the codes by their specifications.
+	qrQuietZone     = 4
+	linearQuietZone = 10
+	// barcodeTextHeight is the height of the human-readable text under a barcode, in pixels.
+	barcodeTextHeight = 16
+)
+
+var qrLevels = map[string]qr.ErrorCorrectionLevel{
+	"L": qr.L,
+	"M": qr.M,
+	"Q": qr.Q,
+	"H": qr.H,
+}
+
+// qrcode draws a value as a QR code, as an inline SVG or, with format="png", as a PNG data URI for an <img> src.
+//
+//	{{qrcode payload}} {{qrcode payload size=200 level="H"}} <img src="{{qrcode payload format="png"}}">
+//
+// level is the error correction level: L, M (default), Q or H. size is the width and height in pixels, 150 by default.
+func qrcode(value any, options *raymond.Options) raymond.SafeString {
+	content := toString(value)
+	if content == "" {
+		return ""
+	}
+
+	level := strings.ToUpper(options.HashStr("level"))
+	if level == "" {
+		level = "M"
+	}
+	errorCorrectionLevel, ok := qrLevels[level]
+	if !ok {
+		panic(fmt.Errorf("qrcode: unknown error correction level %s, expected L, M, Q or H", level))
+	}
+	size := toInt("qrcode", options.HashProp("size"), 150)
+
+	code, err := qr.Encode(content, errorCorrectionLevel, qr.Auto)
+	if err != nil {
+		panic(fmt.Errorf("qrcode: %v", err))
+	}
+
+	modules := code.Bounds().Dx() + 2*qrQuietZone
+	isDark := func(x int, y int) bool {
+		x, y = x-qrQuietZone, y-qrQuietZone
+		if x < 0 || y < 0 || x >= code.Bounds().Dx() || y >= code.Bounds().Dy() {
+			return false
+		}
+		return isDarkModule(code, x, y)
+	}
+
+	switch options.HashStr("format") {
+	case "", "svg":
+		var svg strings.Builder
+		fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, modules, modules)
+		fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, modules, modules)
+		for y := 0; y < modules; y++ {
+			writeDarkRuns(modules, func(x int) bool { return isDark(x, y) }, func(start int, length int) {
+				fmt.Fprintf(&svg, "M%d %dh%dv1h-%dz", start, y, length, length)
+			})
+		}
+		svg.WriteString(`"/></svg>`)
+
+		return raymond.SafeString(svg.String())
+	case "png":
+		img := image.NewGray(image.Rect(0, 0, size, size))
+		for py := 0; py < size; py++ {
+			for px := 0; px < size; px++ {
+				pixel := color.Gray{Y: 255}
+				if isDark(px*modules/size, py*modules/size) {
+					pixel = color.Gray{Y: 0}
+				}
+				img.SetGray(px, py, pixel)
+			}
+		}
+
+		return pngDataUri("qrcode", img)
+	}
+
+	panic(fmt.Errorf("qrcode: unknown format %s, expected svg or png", options.HashStr("format")))
+}
+
+// barcodeOf draws a value as a Code 128 or EAN-13 barcode, as an inline SVG or, with format="png", as a PNG data URI.
+//
+//	{{barcode tracking_number}} {{barcode gtin type="ean13" width=250 height=90 text=false format="png"}}
+//
+// type is code128 (default) or ean13. An EAN-13 value has 12 digits, or 13 with its check digit. width and height are
+// in pixels, 300 and 80 by default. The value is printed under the bars unless text is false.
+func barcodeOf(value any, options *raymond.Options) raymond.SafeString {
+	content := toString(value)
+	if content == "" {
+		return ""
+	}
+
+	var code barcode.Barcode
+	var err error
+	switch codeType := options.HashStr("type"); codeType {
+	case "", "code128":
+		code, err = code128.Encode(content)
+	case "ean13":
+		checkEan13(content)
+		code, err = ean.Encode(content)
+	default:
+		panic(fmt.Errorf("barcode: unknown type %s, expected code128 or ean13", codeType))
+	}
+	if err != nil {
+		panic(fmt.Errorf("barcode: %v", err))
+	}
+
+	width := toInt("barcode", options.HashProp("width"), 300)
+	height := toInt("barcode", options.HashProp("height"), 80)
+	text := ""
+	if options.HashProp("text") == nil || options.HashProp("text") == true {
+		text = code.Content()
+	}
+	barsHeight := height
+	if text != "" {
+		barsHeight -= barcodeTextHeight
+	}
+
+	modules := code.Bounds().Dx() + 2*linearQuietZone
+	isDark := func(x int) bool {
+		x -= linearQuietZone
+		return x >= 0 && x < code.Bounds().Dx() && isDarkModule(code, x, 0)
+	}
+
+	switch options.HashStr("format") {
+	case "", "svg":
+		moduleWidth := float64(width) / float64(modules)
+
+		var svg strings.Builder
+		fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+		fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+		writeDarkRuns(modules, isDark, func(start int, length int) {
+			fmt.Fprintf(&svg, `<rect x="%.2f" y="0" width="%.2f" height="%d" fill="#000"/>`, float64(start)*moduleWidth, float64(length)*moduleWidth, barsHeight)
+		})
+		if text != "" {
+			fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-family="monospace" font-size="14">%s</text>`, width/2, height-3, html.EscapeString(text))
+		}
+		svg.WriteString(`</svg>`)
+
+		return raymond.SafeString(svg.String())
+	case "png":
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		for px := 0; px < width; px++ {
+			if isDark(px * modules / width) {
+				draw.Draw(img, image.Rect(px, 0, px+1, barsHeight), image.Black, image.Point{}, draw.Src)
+			}
+		}
+		if text != "" {
+			drawer := font.Drawer{Dst: img, Src: image.Black, Face: basicfont.Face7x13}
+			textWidth := drawer.MeasureString(text).Ceil()
+			drawer.Dot = fixed.P((width-textWidth)/2, height-3)
+			drawer.DrawString(text)
+		}
+
+		return pngDataUri("barcode", img)
+	}
+
+	panic(fmt.Errorf("barcode: unknown format %s, expected svg or png", options.HashStr("format")))
+}
+
+// checkEan13 fails the render if the value isn't 12 digits, or 13 digits ending with their check digit.
+func checkEan13(content string) {
+	if len(content) != 12 && len(content) != 13 {
+		panic(fmt.Errorf("barcode: an EAN-13 value has 12 or 13 digits, got %s", content))
+	}
+	for _, digit := range content {
+		if digit < '0' || digit > '9' {
+			panic(fmt.Errorf("barcode: an EAN-13 value only has digits, got %s", content))
+		}
+	}
+
+	if len(content) == 13 {
+		// The digits are weighted 1 and 3 alternately, the check digit rounds their sum up to a multiple of 10.
+		sum := 0
+		for i, digit := range content[:12] {
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += int(digit-'0') * weight
+		}
+		checkDigit := (10 - sum%10) % 10
+
+		if int(content[12]-'0') != checkDigit {
+			panic(fmt.Errorf("barcode: the check digit of the EAN-13 value %s is %d, expected %d", content, content[12]-'0', checkDigit))
+		}
+	}
+}
+
+// isDarkModule reports whether the module of the code is dark.
+func isDarkModule(code barcode.Barcode, x int, y int) bool {
+	bounds := code.Bounds()
+	gray := color.GrayModel.Convert(code.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+
+	return gray.Y < 128
+}
+
+// writeDarkRuns calls write for every run of consecutive dark modules of a line, to draw them as a single shape.
+func writeDarkRuns(modules int, isDark func(x int) bool, write func(start int, length int)) {
+	for x := 0; x < modules; {
+		if !isDark(x) {
+			x++
+			continue
+		}
+
+		start := x
+		for x < modules && isDark(x) {
+			x++
+		}
+		write(start, x-start)
+	}
+}
+
+// pngDataUri encodes the image as a PNG data URI.
+func pngDataUri(helperName string, img image.Image) raymond.SafeString {
+	buffer := &bytes.Buffer{}
+	err := png.Encode(buffer, img)
+	if err != nil {
+		panic(fmt.Errorf("%s: %v", helperName, err))
+	}
+
+	return raymond.SafeString("data:image/png;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()))
+}
@@ -0,0 +1,193 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+	gozxingqr "github.com/makiuchi-d/gozxing/qrcode"
+	"image"
+	"image/draw"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// svgScale is the number of pixels per SVG unit the SVG codes are rasterized with.
+const svgScale = 4
+
+var (
+	svgViewBoxRegex = regexp.MustCompile(`viewBox="0 0 (\d+) (\d+)"`)
+	// qrSvgRunRegex matches a run of dark modules of a line of a QR code: M<x> <y>h<length>.
+	qrSvgRunRegex = regexp.MustCompile(`M(\d+) (\d+)h(\d+)`)
+	// barSvgRegex matches a bar of a barcode.
+	barSvgRegex = regexp.MustCompile(`<rect x="([\d.]+)" y="0" width="([\d.]+)" height="(\d+)" fill="#000"/>`)
+)
+
+// decoder reads the payload of a code from an image.
+type decoder func(bitmap *gozxing.BinaryBitmap) (*gozxing.Result, error)
+
+func decodeQr(bitmap *gozxing.BinaryBitmap) (*gozxing.Result, error) {
+	return gozxingqr.NewQRCodeReader().Decode(bitmap, nil)
+}
+
+func decodeCode128(bitmap *gozxing.BinaryBitmap) (*gozxing.Result, error) {
+	return oned.NewCode128Reader().Decode(bitmap, nil)
+}
+
+func decodeEan13(bitmap *gozxing.BinaryBitmap) (*gozxing.Result, error) {
+	return oned.NewEAN13Reader().Decode(bitmap, nil)
+}
+
+func TestCodesRoundTrip(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		value    any
+		// rasterize turns the output of the helper into an image.
+		rasterize func(t *testing.T, output string) image.Image
+		decode    decoder
+		want      string
+	}{
+		{name: "qrcode svg", template: `{{qrcode value}}`, value: "https://example.com/invoices/42?lang=fr", rasterize: rasterizeQrSvg, decode: decodeQr, want: "https://example.com/invoices/42?lang=fr"},
+		{name: "qrcode png", template: `{{qrcode value format="png" size=300}}`, value: "INV-2024-0001", rasterize: decodePngDataUri, decode: decodeQr, want: "INV-2024-0001"},
+		{name: "qrcode level H", template: `{{qrcode value format="png" size=400 level="h"}}`, value: "Payé 12,50 €", rasterize: decodePngDataUri, decode: decodeQr, want: "Payé 12,50 €"},
+		{name: "code128 svg", template: `{{barcode value}}`, value: "GR-2024-000123", rasterize: rasterizeBarcodeSvg, decode: decodeCode128, want: "GR-2024-000123"},
+		{name: "code128 png", template: `{{barcode value format="png" width=600}}`, value: "GR-2024-000123", rasterize: decodePngDataUri, decode: decodeCode128, want: "GR-2024-000123"},
+		{name: "code128 number", template: `{{barcode value format="png" width=600 text=false}}`, value: 1234567890, rasterize: decodePngDataUri, decode: decodeCode128, want: "1234567890"},
+		{name: "ean13 svg", template: `{{barcode value type="ean13"}}`, value: "5901234123457", rasterize: rasterizeBarcodeSvg, decode: decodeEan13, want: "5901234123457"},
+		{name: "ean13 png", template: `{{barcode value type="ean13" format="png" width=400}}`, value: "5901234123457", rasterize: decodePngDataUri, decode: decodeEan13, want: "5901234123457"},
+		{name: "ean13 check digit added", template: `{{barcode value type="ean13" format="png" width=400}}`, value: "400638133393", rasterize: decodePngDataUri, decode: decodeEan13, want: "4006381333931"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			output, err := renderWithHelpers(testCase.template, map[string]any{"value": testCase.value}, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			bitmap, err := gozxing.NewBinaryBitmapFromImage(testCase.rasterize(t, output))
+			if err != nil {
+				t.Fatalf("error while reading the image: %v", err)
+			}
+			result, err := testCase.decode(bitmap)
+			if err != nil {
+				t.Fatalf("error while decoding the code: %v", err)
+			}
+			if result.GetText() != testCase.want {
+				t.Errorf("decoded %q, want %q", result.GetText(), testCase.want)
+			}
+		})
+	}
+}
+
+func TestCodesErrors(t *testing.T) {
+	cases := []helperCase{
+		{name: "ean13 wrong check digit", template: `{{barcode value type="ean13"}}`, context: map[string]any{"value": "5901234123458"}, wantErr: "barcode: the check digit of the EAN-13 value 5901234123458 is 8, expected 7"},
+		{name: "ean13 too short", template: `{{barcode value type="ean13"}}`, context: map[string]any{"value": "59012341234"}, wantErr: "barcode: an EAN-13 value has 12 or 13 digits, got 59012341234"},
+		{name: "ean13 too long", template: `{{barcode value type="ean13"}}`, context: map[string]any{"value": "59012341234570"}, wantErr: "barcode: an EAN-13 value has 12 or 13 digits"},
+		{name: "ean13 not digits", template: `{{barcode value type="ean13"}}`, context: map[string]any{"value": "59012341234A"}, wantErr: "barcode: an EAN-13 value only has digits, got 59012341234A"},
+		{name: "unknown barcode type", template: `{{barcode value type="upc"}}`, context: map[string]any{"value": "123"}, wantErr: "barcode: unknown type upc, expected code128 or ean13"},
+		{name: "unknown barcode format", template: `{{barcode value format="gif"}}`, context: map[string]any{"value": "123"}, wantErr: "barcode: unknown format gif, expected svg or png"},
+		{name: "code128 not ascii", template: `{{barcode value}}`, context: map[string]any{"value": "é"}, wantErr: "barcode:"},
+		{name: "unknown qrcode level", template: `{{qrcode value level="X"}}`, context: map[string]any{"value": "123"}, wantErr: "qrcode: unknown error correction level X, expected L, M, Q or H"},
+		{name: "unknown qrcode format", template: `{{qrcode value format="gif"}}`, context: map[string]any{"value": "123"}, wantErr: "qrcode: unknown format gif, expected svg or png"},
+		{name: "empty qrcode", template: `[{{qrcode value}}]`, context: map[string]any{"value": ""}, want: "[]"},
+		{name: "empty barcode", template: `[{{barcode value}}]`, context: map[string]any{"value": nil}, want: "[]"},
+	}
+
+	runHelperCases(t, cases)
+}
+
+// decodePngDataUri decodes the PNG of a data URI.
+func decodePngDataUri(t *testing.T, output string) image.Image {
+	t.Helper()
+
+	encoded, found := strings.CutPrefix(output, "data:image/png;base64,")
+	if !found {
+		t.Fatalf("not a PNG data URI: %.40s", output)
+	}
+	content, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("error while decoding the data URI: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("error while decoding the PNG: %v", err)
+	}
+
+	return img
+}
+
+// rasterizeQrSvg draws the dark modules of a QR code SVG, svgScale pixels per module.
+func rasterizeQrSvg(t *testing.T, output string) image.Image {
+	t.Helper()
+
+	width, height := svgViewBox(t, output)
+	img := whiteImage(width*svgScale, height*svgScale)
+	for _, run := range qrSvgRunRegex.FindAllStringSubmatch(output, -1) {
+		x, y, length := atoi(t, run[1]), atoi(t, run[2]), atoi(t, run[3])
+		draw.Draw(img, image.Rect(x*svgScale, y*svgScale, (x+length)*svgScale, (y+1)*svgScale), image.Black, image.Point{}, draw.Src)
+	}
+
+	return img
+}
+
+// rasterizeBarcodeSvg draws the bars of a barcode SVG, svgScale pixels per unit.
+func rasterizeBarcodeSvg(t *testing.T, output string) image.Image {
+	t.Helper()
+
+	width, height := svgViewBox(t, output)
+	img := whiteImage(width*svgScale, height*svgScale)
+	for _, bar := range barSvgRegex.FindAllStringSubmatch(output, -1) {
+		x, barWidth, barHeight := atof(t, bar[1]), atof(t, bar[2]), atoi(t, bar[3])
+		left := int(x*svgScale + 0.5)
+		right := int((x+barWidth)*svgScale + 0.5)
+		draw.Draw(img, image.Rect(left, 0, right, barHeight*svgScale), image.Black, image.Point{}, draw.Src)
+	}
+
+	return img
+}
+
+func svgViewBox(t *testing.T, output string) (int, int) {
+	t.Helper()
+
+	match := svgViewBoxRegex.FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("not an SVG: %.40s", output)
+	}
+
+	return atoi(t, match[1]), atoi(t, match[2])
+}
+
+func whiteImage(width int, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	return img
+}
+
+func atoi(t *testing.T, text string) int {
+	t.Helper()
+
+	number, err := strconv.Atoi(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return number
+}
+
+func atof(t *testing.T, text string) float64 {
+	t.Helper()
+
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return number
+}
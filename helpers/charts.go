@@ -0,0 +1,326 @@
+package helpers
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+	"html"
+	"math"
+	"strings"
+)
+
+// chartPalette colors the series of a chart, or the slices of a pie, in order.
+var chartPalette = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+const (
+	chartFontSize     = 12
+	chartTitleHeight  = 24
+	chartLegendHeight = 22
+	chartAxisWidth    = 56
+)
+
+// chartSeries is a column of the rows drawn on a chart.
+type chartSeries struct {
+	name   string
+	color  string
+	values []float64
+}
+
+// chart draws the rows of a query as an inline SVG bar, line or pie chart.
+//
+//	{{chart [Q[SELECT month, revenue, cost FROM sales]] type="bar" label="month" values="revenue,cost"}}
+//
+// Hash arguments:
+//   - type: bar (default), line or pie. A pie only draws the first values column.
+//   - label: the column of the categories, or of the slices of a pie.
+//   - values: the comma separated columns to draw, one series per column. Null values are drawn as 0.
+//   - names: the comma separated names of the series in the legend. Defaults to the column names.
+//   - colors: the comma separated colors of the series, or of the slices of a pie.
+//   - title, width (600) and height (300).
+func chart(rows any, options *raymond.Options) raymond.SafeString {
+	chartType := options.HashStr("type")
+	if chartType == "" {
+		chartType = "bar"
+	}
+	labelColumn := options.HashStr("label")
+	valueColumns := splitList(options.HashStr("values"))
+	if labelColumn == "" || len(valueColumns) == 0 {
+		panic(fmt.Errorf("chart: the label and values arguments are required"))
+	}
+
+	width := toInt("chart", options.HashProp("width"), 600)
+	height := toInt("chart", options.HashProp("height"), 300)
+	title := options.HashStr("title")
+	names := splitList(options.HashStr("names"))
+	colors := splitList(options.HashStr("colors"))
+	if len(colors) == 0 {
+		colors = chartPalette
+	}
+	format := findLocaleFormat(localeOf(options))
+
+	// Read the rows.
+	labels := []string{}
+	series := make([]chartSeries, len(valueColumns))
+	for i, column := range valueColumns {
+		series[i] = chartSeries{name: column, color: colors[i%len(colors)]}
+		if i < len(names) {
+			series[i].name = names[i]
+		}
+	}
+	for _, item := range chartRows(rows) {
+		labels = append(labels, toString(item[labelColumn]))
+		for i, column := range valueColumns {
+			value := 0.0
+			if item[column] != nil {
+				value = mustFloat("chart", item[column])
+			}
+			series[i].values = append(series[i].values, value)
+		}
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%d">`, width, height, width, height, chartFontSize)
+
+	top := 8
+	if title != "" {
+		fmt.Fprintf(&svg, `<text x="%d" y="18" text-anchor="middle" font-size="%d" font-weight="bold">%s</text>`, width/2, chartFontSize+2, html.EscapeString(title))
+		top += chartTitleHeight
+	}
+
+	switch chartType {
+	case "bar", "line":
+		legendShown := len(series) > 1
+		bottom := height - 24
+		if legendShown {
+			bottom -= chartLegendHeight
+			legendItems := make([]string, len(series))
+			legendColors := make([]string, len(series))
+			for i, serie := range series {
+				legendItems[i], legendColors[i] = serie.name, serie.color
+			}
+			writeChartLegend(&svg, legendItems, legendColors, width, height-chartLegendHeight+6)
+		}
+		writeAxisChart(&svg, chartType, labels, series, format, chartAxisWidth, top, width-12, bottom)
+	case "pie":
+		legendItems := make([]string, len(labels))
+		legendColors := make([]string, len(labels))
+		for i := range labels {
+			legendColors[i] = colors[i%len(colors)]
+		}
+		total := 0.0
+		for _, value := range series[0].values {
+			total += math.Abs(value)
+		}
+		for i, label := range labels {
+			share := 0.0
+			if total > 0 {
+				share = math.Abs(series[0].values[i]) / total
+			}
+			legendItems[i] = label + " (" + strings.Replace(format.PercentPattern, "#", formatLocalizedNumber(share*100, 0, format), 1) + ")"
+		}
+
+		writeChartLegend(&svg, legendItems, legendColors, width, height-chartLegendHeight+6)
+		writePieChart(&svg, series[0].values, legendColors, width/2, (top+height-chartLegendHeight)/2, (height-chartLegendHeight-top)/2-4)
+	default:
+		panic(fmt.Errorf("chart: unknown type %s, expected bar, line or pie", chartType))
+	}
+
+	svg.WriteString(`</svg>`)
+
+	return raymond.SafeString(svg.String())
+}
+
+// chartRows returns the rows of a query result.
+func chartRows(rows any) []map[string]any {
+	switch typedRows := rows.(type) {
+	case nil:
+		return nil
+	case []map[string]any:
+		return typedRows
+	case []any:
+		result := make([]map[string]any, 0, len(typedRows))
+		for _, item := range typedRows {
+			row, ok := item.(map[string]any)
+			if !ok {
+				panic(fmt.Errorf("chart: the items of the list don't have columns"))
+			}
+			result = append(result, row)
+		}
+		return result
+	}
+
+	panic(fmt.Errorf("chart: expected the rows of a query, got %v. A query returning a single row is replaced by its value", rows))
+}
+
+// writeAxisChart draws a bar or line chart with its axes in the plot area.
+func writeAxisChart(svg *strings.Builder, chartType string, labels []string, series []chartSeries, format localeFormat, left int, top int, right int, bottom int) {
+	minimum, maximum := 0.0, 0.0
+	for _, serie := range series {
+		for _, value := range serie.values {
+			minimum = math.Min(minimum, value)
+			maximum = math.Max(maximum, value)
+		}
+	}
+	ticks, decimals := chartTicks(minimum, maximum)
+	low, high := ticks[0], ticks[len(ticks)-1]
+	scaleY := func(value float64) float64 {
+		return float64(bottom) - (value-low)/(high-low)*float64(bottom-top)
+	}
+
+	// Grid lines and the labels of the y axis.
+	for _, tick := range ticks {
+		y := scaleY(tick)
+		fmt.Fprintf(svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e0e0e0"/>`, left, y, right, y)
+		fmt.Fprintf(svg, `<text x="%d" y="%.1f" text-anchor="end" dy="4" fill="#555">%s</text>`, left-6, y, html.EscapeString(formatLocalizedNumber(tick, decimals, format)))
+	}
+
+	if len(labels) == 0 {
+		fmt.Fprintf(svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#333"/>`, left, scaleY(0), right, scaleY(0))
+		return
+	}
+
+	band := float64(right-left) / float64(len(labels))
+
+	// The labels of the x axis, rotated when they don't fit their band.
+	longestLabel := 0
+	for _, label := range labels {
+		longestLabel = int(math.Max(float64(longestLabel), float64(len([]rune(label)))))
+	}
+	rotated := float64(longestLabel*chartFontSize)*0.6 > band
+	for i, label := range labels {
+		x := float64(left) + band*(float64(i)+0.5)
+		if rotated {
+			fmt.Fprintf(svg, `<text x="%.1f" y="%d" text-anchor="end" transform="rotate(-30 %.1f %d)" fill="#555">%s</text>`, x, bottom+14, x, bottom+14, html.EscapeString(label))
+		} else {
+			fmt.Fprintf(svg, `<text x="%.1f" y="%d" text-anchor="middle" fill="#555">%s</text>`, x, bottom+16, html.EscapeString(label))
+		}
+	}
+
+	switch chartType {
+	case "bar":
+		barWidth := band * 0.8 / float64(len(series))
+		for j, serie := range series {
+			for i, value := range serie.values {
+				x := float64(left) + band*float64(i) + band*0.1 + barWidth*float64(j)
+				y := math.Min(scaleY(value), scaleY(0))
+				barHeight := math.Abs(scaleY(value) - scaleY(0))
+				fmt.Fprintf(svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, x, y, barWidth, barHeight, html.EscapeString(serie.color))
+			}
+		}
+	case "line":
+		for _, serie := range series {
+			points := make([]string, len(serie.values))
+			for i, value := range serie.values {
+				points[i] = fmt.Sprintf("%.1f,%.1f", float64(left)+band*(float64(i)+0.5), scaleY(value))
+			}
+			fmt.Fprintf(svg, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), html.EscapeString(serie.color))
+			for _, point := range points {
+				x, y, _ := strings.Cut(point, ",")
+				fmt.Fprintf(svg, `<circle cx="%s" cy="%s" r="3" fill="%s"/>`, x, y, html.EscapeString(serie.color))
+			}
+		}
+	}
+
+	// The axes.
+	fmt.Fprintf(svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`, left, top, left, bottom)
+	fmt.Fprintf(svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#333"/>`, left, scaleY(0), right, scaleY(0))
+}
+
+// writePieChart draws the slices of a pie chart around the center.
+func writePieChart(svg *strings.Builder, values []float64, colors []string, centerX int, centerY int, radius int) {
+	total := 0.0
+	for _, value := range values {
+		total += math.Abs(value)
+	}
+	if total == 0 || radius <= 0 {
+		return
+	}
+
+	angle := -math.Pi / 2
+	for i, value := range values {
+		share := math.Abs(value) / total
+		if share == 0 {
+			continue
+		}
+		color := html.EscapeString(colors[i])
+
+		// An arc can't be a full circle.
+		if share >= 1 {
+			fmt.Fprintf(svg, `<circle cx="%d" cy="%d" r="%d" fill="%s"/>`, centerX, centerY, radius, color)
+			return
+		}
+
+		endAngle := angle + share*2*math.Pi
+		largeArc := 0
+		if share > 0.5 {
+			largeArc = 1
+		}
+		fmt.Fprintf(svg, `<path d="M %d %d L %.2f %.2f A %d %d 0 %d 1 %.2f %.2f Z" fill="%s" stroke="#fff"/>`,
+			centerX, centerY,
+			float64(centerX)+float64(radius)*math.Cos(angle), float64(centerY)+float64(radius)*math.Sin(angle),
+			radius, radius, largeArc,
+			float64(centerX)+float64(radius)*math.Cos(endAngle), float64(centerY)+float64(radius)*math.Sin(endAngle),
+			color,
+		)
+		angle = endAngle
+	}
+}
+
+// writeChartLegend draws the legend of a chart on a centered line.
+func writeChartLegend(svg *strings.Builder, items []string, colors []string, width int, y int) {
+	// Text widths are estimated, SVG can't measure them without a browser.
+	itemWidths := make([]float64, len(items))
+	totalWidth := 0.0
+	for i, item := range items {
+		itemWidths[i] = 18 + float64(len([]rune(item))*chartFontSize)*0.6 + 14
+		totalWidth += itemWidths[i]
+	}
+
+	x := math.Max(4, (float64(width)-totalWidth)/2)
+	for i, item := range items {
+		fmt.Fprintf(svg, `<rect x="%.1f" y="%d" width="12" height="12" fill="%s"/>`, x, y-10, html.EscapeString(colors[i]))
+		fmt.Fprintf(svg, `<text x="%.1f" y="%d">%s</text>`, x+18, y, html.EscapeString(item))
+		x += itemWidths[i]
+	}
+}
+
+// chartTicks returns about 5 round values covering the range, and the decimals needed to print them.
+func chartTicks(minimum float64, maximum float64) ([]float64, int) {
+	if minimum == maximum {
+		maximum = minimum + 1
+	}
+
+	rawStep := (maximum - minimum) / 5
+	magnitude := math.Pow(10, math.Floor(math.Log10(rawStep)))
+	step := magnitude * 10
+	for _, multiple := range []float64{1, 2, 5} {
+		if rawStep <= multiple*magnitude {
+			step = multiple * magnitude
+			break
+		}
+	}
+
+	start := math.Floor(minimum/step) * step
+	ticks := []float64{start}
+	for ticks[len(ticks)-1] < maximum-step*1e-9 {
+		ticks = append(ticks, start+float64(len(ticks))*step)
+	}
+
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+
+	return ticks, decimals
+}
+
+// splitList splits a comma separated hash argument.
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
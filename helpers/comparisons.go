@@ -0,0 +1,54 @@
+package helpers
+
+// The comparison helpers return booleans to be used in subexpressions: {{#if (gt balance 0)}}.
+// Two values that can both be read as numbers are compared as numbers, so "10" equals 10. Other values are compared
+// as the strings they print as.
+
+func eq(a any, b any) bool {
+	return compare(a, b) == 0
+}
+
+func ne(a any, b any) bool {
+	return compare(a, b) != 0
+}
+
+func gt(a any, b any) bool {
+	return compare(a, b) > 0
+}
+
+func gte(a any, b any) bool {
+	return compare(a, b) >= 0
+}
+
+func lt(a any, b any) bool {
+	return compare(a, b) < 0
+}
+
+func lte(a any, b any) bool {
+	return compare(a, b) <= 0
+}
+
+// compare returns -1, 0 or 1 like strings.Compare.
+func compare(a any, b any) int {
+	numberA, okA := toFloat(a)
+	numberB, okB := toFloat(b)
+
+	if okA && okB {
+		switch {
+		case numberA < numberB:
+			return -1
+		case numberA > numberB:
+			return 1
+		}
+		return 0
+	}
+
+	stringA, stringB := toString(a), toString(b)
+	switch {
+	case stringA < stringB:
+		return -1
+	case stringA > stringB:
+		return 1
+	}
+	return 0
+}
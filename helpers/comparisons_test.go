@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComparisons(t *testing.T) {
+	cases := []struct {
+		name string
+		a    any
+		b    any
+		// want holds the results of eq, ne, gt, gte, lt and lte.
+		want string
+	}{
+		{name: "equal numbers", a: 10, b: 10.0, want: "true false false true false true"},
+		{name: "smaller number", a: 2, b: 10, want: "false true false false true true"},
+		{name: "greater number", a: 10, b: 2, want: "false true true true false false"},
+		{name: "numeric string", a: "10", b: 10, want: "true false false true false true"},
+		{name: "decimal string", a: "9.5", b: 10, want: "false true false false true true"},
+		{name: "numbers as numbers", a: "10", b: "9", want: "false true true true false false"},
+		{name: "strings", a: "apple", b: "banana", want: "false true false false true true"},
+		{name: "equal strings", a: "paid", b: "paid", want: "true false false true false true"},
+		{name: "string and number", a: "abc", b: 1, want: "false true true true false false"},
+		{name: "null and empty", a: nil, b: "", want: "true false false true false true"},
+		{name: "booleans", a: true, b: true, want: "true false false true false true"},
+		{name: "dates", a: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), b: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), want: "false true false false true true"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := renderWithHelpers(`{{eq a b}} {{ne a b}} {{gt a b}} {{gte a b}} {{lt a b}} {{lte a b}}`, map[string]any{"a": testCase.a, "b": testCase.b}, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != testCase.want {
+				t.Errorf("got %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestComparisonsInConditions(t *testing.T) {
+	cases := []helperCase{
+		{name: "if", template: `{{#if (gt balance 0)}}credit{{else}}debit{{/if}}`, context: map[string]any{"balance": "12.50"}, want: "credit"},
+		{name: "else", template: `{{#if (gt balance 0)}}credit{{else}}debit{{/if}}`, context: map[string]any{"balance": -3}, want: "debit"},
+		{name: "unless", template: `{{#unless (eq status "paid")}}due{{/unless}}`, context: map[string]any{"status": "open"}, want: "due"},
+	}
+
+	runHelperCases(t, cases)
+}
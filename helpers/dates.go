@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+	"math"
+	"strings"
+	"time"
+	// Embed the timezone database, the server may run in an image without one.
+	_ "time/tzdata"
+)
+
+// DefaultDateLayout is used by formatDate when no layout is given.
+const DefaultDateLayout = "2006-01-02"
+
+// dateLayouts are the layouts tried, in order, to parse the dates returned as strings by the database drivers.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// formatDate writes a date with a Go layout, in the given timezone and with the month and day names of the locale. Dates without a timezone are taken as UTC.
+// Numbers are Unix timestamps in seconds, or milliseconds when they are too large to be seconds.
+//
+//	{{formatDate created_at "02/01/2006 15:04"}} {{formatDate created_at "Monday 2 January 2006" timezone="Asia/Amman" locale="fr"}}
+func formatDate(value any, layout string, options *raymond.Options) string {
+	if value == nil || value == "" {
+		return ""
+	}
+	if layout == "" {
+		layout = DefaultDateLayout
+	}
+
+	date := parseDate(value)
+
+	if timezone := options.HashStr("timezone"); timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			panic(fmt.Errorf("formatDate: unknown timezone %s", timezone))
+		}
+		date = date.In(location)
+	}
+
+	return formatLocalizedDate(date, layout, findLocaleFormat(localeOf(options)))
+}
+
+// formatLocalizedDate formats the date like time.Format, with the month and day names and the digits of the locale.
+func formatLocalizedDate(date time.Time, layout string, format localeFormat) string {
+	if format.Months == nil {
+		return localizeDigits(date.Format(layout), format)
+	}
+
+	// The longest names come first so that January isn't read as Jan.
+	names := []struct {
+		token string
+		value string
+	}{
+		{"January", format.Months[date.Month()-1]},
+		{"Monday", format.Days[date.Weekday()]},
+		{"Jan", format.ShortMonths[date.Month()-1]},
+		{"Mon", format.ShortDays[date.Weekday()]},
+	}
+
+	var builder strings.Builder
+	segmentStart := 0
+	for i := 0; i < len(layout); {
+		matched := false
+		for _, name := range names {
+			if strings.HasPrefix(layout[i:], name.token) {
+				builder.WriteString(date.Format(layout[segmentStart:i]))
+				builder.WriteString(name.value)
+				i += len(name.token)
+				segmentStart = i
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	builder.WriteString(date.Format(layout[segmentStart:]))
+
+	return localizeDigits(builder.String(), format)
+}
+
+// parseDate converts a template value to a time or fails the render.
+func parseDate(value any) time.Time {
+	if date, ok := value.(time.Time); ok {
+		return date
+	}
+
+	if timestamp, ok := toFloat(value); ok {
+		// 1e11 seconds is in the year 5138, larger timestamps are in milliseconds.
+		if math.Abs(timestamp) >= 1e11 {
+			return time.UnixMilli(int64(timestamp)).UTC()
+		}
+		return time.Unix(int64(timestamp), 0).UTC()
+	}
+
+	text := strings.TrimSpace(toString(value))
+	for _, layout := range dateLayouts {
+		date, err := time.Parse(layout, text)
+		if err == nil {
+			return date
+		}
+	}
+
+	panic(fmt.Errorf("formatDate: can't parse %q as a date", text))
+}
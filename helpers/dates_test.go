@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDate(t *testing.T) {
+	// A Wednesday.
+	date := time.Date(2024, time.January, 31, 22, 30, 0, 0, time.UTC)
+
+	cases := []helperCase{
+		{name: "default layout", template: `{{formatDate value ""}}`, context: map[string]any{"value": date}, want: "2024-01-31"},
+		{name: "layout", template: `{{formatDate value "02/01/2006 15:04"}}`, context: map[string]any{"value": date}, want: "31/01/2024 22:30"},
+		{name: "string", template: `{{formatDate value "Jan 2, 2006"}}`, context: map[string]any{"value": "2024-01-31"}, want: "Jan 31, 2024"},
+		{name: "string with offset", template: `{{formatDate value "15:04 -07:00"}}`, context: map[string]any{"value": "2024-01-31T22:30:00+02:00"}, want: "22:30 +02:00"},
+		{name: "string without timezone", template: `{{formatDate value "15:04 MST"}}`, context: map[string]any{"value": "2024-01-31 22:30:00"}, want: "22:30 UTC"},
+		{name: "unix seconds", template: `{{formatDate value "2006-01-02 15:04"}}`, context: map[string]any{"value": int64(1706740200)}, want: "2024-01-31 22:30"},
+		{name: "unix milliseconds", template: `{{formatDate value "2006-01-02 15:04"}}`, context: map[string]any{"value": int64(1706740200000)}, want: "2024-01-31 22:30"},
+		{name: "null", template: `{{formatDate value ""}}`, context: map[string]any{"value": nil}, want: ""},
+		{name: "empty", template: `{{formatDate value ""}}`, context: map[string]any{"value": ""}, want: ""},
+		{name: "timezone", template: `{{formatDate value "2006-01-02 15:04 MST" timezone="America/New_York"}}`, context: map[string]any{"value": date}, want: "2024-01-31 17:30 EST"},
+		{name: "timezone changes the day", template: `{{formatDate value "2006-01-02" timezone="Asia/Tokyo"}}`, context: map[string]any{"value": date}, want: "2024-02-01"},
+		{name: "timezone of a string", template: `{{formatDate value "15:04" timezone="UTC"}}`, context: map[string]any{"value": "2024-01-31T22:30:00+02:00"}, want: "20:30"},
+		{name: "fr", template: `{{formatDate value "Monday 2 January 2006" locale="fr"}}`, context: map[string]any{"value": date}, want: "mercredi 31 janvier 2024"},
+		{name: "fr short names", template: `{{formatDate value "Mon 2 Jan"}}`, context: map[string]any{"value": date}, locale: "fr", want: "mer. 31 janv."},
+		{name: "de", template: `{{formatDate value "Monday, 2. January 2006" locale="de"}}`, context: map[string]any{"value": date}, want: "Mittwoch, 31. Januar 2024"},
+		{name: "ar digits", template: `{{formatDate value "2 January 2006" locale="ar"}}`, context: map[string]any{"value": date}, want: "٣١ يناير ٢٠٢٤"},
+		{name: "locale and timezone", template: `{{formatDate value "Monday 2 January" timezone="Asia/Tokyo" locale="fr"}}`, context: map[string]any{"value": date}, want: "jeudi 1 février"},
+		{name: "unknown locale", template: `{{formatDate value "Monday" locale="xx"}}`, context: map[string]any{"value": date}, want: "Wednesday"},
+		{name: "unknown timezone", template: `{{formatDate value "" timezone="Mars/Olympus"}}`, context: map[string]any{"value": date}, wantErr: "formatDate: unknown timezone Mars/Olympus"},
+		{name: "not a date", template: `{{formatDate value ""}}`, context: map[string]any{"value": "yesterday"}, wantErr: `formatDate: can't parse "yesterday" as a date`},
+	}
+
+	runHelperCases(t, cases)
+}
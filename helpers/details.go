@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+	"strings"
+)
+
+// detail runs a query for the enclosing row and renders the block once per row of the result, or the {{else}} block if
+// there's none. [R[field]] is replaced with a field of the enclosing row. The queries of the blocks rendered for many
+// rows are batched: `column = [R[field]]` becomes `column IN (...)`, run once for all the rows, as long as the query
+// selects the column.
+//
+//	{{#each [Q[SELECT id, number FROM orders]]}}
+//	  {{#detail "SELECT order_id, product, quantity FROM order_lines WHERE order_id = [R[id]]"}}{{product}}{{/detail}}
+//	{{/each}}
+func detail(query string, options *raymond.Options) raymond.SafeString {
+	loadDetail, ok := options.Data("loadDetail").(func(query string, enclosingRow any) ([]map[string]any, bool, error))
+	if !ok {
+		panic(fmt.Errorf("detail: detail blocks can only be rendered in the body of a report"))
+	}
+
+	rows, loaded, err := loadDetail(query, options.Ctx())
+	if err != nil {
+		panic(fmt.Errorf("detail: %v", err))
+	}
+	if !loaded {
+		return ""
+	}
+	if len(rows) == 0 {
+		return raymond.SafeString(options.Inverse())
+	}
+
+	var result strings.Builder
+	for i, row := range rows {
+		frame := options.NewDataFrame()
+		frame.Set("index", i)
+		frame.Set("first", i == 0)
+		frame.Set("last", i == len(rows)-1)
+
+		result.WriteString(options.FnCtxData(row, frame))
+	}
+
+	return raymond.SafeString(result.String())
+}
@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+)
+
+// layoutBlock is the content a template gives to a block of the layout it extends.
+type layoutBlock struct {
+	content string
+	// mode is replace (default), append or prepend.
+	mode string
+}
+
+// apply returns the content of the block written over base, the content of the block in a less specific template.
+func (self layoutBlock) apply(base string) string {
+	switch self.mode {
+	case "append":
+		return base + self.content
+	case "prepend":
+		return self.content + base
+	}
+
+	return self.content
+}
+
+// extends renders the layout partial with the blocks given by the {{#content}} blocks it contains. Anything else inside
+// it is ignored. A layout can extend another layout.
+//
+//	{{#extends "base_layout"}}
+//	  {{#content "title"}}Invoice {{number}}{{/content}}
+//	  {{#content "styles" mode="append"}}table { width: 100%; }{{/content}}
+//	{{/extends}}
+func extends(name string, options *raymond.Options) raymond.SafeString {
+	partials, _ := options.Data("partials").(map[string]string)
+	layout, ok := partials[name]
+	if !ok {
+		panic(fmt.Errorf("extends: layout %s was not found", name))
+	}
+
+	// The blocks by name, from the most specific template to the least specific one. When this is a layout extending
+	// another one, the blocks of the templates extending it come first.
+	blocks := map[string][]layoutBlock{}
+	if parentBlocks, ok := options.Data("blocks").(map[string][]layoutBlock); ok {
+		for blockName, layers := range parentBlocks {
+			blocks[blockName] = append([]layoutBlock{}, layers...)
+		}
+	}
+
+	frame := options.NewDataFrame()
+	frame.Set("blocks", blocks)
+	options.FnData(frame)
+
+	template, err := raymond.Parse(layout)
+	if err != nil {
+		panic(fmt.Errorf("extends: layout %s: %v", name, err))
+	}
+	template.RegisterPartials(partials)
+
+	result, err := template.ExecWith(options.Ctx(), frame)
+	if err != nil {
+		panic(fmt.Errorf("extends: layout %s: %v", name, err))
+	}
+
+	return raymond.SafeString(result)
+}
+
+// content gives the content of a block of the layout extended with {{#extends}}. It replaces the default content of the
+// block, or is added after or before it with mode="append" or mode="prepend".
+func content(name string, options *raymond.Options) string {
+	blocks, ok := options.Data("blocks").(map[string][]layoutBlock)
+	if !ok {
+		panic(fmt.Errorf("content: the %s block must be inside {{#extends}}", name))
+	}
+
+	mode := options.HashStr("mode")
+	if mode != "" && mode != "replace" && mode != "append" && mode != "prepend" {
+		panic(fmt.Errorf("content: unknown mode %s, expected replace, append or prepend", mode))
+	}
+
+	blocks[name] = append(blocks[name], layoutBlock{content: options.Fn(), mode: mode})
+
+	return ""
+}
+
+// block marks a place of a layout the templates extending it fill with {{#content}}. Its own content is the default.
+//
+//	<title>{{#block "title"}}Report{{/block}}</title>
+func block(name string, options *raymond.Options) raymond.SafeString {
+	result := options.Fn()
+
+	blocks, _ := options.Data("blocks").(map[string][]layoutBlock)
+	layers := blocks[name]
+	for i := len(layers) - 1; i >= 0; i-- {
+		result = layers[i].apply(result)
+	}
+
+	return raymond.SafeString(result)
+}
@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"github.com/aymerick/raymond"
+	"strings"
+)
+
+// DefaultLocale is used when neither the template nor the render asks for a locale.
+const DefaultLocale = "en"
+
+// rightToLeftLanguages are the languages written from right to left.
+var rightToLeftLanguages = []string{"ar", "fa", "he", "ur"}
+
+// localeFormat describes how numbers and dates are written in a locale.
+type localeFormat struct {
+	Decimal string
+	Group   string
+	// Digits replaces the ASCII digits when set, e.g. with Arabic-Indic digits.
+	Digits []rune
+	// CurrencyPattern and PercentPattern place the formatted number (#) and the symbol (¤ or %). Spaces are no-break
+	// spaces so that amounts aren't split across lines.
+	CurrencyPattern string
+	PercentPattern  string
+	// Months, ShortMonths, Days and ShortDays replace the English names of the date layouts. Days start on Sunday.
+	// The English names are kept when they're nil.
+	Months      []string
+	ShortMonths []string
+	Days        []string
+	ShortDays   []string
+}
+
+var localeFormats = map[string]localeFormat{
+	"en": {Decimal: ".", Group: ",", CurrencyPattern: "¤#", PercentPattern: "#%"},
+	"fr": {
+		Decimal:         ",",
+		Group:           "\u00a0",
+		CurrencyPattern: "#\u00a0¤",
+		PercentPattern:  "#\u00a0%",
+		Months:          []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths:     []string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Days:            []string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortDays:       []string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"de": {
+		Decimal:         ",",
+		Group:           ".",
+		CurrencyPattern: "#\u00a0¤",
+		PercentPattern:  "#\u00a0%",
+		Months:          []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths:     []string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		Days:            []string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortDays:       []string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+	},
+	"es": {
+		Decimal:         ",",
+		Group:           ".",
+		CurrencyPattern: "#\u00a0¤",
+		PercentPattern:  "#\u00a0%",
+		Months:          []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths:     []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		Days:            []string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortDays:       []string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"it": {
+		Decimal:         ",",
+		Group:           ".",
+		CurrencyPattern: "#\u00a0¤",
+		PercentPattern:  "#%",
+		Months:          []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		ShortMonths:     []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		Days:            []string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		ShortDays:       []string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"pt": {
+		Decimal:         ",",
+		Group:           ".",
+		CurrencyPattern: "¤\u00a0#",
+		PercentPattern:  "#%",
+		Months:          []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		ShortMonths:     []string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		Days:            []string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		ShortDays:       []string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+	},
+	"nl": {
+		Decimal:         ",",
+		Group:           ".",
+		CurrencyPattern: "¤\u00a0#",
+		PercentPattern:  "#%",
+		Months:          []string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		ShortMonths:     []string{"jan.", "feb.", "mrt.", "apr.", "mei", "jun.", "jul.", "aug.", "sep.", "okt.", "nov.", "dec."},
+		Days:            []string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		ShortDays:       []string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"ar": {
+		Decimal:         "٫",
+		Group:           "٬",
+		Digits:          []rune("٠١٢٣٤٥٦٧٨٩"),
+		CurrencyPattern: "#\u00a0¤",
+		PercentPattern:  "#٪",
+		Months:          []string{"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+		ShortMonths:     []string{"يناير", "فبراير", "مارس", "أبريل", "مايو", "يونيو", "يوليو", "أغسطس", "سبتمبر", "أكتوبر", "نوفمبر", "ديسمبر"},
+		Days:            []string{"الأحد", "الاثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"},
+		ShortDays:       []string{"أحد", "اثنين", "ثلاثاء", "أربعاء", "خميس", "جمعة", "سبت"},
+	},
+}
+
+// currency describes a currency by its ISO 4217 code.
+type currency struct {
+	Symbol   string
+	Decimals int
+	// LocalSymbols replace the symbol in the given languages.
+	LocalSymbols map[string]string
+}
+
+// symbolIn returns the symbol of the currency in the locale.
+func (self currency) symbolIn(locale string) string {
+	if symbol, ok := self.LocalSymbols[LanguageOf(locale)]; ok {
+		return symbol
+	}
+
+	return self.Symbol
+}
+
+var currencies = map[string]currency{
+	"USD": {Symbol: "$", Decimals: 2},
+	"EUR": {Symbol: "€", Decimals: 2},
+	"GBP": {Symbol: "£", Decimals: 2},
+	"JPY": {Symbol: "¥", Decimals: 0},
+	"CNY": {Symbol: "CN¥", Decimals: 2},
+	"INR": {Symbol: "₹", Decimals: 2},
+	"CHF": {Symbol: "CHF", Decimals: 2},
+	"CAD": {Symbol: "CA$", Decimals: 2},
+	"AUD": {Symbol: "A$", Decimals: 2},
+	"SAR": {Symbol: "SAR", Decimals: 2, LocalSymbols: map[string]string{"ar": "ر.س."}},
+	"AED": {Symbol: "AED", Decimals: 2, LocalSymbols: map[string]string{"ar": "د.إ."}},
+	"EGP": {Symbol: "EGP", Decimals: 2, LocalSymbols: map[string]string{"ar": "ج.م."}},
+	"MAD": {Symbol: "MAD", Decimals: 2, LocalSymbols: map[string]string{"ar": "د.م."}},
+	"JOD": {Symbol: "JOD", Decimals: 3, LocalSymbols: map[string]string{"ar": "د.أ."}},
+	"KWD": {Symbol: "KWD", Decimals: 3, LocalSymbols: map[string]string{"ar": "د.ك."}},
+	"BHD": {Symbol: "BHD", Decimals: 3, LocalSymbols: map[string]string{"ar": "د.ب."}},
+	"OMR": {Symbol: "OMR", Decimals: 3, LocalSymbols: map[string]string{"ar": "ر.ع."}},
+	"TND": {Symbol: "TND", Decimals: 3, LocalSymbols: map[string]string{"ar": "د.ت."}},
+}
+
+// findLocaleFormat returns the format of the locale, falling back from a regional locale (fr-CA) to its language (fr),
+// and then to the default locale.
+func findLocaleFormat(locale string) localeFormat {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+
+	if format, ok := localeFormats[locale]; ok {
+		return format
+	}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		if format, ok := localeFormats[language]; ok {
+			return format
+		}
+	}
+
+	return localeFormats[DefaultLocale]
+}
+
+// findCurrency returns the currency of the ISO code. Unknown codes are printed as is with 2 decimals.
+func findCurrency(code string) currency {
+	code = strings.ToUpper(code)
+
+	if currency, ok := currencies[code]; ok {
+		return currency
+	}
+
+	return currency{Symbol: code, Decimals: 2}
+}
+
+// localeOf returns the locale asked for with the locale hash argument, or else the locale of the render.
+func localeOf(options *raymond.Options) string {
+	if locale := options.HashStr("locale"); locale != "" {
+		return locale
+	}
+	if locale := options.DataStr("locale"); locale != "" {
+		return locale
+	}
+
+	return DefaultLocale
+}
+
+// LanguageOf returns the language of a locale: fr for fr-CA.
+func LanguageOf(locale string) string {
+	language, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+
+	return strings.ToLower(language)
+}
+
+// IsRightToLeft reports whether the language of the locale is written from right to left.
+func IsRightToLeft(locale string) bool {
+	language := LanguageOf(locale)
+	for _, rightToLeftLanguage := range rightToLeftLanguages {
+		if language == rightToLeftLanguage {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"fmt"
+)
+
+// add, subtract, multiply and divide do arithmetic on two numbers: {{formatNumber (multiply quantity price)}}.
+
+func add(a any, b any) float64 {
+	return mustFloat("add", a) + mustFloat("add", b)
+}
+
+func subtract(a any, b any) float64 {
+	return mustFloat("subtract", a) - mustFloat("subtract", b)
+}
+
+func multiply(a any, b any) float64 {
+	return mustFloat("multiply", a) * mustFloat("multiply", b)
+}
+
+func divide(a any, b any) float64 {
+	divisor := mustFloat("divide", b)
+	if divisor == 0 {
+		panic(fmt.Errorf("divide: division of %v by zero", a))
+	}
+
+	return mustFloat("divide", a) / divisor
+}
+
+// sum adds up a field of every row of a query result: {{formatCurrency (sum data_0 "amount") "USD"}}.
+// Pass an empty field to add up a list of numbers. Null values are skipped.
+func sum(list any, field string) float64 {
+	total := 0.0
+	for _, value := range listValues("sum", list, field) {
+		if value != nil {
+			total += mustFloat("sum", value)
+		}
+	}
+
+	return total
+}
+
+// avg is the average of a field of every row of a query result, like sum. Null values are skipped.
+func avg(list any, field string) float64 {
+	total, count := 0.0, 0
+	for _, value := range listValues("avg", list, field) {
+		if value != nil {
+			total += mustFloat("avg", value)
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return total / float64(count)
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func TestArithmetic(t *testing.T) {
+	cases := []helperCase{
+		{name: "add", template: `{{add a b}}`, context: map[string]any{"a": 1, "b": 2.5}, want: "3.5"},
+		{name: "add strings", template: `{{add a b}}`, context: map[string]any{"a": "10.25", "b": "0.75"}, want: "11"},
+		{name: "subtract", template: `{{subtract a b}}`, context: map[string]any{"a": 10, "b": 4.5}, want: "5.5"},
+		{name: "subtract below zero", template: `{{subtract a b}}`, context: map[string]any{"a": 1, "b": 3}, want: "-2"},
+		{name: "multiply", template: `{{multiply a b}}`, context: map[string]any{"a": int64(3), "b": "1.5"}, want: "4.5"},
+		{name: "divide", template: `{{divide a b}}`, context: map[string]any{"a": 7, "b": 2}, want: "3.5"},
+		{name: "nested", template: `{{formatNumber (multiply (add a b) c) decimals=1}}`, context: map[string]any{"a": 1, "b": 2, "c": 1.5}, want: "4.5"},
+		{name: "add not a number", template: `{{add a b}}`, context: map[string]any{"a": "x", "b": 1}, wantErr: "add: x is not a number"},
+		{name: "add null", template: `{{add a b}}`, context: map[string]any{"a": nil, "b": 1}, wantErr: "add: <nil> is not a number"},
+		{name: "subtract not a number", template: `{{subtract a b}}`, context: map[string]any{"a": 1, "b": "y"}, wantErr: "subtract: y is not a number"},
+		{name: "multiply not a number", template: `{{multiply a b}}`, context: map[string]any{"a": true, "b": 1}, wantErr: "multiply: true is not a number"},
+		{name: "divide by zero", template: `{{divide a b}}`, context: map[string]any{"a": 7, "b": 0}, wantErr: "divide: division of 7 by zero"},
+		{name: "divide not a number", template: `{{divide a b}}`, context: map[string]any{"a": "z", "b": 2}, wantErr: "divide: z is not a number"},
+	}
+
+	runHelperCases(t, cases)
+}
+
+func TestSumAndAvg(t *testing.T) {
+	rows := []any{
+		map[string]any{"amount": 10},
+		map[string]any{"amount": "2.5"},
+		map[string]any{"amount": nil},
+		map[string]any{"amount": 7.5},
+	}
+
+	cases := []helperCase{
+		{name: "sum of a field", template: `{{sum rows "amount"}}`, context: map[string]any{"rows": rows}, want: "20"},
+		{name: "sum of a list", template: `{{sum values ""}}`, context: map[string]any{"values": []any{1, 2, 3.5}}, want: "6.5"},
+		{name: "sum of a single row", template: `{{sum value ""}}`, context: map[string]any{"value": 4}, want: "4"},
+		{name: "sum of nothing", template: `{{sum rows "amount"}}`, context: map[string]any{"rows": []any{}}, want: "0"},
+		{name: "sum of null", template: `{{sum rows "amount"}}`, context: map[string]any{"rows": nil}, want: "0"},
+		{name: "sum of a missing field", template: `{{sum rows "price"}}`, context: map[string]any{"rows": rows}, want: "0"},
+		{name: "avg skips nulls", template: `{{avg rows "amount"}}`, context: map[string]any{"rows": rows}, want: "6.666666666666667"},
+		{name: "avg of a list", template: `{{avg values ""}}`, context: map[string]any{"values": []any{1, 2}}, want: "1.5"},
+		{name: "avg of nothing", template: `{{avg rows "amount"}}`, context: map[string]any{"rows": []any{}}, want: "0"},
+		{name: "sum without fields", template: `{{sum values "amount"}}`, context: map[string]any{"values": []any{1, 2}}, wantErr: "sum: the items of the list don't have fields"},
+		{name: "sum not a number", template: `{{sum rows "amount"}}`, context: map[string]any{"rows": []any{map[string]any{"amount": "n/a"}}}, wantErr: "sum: n/a is not a number"},
+		{name: "avg not a number", template: `{{avg values ""}}`, context: map[string]any{"values": []any{"n/a"}}, wantErr: "avg: n/a is not a number"},
+	}
+
+	runHelperCases(t, cases)
+}
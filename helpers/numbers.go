@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"github.com/aymerick/raymond"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// formatNumber writes a number with the separators of the locale.
+//
+//	{{formatNumber amount}} {{formatNumber amount decimals=0 locale="fr"}}
+func formatNumber(value any, options *raymond.Options) string {
+	if value == nil {
+		return ""
+	}
+
+	number := mustFloat("formatNumber", value)
+	decimals := toInt("formatNumber", options.HashProp("decimals"), 2)
+
+	return formatLocalizedNumber(number, decimals, findLocaleFormat(localeOf(options)))
+}
+
+// formatCurrency writes an amount with the symbol and the decimals of the currency.
+//
+//	{{formatCurrency amount "EUR"}} {{formatCurrency amount "USD" locale="ar"}}
+func formatCurrency(value any, code string, options *raymond.Options) string {
+	if value == nil {
+		return ""
+	}
+
+	number := mustFloat("formatCurrency", value)
+	currency := findCurrency(code)
+	decimals := toInt("formatCurrency", options.HashProp("decimals"), currency.Decimals)
+	locale := localeOf(options)
+	format := findLocaleFormat(locale)
+
+	symbol := currency.symbolIn(locale)
+	pattern := format.CurrencyPattern
+	// Separate the codes used as symbols from the amount: CHF 10.00, not CHF10.00.
+	if pattern == "¤#" && symbol != "" && unicode.IsLetter([]rune(symbol)[len([]rune(symbol))-1]) {
+		pattern = "¤\u00a0#"
+	}
+
+	formatted := formatLocalizedNumber(math.Abs(number), decimals, format)
+	formatted = strings.Replace(strings.Replace(pattern, "#", formatted, 1), "¤", symbol, 1)
+	if number < 0 && formatted != "" {
+		formatted = "-" + formatted
+	}
+
+	return formatted
+}
+
+// percentage writes a ratio as a percentage: 0.255 is 26%.
+//
+//	{{percentage ratio}} {{percentage ratio decimals=1}}
+func percentage(value any, options *raymond.Options) string {
+	if value == nil {
+		return ""
+	}
+
+	number := mustFloat("percentage", value)
+	decimals := toInt("percentage", options.HashProp("decimals"), 0)
+	format := findLocaleFormat(localeOf(options))
+
+	return strings.Replace(format.PercentPattern, "#", formatLocalizedNumber(number*100, decimals, format), 1)
+}
+
+// formatLocalizedNumber rounds the number to the decimals and writes it with the separators and digits of the locale.
+func formatLocalizedNumber(number float64, decimals int, format localeFormat) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	formatted := strconv.FormatFloat(math.Abs(number), 'f', decimals, 64)
+	integerPart, fractionPart, _ := strings.Cut(formatted, ".")
+
+	// Group the integer part by thousands.
+	var builder strings.Builder
+	for i, digit := range integerPart {
+		if i > 0 && (len(integerPart)-i)%3 == 0 {
+			builder.WriteString(format.Group)
+		}
+		builder.WriteRune(digit)
+	}
+	if fractionPart != "" {
+		builder.WriteString(format.Decimal)
+		builder.WriteString(fractionPart)
+	}
+
+	result := localizeDigits(builder.String(), format)
+
+	// Don't print -0.00 for small negative numbers.
+	if number < 0 && strings.Trim(formatted, "0.") != "" {
+		result = "-" + result
+	}
+
+	return result
+}
+
+// localizeDigits replaces the ASCII digits with the digits of the locale.
+func localizeDigits(text string, format localeFormat) string {
+	if len(format.Digits) != 10 {
+		return text
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return format.Digits[r-'0']
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFormatNumber(t *testing.T) {
+	cases := []helperCase{
+		{name: "default", template: `{{formatNumber value}}`, context: map[string]any{"value": 1234567.891}, want: "1,234,567.89"},
+		{name: "no decimals", template: `{{formatNumber value decimals=0}}`, context: map[string]any{"value": 1234567.891}, want: "1,234,568"},
+		{name: "more decimals", template: `{{formatNumber value decimals=3}}`, context: map[string]any{"value": 0.5}, want: "0.500"},
+		{name: "negative", template: `{{formatNumber value}}`, context: map[string]any{"value": -1234.5}, want: "-1,234.50"},
+		{name: "small negative", template: `{{formatNumber value}}`, context: map[string]any{"value": -0.001}, want: "0.00"},
+		{name: "integer", template: `{{formatNumber value}}`, context: map[string]any{"value": int64(1000)}, want: "1,000.00"},
+		{name: "decimal string", template: `{{formatNumber value}}`, context: map[string]any{"value": "42.5"}, want: "42.50"},
+		{name: "json number", template: `{{formatNumber value}}`, context: map[string]any{"value": json.Number("7")}, want: "7.00"},
+		{name: "null", template: `{{formatNumber value}}`, context: map[string]any{"value": nil}, want: ""},
+		{name: "fr", template: `{{formatNumber value locale="fr"}}`, context: map[string]any{"value": 1234567.891}, want: "1\u00a0234\u00a0567,89"},
+		{name: "de", template: `{{formatNumber value locale="de"}}`, context: map[string]any{"value": 1234567.891}, want: "1.234.567,89"},
+		{name: "ar digits", template: `{{formatNumber value locale="ar"}}`, context: map[string]any{"value": 1234.5}, want: "١٬٢٣٤٫٥٠"},
+		{name: "regional locale", template: `{{formatNumber value locale="fr-CA"}}`, context: map[string]any{"value": 1234.5}, want: "1\u00a0234,50"},
+		{name: "unknown locale", template: `{{formatNumber value locale="xx"}}`, context: map[string]any{"value": 1234.5}, want: "1,234.50"},
+		{name: "render locale", template: `{{formatNumber value}}`, context: map[string]any{"value": 1234.5}, locale: "de", want: "1.234,50"},
+		{name: "hash over render locale", template: `{{formatNumber value locale="en"}}`, context: map[string]any{"value": 1234.5}, locale: "de", want: "1,234.50"},
+		{name: "not a number", template: `{{formatNumber value}}`, context: map[string]any{"value": "abc"}, wantErr: "formatNumber: abc is not a number"},
+		{name: "invalid decimals", template: `{{formatNumber value decimals="two"}}`, context: map[string]any{"value": 1}, wantErr: "formatNumber: two is not a number"},
+	}
+
+	runHelperCases(t, cases)
+}
+
+func TestFormatCurrency(t *testing.T) {
+	cases := []helperCase{
+		{name: "usd", template: `{{formatCurrency value "USD"}}`, context: map[string]any{"value": 1234.5}, want: "$1,234.50"},
+		{name: "lower case code", template: `{{formatCurrency value "usd"}}`, context: map[string]any{"value": 1234.5}, want: "$1,234.50"},
+		{name: "negative", template: `{{formatCurrency value "USD"}}`, context: map[string]any{"value": -5}, want: "-$5.00"},
+		{name: "no decimals", template: `{{formatCurrency value "JPY"}}`, context: map[string]any{"value": 1234.6}, want: "¥1,235"},
+		{name: "three decimals", template: `{{formatCurrency value "JOD"}}`, context: map[string]any{"value": 1.5}, want: "JOD\u00a01.500"},
+		{name: "decimals override", template: `{{formatCurrency value "USD" decimals=0}}`, context: map[string]any{"value": 1234.4}, want: "$1,234"},
+		{name: "code as symbol", template: `{{formatCurrency value "CHF"}}`, context: map[string]any{"value": 10}, want: "CHF\u00a010.00"},
+		{name: "unknown code", template: `{{formatCurrency value "XYZ"}}`, context: map[string]any{"value": 1}, want: "XYZ\u00a01.00"},
+		{name: "fr", template: `{{formatCurrency value "EUR" locale="fr"}}`, context: map[string]any{"value": 1234.5}, want: "1\u00a0234,50\u00a0€"},
+		{name: "ar symbol", template: `{{formatCurrency value "SAR" locale="ar"}}`, context: map[string]any{"value": 5}, want: "٥٫٠٠\u00a0ر.س."},
+		{name: "render locale", template: `{{formatCurrency value "EUR"}}`, context: map[string]any{"value": 5}, locale: "nl", want: "€\u00a05,00"},
+		{name: "null", template: `{{formatCurrency value "USD"}}`, context: map[string]any{"value": nil}, want: ""},
+		{name: "not a number", template: `{{formatCurrency value "USD"}}`, context: map[string]any{"value": "ten"}, wantErr: "formatCurrency: ten is not a number"},
+	}
+
+	runHelperCases(t, cases)
+}
+
+func TestPercentage(t *testing.T) {
+	cases := []helperCase{
+		{name: "default", template: `{{percentage value}}`, context: map[string]any{"value": 0.256}, want: "26%"},
+		{name: "decimals", template: `{{percentage value decimals=1}}`, context: map[string]any{"value": 0.1234}, want: "12.3%"},
+		{name: "over one", template: `{{percentage value}}`, context: map[string]any{"value": 12.5}, want: "1,250%"},
+		{name: "fr", template: `{{percentage value decimals=1 locale="fr"}}`, context: map[string]any{"value": 0.1234}, want: "12,3\u00a0%"},
+		{name: "ar", template: `{{percentage value locale="ar"}}`, context: map[string]any{"value": 0.5}, want: "٥٠٪"},
+		{name: "null", template: `{{percentage value}}`, context: map[string]any{"value": nil}, want: ""},
+		{name: "not a number", template: `{{percentage value}}`, context: map[string]any{"value": true}, wantErr: "percentage: true is not a number"},
+	}
+
+	runHelperCases(t, cases)
+}
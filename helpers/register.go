@@ -0,0 +1,61 @@
+// Package helpers holds the handlebars helpers available in every report template.
+package helpers
+
+import (
+	"github.com/aymerick/raymond"
+	"sync"
+)
+
+var registerOnce sync.Once
+
+// Register registers the helpers globally in raymond. It's safe to call it before every render, the helpers are only
+// registered the first time.
+func Register() {
+	registerOnce.Do(func() {
+		raymond.RegisterHelpers(map[string]any{
+			// Numbers
+			"formatNumber":   formatNumber,
+			"formatCurrency": formatCurrency,
+			"percentage":     percentage,
+			// Dates
+			"formatDate": formatDate,
+			// Charts
+			"chart": chart,
+			// Barcodes
+			"qrcode":  qrcode,
+			"barcode": barcodeOf,
+			// Assets
+			"asset": asset,
+			// Layouts
+			"extends": extends,
+			"content": content,
+			"block":   block,
+			// Sub-reports and details
+			"subreport": subreport,
+			"detail":    detail,
+			// Text
+			"upper":    upper,
+			"lower":    lower,
+			"truncate": truncate,
+			// Translations
+			"t": t,
+			// Missing values
+			"default":  defaultValue,
+			"coalesce": coalesce,
+			// Math
+			"add":      add,
+			"subtract": subtract,
+			"multiply": multiply,
+			"divide":   divide,
+			"sum":      sum,
+			"avg":      avg,
+			// Comparisons
+			"eq":  eq,
+			"ne":  ne,
+			"gt":  gt,
+			"gte": gte,
+			"lt":  lt,
+			"lte": lte,
+		})
+	})
+}
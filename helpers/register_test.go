@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"github.com/aymerick/raymond"
+	"strings"
+	"testing"
+)
+
+// helperCase is a template rendered with the helpers, and its expected output or the expected part of its error.
+type helperCase struct {
+	name     string
+	template string
+	context  map[string]any
+	// locale is the locale of the render, set in the data frame like core does.
+	locale string
+	want   string
+	// wantErr is a part of the expected error. The output isn't checked when it is set.
+	wantErr string
+}
+
+// renderWithHelpers renders the template with the helpers registered, in the locale if any.
+func renderWithHelpers(source string, context map[string]any, locale string) (string, error) {
+	Register()
+
+	template, err := raymond.Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	frame := raymond.NewDataFrame()
+	if locale != "" {
+		frame.Set("locale", locale)
+	}
+
+	return template.ExecWith(context, frame)
+}
+
+// runHelperCases renders every case and checks its output or its error.
+func runHelperCases(t *testing.T, cases []helperCase) {
+	t.Helper()
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := renderWithHelpers(testCase.template, testCase.context, testCase.locale)
+
+			if testCase.wantErr != "" {
+				if err == nil {
+					t.Fatalf("got %q, want an error containing %q", got, testCase.wantErr)
+				}
+				if !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("got the error %q, want it to contain %q", err, testCase.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != testCase.want {
+				t.Errorf("got %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestHelperErrorsFailTheRender(t *testing.T) {
+	// The helpers fail the render by panicking with an error, which raymond returns from Exec.
+	cases := []helperCase{
+		{name: "not a number", template: `{{formatNumber value}}`, context: map[string]any{"value": "abc"}, wantErr: "formatNumber: abc is not a number"},
+		{name: "division by zero", template: `{{divide 1 0}}`, wantErr: "divide: division of 1 by zero"},
+		{name: "unknown timezone", template: `{{formatDate 0 "" timezone="Mars/Olympus"}}`, wantErr: "formatDate: unknown timezone Mars/Olympus"},
+		{name: "in a subexpression", template: `{{formatNumber (add value 1)}}`, context: map[string]any{"value": "x"}, wantErr: "add: x is not a number"},
+		{name: "in a block", template: `{{#each rows}}{{multiply this 2}}{{/each}}`, context: map[string]any{"rows": []any{1, "two"}}, wantErr: "multiply: two is not a number"},
+	}
+
+	runHelperCases(t, cases)
+}
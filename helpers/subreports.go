@@ -0,0 +1,25 @@
+package helpers
+
+import (
+	"fmt"
+	"github.com/aymerick/raymond"
+)
+
+// subreport renders the body of another report inline, running its queries with the hash arguments as parameters.
+// Inside {{#each}} it's rendered once per row.
+//
+//	{{subreport "company_address"}}
+//	{{#each [Q[SELECT id FROM invoices WHERE customer_id = [P[customer_id]]]]}}{{subreport "invoice_lines" invoice_id=id}}{{/each}}
+func subreport(name string, options *raymond.Options) raymond.SafeString {
+	renderSubreport, ok := options.Data("renderSubreport").(func(name string, params map[string]any) (string, error))
+	if !ok {
+		panic(fmt.Errorf("subreport: sub-reports can only be rendered in the body of a report"))
+	}
+
+	result, err := renderSubreport(name, options.Hash())
+	if err != nil {
+		panic(fmt.Errorf("subreport %s: %v", name, err))
+	}
+
+	return raymond.SafeString(result)
+}
@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"github.com/aymerick/raymond"
+	"strings"
+)
+
+// upper writes a value in upper case.
+func upper(value any) string {
+	return strings.ToUpper(toString(value))
+}
+
+// lower writes a value in lower case.
+func lower(value any) string {
+	return strings.ToLower(toString(value))
+}
+
+// truncate shortens a value to the given number of characters, suffix included.
+//
+//	{{truncate description 40}} {{truncate description 40 suffix="..."}}
+func truncate(value any, length any, options *raymond.Options) string {
+	maxLength := toInt("truncate", length, 0)
+	if maxLength < 0 {
+		maxLength = 0
+	}
+
+	suffix := "…"
+	if options.HashProp("suffix") != nil {
+		suffix = options.HashStr("suffix")
+	}
+
+	characters := []rune(toString(value))
+	if len(characters) <= maxLength {
+		return string(characters)
+	}
+
+	suffixLength := len([]rune(suffix))
+	if maxLength <= suffixLength {
+		return string(characters[:maxLength])
+	}
+
+	return string(characters[:maxLength-suffixLength]) + suffix
+}
+
+// defaultValue returns the fallback when the value is empty: null, false, 0, "" or an empty list.
+//
+//	{{default notes "No notes"}}
+func defaultValue(value any, fallback any) any {
+	if isEmpty(value) {
+		return fallback
+	}
+
+	return value
+}
+
+// coalesce returns the fallback only when the value is null, like SQL's COALESCE. Calls can be nested to try more
+// values: {{coalesce phone (coalesce mobile "-")}}.
+func coalesce(value any, fallback any) any {
+	if value == nil {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func TestUpperAndLower(t *testing.T) {
+	cases := []helperCase{
+		{name: "upper", template: `{{upper value}}`, context: map[string]any{"value": "Café"}, want: "CAFÉ"},
+		{name: "upper number", template: `{{upper value}}`, context: map[string]any{"value": 1.5}, want: "1.5"},
+		{name: "upper null", template: `{{upper value}}`, context: map[string]any{"value": nil}, want: ""},
+		{name: "lower", template: `{{lower value}}`, context: map[string]any{"value": "ÉCOLE"}, want: "école"},
+		{name: "lower null", template: `{{lower value}}`, context: map[string]any{"value": nil}, want: ""},
+	}
+
+	runHelperCases(t, cases)
+}
+
+func TestTruncate(t *testing.T) {
+	cases := []helperCase{
+		{name: "shorter", template: `{{truncate value 20}}`, context: map[string]any{"value": "Hello world"}, want: "Hello world"},
+		{name: "exact length", template: `{{truncate value 11}}`, context: map[string]any{"value": "Hello world"}, want: "Hello world"},
+		{name: "longer", template: `{{truncate value 5}}`, context: map[string]any{"value": "Hello world"}, want: "Hell…"},
+		{name: "suffix", template: `{{truncate value 8 suffix="..."}}`, context: map[string]any{"value": "Hello world"}, want: "Hello..."},
+		{name: "empty suffix", template: `{{truncate value 5 suffix=""}}`, context: map[string]any{"value": "Hello world"}, want: "Hello"},
+		{name: "shorter than the suffix", template: `{{truncate value 2 suffix="..."}}`, context: map[string]any{"value": "Hello world"}, want: "He"},
+		{name: "characters", template: `{{truncate value 4}}`, context: map[string]any{"value": "مرحبا بالعالم"}, want: "مرح…"},
+		{name: "negative length", template: `{{truncate value -1}}`, context: map[string]any{"value": "Hello"}, want: ""},
+		{name: "length as string", template: `{{truncate value "3"}}`, context: map[string]any{"value": "Hello"}, want: "He…"},
+		{name: "null", template: `{{truncate value 3}}`, context: map[string]any{"value": nil}, want: ""},
+		{name: "invalid length", template: `{{truncate value "three"}}`, context: map[string]any{"value": "Hello"}, wantErr: "truncate: three is not a number"},
+	}
+
+	runHelperCases(t, cases)
+}
+
+func TestDefaultAndCoalesce(t *testing.T) {
+	cases := []helperCase{
+		{name: "default of a value", template: `{{default value "-"}}`, context: map[string]any{"value": "notes"}, want: "notes"},
+		{name: "default of null", template: `{{default value "-"}}`, context: map[string]any{"value": nil}, want: "-"},
+		{name: "default of empty", template: `{{default value "-"}}`, context: map[string]any{"value": ""}, want: "-"},
+		{name: "default of zero", template: `{{default value "-"}}`, context: map[string]any{"value": 0}, want: "-"},
+		{name: "default of false", template: `{{default value "-"}}`, context: map[string]any{"value": false}, want: "-"},
+		{name: "default of empty list", template: `{{default value "-"}}`, context: map[string]any{"value": []any{}}, want: "-"},
+		{name: "default of missing", template: `{{default missing "-"}}`, context: map[string]any{}, want: "-"},
+		{name: "coalesce of a value", template: `{{coalesce value "-"}}`, context: map[string]any{"value": "phone"}, want: "phone"},
+		{name: "coalesce of null", template: `{{coalesce value "-"}}`, context: map[string]any{"value": nil}, want: "-"},
+		{name: "coalesce keeps zero", template: `{{coalesce value "-"}}`, context: map[string]any{"value": 0}, want: "0"},
+		{name: "coalesce keeps empty", template: `[{{coalesce value "-"}}]`, context: map[string]any{"value": ""}, want: "[]"},
+		{name: "nested coalesce", template: `{{coalesce phone (coalesce mobile "-")}}`, context: map[string]any{"phone": nil, "mobile": "555"}, want: "555"},
+		{name: "nested coalesce fallback", template: `{{coalesce phone (coalesce mobile "-")}}`, context: map[string]any{"phone": nil, "mobile": nil}, want: "-"},
+	}
+
+	runHelperCases(t, cases)
+}
@@ -0,0 +1,25 @@
+package helpers
+
+import (
+	"github.com/aymerick/raymond"
+	"strings"
+)
+
+// t writes the translation of a key in the locale of the render. Hash arguments replace their {name} placeholders.
+// A missing translation writes the key itself so that it stands out in the document.
+//
+//	{{t "invoice.title"}} {{t "invoice.greeting" name=customer_name}}
+func t(key string, options *raymond.Options) string {
+	translation := key
+
+	translations, _ := options.Data("translations").(map[string]string)
+	if value, ok := translations[key]; ok {
+		translation = value
+	}
+
+	for name, value := range options.Hash() {
+		translation = strings.ReplaceAll(translation, "{"+name+"}", toString(value))
+	}
+
+	return translation
+}
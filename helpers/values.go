@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// toFloat converts a template value to a number. Database drivers return decimals as strings, so numeric strings are
+// accepted too.
+func toFloat(value any) (float64, bool) {
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, true
+	case float32:
+		return float64(typedValue), true
+	case int:
+		return float64(typedValue), true
+	case int32:
+		return float64(typedValue), true
+	case int64:
+		return float64(typedValue), true
+	case uint:
+		return float64(typedValue), true
+	case uint32:
+		return float64(typedValue), true
+	case uint64:
+		return float64(typedValue), true
+	case json.Number:
+		number, err := typedValue.Float64()
+		return number, err == nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(typedValue), 64)
+		return number, err == nil
+	case []byte:
+		number, err := strconv.ParseFloat(strings.TrimSpace(string(typedValue)), 64)
+		return number, err == nil
+	}
+
+	return 0, false
+}
+
+// mustFloat converts a template value to a number or fails the render.
+func mustFloat(helperName string, value any) float64 {
+	number, ok := toFloat(value)
+	if !ok {
+		panic(fmt.Errorf("%s: %v is not a number", helperName, value))
+	}
+
+	return number
+}
+
+// toInt converts an optional hash argument to an int, returning the fallback if it's missing.
+func toInt(helperName string, value any, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+
+	return int(mustFloat(helperName, value))
+}
+
+// toString converts a template value to the string handlebars would print.
+func toString(value any) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typedValue
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// isEmpty follows the truthiness of handlebars: nil, false, 0, "" and empty lists and maps are empty.
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Bool:
+		return !reflectValue.Bool()
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return reflectValue.Len() == 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflectValue.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflectValue.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return reflectValue.Float() == 0
+	case reflect.Pointer, reflect.Interface:
+		return reflectValue.IsNil()
+	}
+
+	return false
+}
+
+// listValues returns the values of a list, or the given field of every row of a list of rows. A single value is a list
+// of one value.
+func listValues(helperName string, list any, field string) []any {
+	if list == nil {
+		return nil
+	}
+
+	// A query that returns a single row is replaced by its value in the template.
+	reflectValue := reflect.ValueOf(list)
+	if reflectValue.Kind() != reflect.Slice && reflectValue.Kind() != reflect.Array {
+		return []any{list}
+	}
+
+	values := make([]any, 0, reflectValue.Len())
+	for i := 0; i < reflectValue.Len(); i++ {
+		item := reflectValue.Index(i).Interface()
+
+		if field != "" {
+			row, ok := item.(map[string]any)
+			if !ok {
+				panic(fmt.Errorf("%s: the items of the list don't have fields", helperName))
+			}
+			item = row[field]
+		}
+
+		values = append(values, item)
+	}
+
+	return values
+}
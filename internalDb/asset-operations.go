@@ -0,0 +1,102 @@
+package internalDb
+
+import (
+	"database/sql"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+)
+
+// assetColumns are the columns scanned by scanAssets, in order. The data is only read by GetAsset.
+const assetColumns = "report_name, name, content_type, size, checksum, created_at, updated_at"
+
+// ListAssets returns the assets of a report, or every asset if reportName is None. Use Some("") for the global ones.
+// The data of the assets isn't read.
+func ListAssets(internalDb *datasource.DataSource, reportName safego.Option[string]) ([]types.Asset, safego.Option[error]) {
+	query := "SELECT " + assetColumns + " FROM assets"
+	args := []any{}
+	if reportName.IsSome() {
+		query += " WHERE report_name = ?"
+		args = append(args, reportName.Unwrap())
+	}
+	query += " ORDER BY report_name, name"
+
+	rows, errOpt := (*internalDb).Query(query, args...)
+	if errOpt.IsSome() {
+		return []types.Asset{}, errOpt
+	}
+
+	return scanAssets(rows, false)
+}
+
+// GetAsset returns the asset of the report with the given name, with its data, or None if it doesn't exist.
+// The global assets have an empty report name.
+func GetAsset(internalDb *datasource.DataSource, reportName string, name string) (safego.Option[types.Asset], safego.Option[error]) {
+	rows, errOpt := (*internalDb).Query("SELECT "+assetColumns+", data FROM assets WHERE report_name = ? AND name = ?", reportName, name)
+	if errOpt.IsSome() {
+		return safego.None[types.Asset](), errOpt
+	}
+
+	assets, errOpt := scanAssets(rows, true)
+	if errOpt.IsSome() || len(assets) == 0 {
+		return safego.None[types.Asset](), errOpt
+	}
+
+	return safego.Some(assets[0]), safego.None[error]()
+}
+
+// FindAsset returns the asset a report sees under the name, without its data: its own asset, or else the global one.
+func FindAsset(internalDb *datasource.DataSource, reportName string, name string) (safego.Option[types.Asset], safego.Option[error]) {
+	rows, errOpt := (*internalDb).Query(
+		"SELECT "+assetColumns+" FROM assets WHERE name = ? AND report_name IN (?, '') ORDER BY report_name DESC LIMIT 1",
+		name, reportName,
+	)
+	if errOpt.IsSome() {
+		return safego.None[types.Asset](), errOpt
+	}
+
+	assets, errOpt := scanAssets(rows, false)
+	if errOpt.IsSome() || len(assets) == 0 {
+		return safego.None[types.Asset](), errOpt
+	}
+
+	return safego.Some(assets[0]), safego.None[error]()
+}
+
+// SaveAsset creates the asset, or replaces the one with the same report and name.
+func SaveAsset(internalDb *datasource.DataSource, asset types.Asset) safego.Option[error] {
+	return (*internalDb).Exec(
+		`INSERT INTO assets (report_name, name, content_type, data, size, checksum, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (report_name, name) DO UPDATE SET content_type = excluded.content_type, data = excluded.data, size = excluded.size,
+			checksum = excluded.checksum, updated_at = excluded.updated_at`,
+		asset.ReportName, asset.Name, asset.ContentType, asset.Data, asset.Size, asset.Checksum, asset.CreatedAt, asset.UpdatedAt,
+	)
+}
+
+// DeleteAsset deletes the asset of the report with the given name.
+func DeleteAsset(internalDb *datasource.DataSource, reportName string, name string) safego.Option[error] {
+	return (*internalDb).Exec("DELETE FROM assets WHERE report_name = ? AND name = ?", reportName, name)
+}
+
+// scanAssets reads every asset out of the rows and closes them.
+func scanAssets(rows *sql.Rows, withData bool) ([]types.Asset, safego.Option[error]) {
+	defer rows.Close()
+
+	assets := []types.Asset{}
+	for rows.Next() {
+		asset := types.Asset{}
+		destinations := []any{&asset.ReportName, &asset.Name, &asset.ContentType, &asset.Size, &asset.Checksum, &asset.CreatedAt, &asset.UpdatedAt}
+		if withData {
+			destinations = append(destinations, &asset.Data)
+		}
+
+		err := rows.Scan(destinations...)
+		if err != nil {
+			return []types.Asset{}, safego.Some(err)
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, safego.None[error]()
+}
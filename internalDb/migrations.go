@@ -0,0 +1,89 @@
+package internalDb
+
+import (
+	"fmt"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+)
+
+// migrations are applied in order on top of the tables created by createInternalDbTables.
+// The number of applied migrations is stored in SQLite's user_version pragma, so a migration
+// must never be edited or removed once released. Append new ones instead.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		render_id VARCHAR(255) NOT NULL,
+		url TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER NOT NULL,
+		error TEXT NULL,
+		created_at INTEGER NOT NULL
+	);`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_render_id ON webhook_deliveries (render_id);`,
+	`ALTER TABLE reports ADD COLUMN printing_options TEXT NULL;`,
+	`ALTER TABLE reports ADD COLUMN parameters TEXT NULL;`,
+	`CREATE TABLE IF NOT EXISTS report_translations (
+		report_name VARCHAR(255) NOT NULL,
+		locale VARCHAR(35) NOT NULL,
+		key VARCHAR(255) NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (report_name, locale, key)
+	);`,
+	`CREATE TABLE IF NOT EXISTS assets (
+		report_name VARCHAR(255) NOT NULL DEFAULT '',
+		name VARCHAR(255) NOT NULL,
+		content_type VARCHAR(255) NOT NULL,
+		data BLOB NOT NULL,
+		size INTEGER NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (report_name, name)
+	);`,
+	`CREATE TABLE IF NOT EXISTS partials (
+		name VARCHAR(255) PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);`,
+	`ALTER TABLE webhook_deliveries ADD COLUMN canceled INTEGER NOT NULL DEFAULT 0;`,
+}
+
+// MigrateInternalDb brings the internal database schema up to date.
+func MigrateInternalDb(internalDb *datasource.DataSource) safego.Option[error] {
+	// The reports table predates the migrations.
+	errOpt := (*internalDb).Exec(createReportsTableSQL)
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	rows, errOpt := (*internalDb).Query("PRAGMA user_version")
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	appliedMigrations := 0
+	for rows.Next() {
+		err := rows.Scan(&appliedMigrations)
+		if err != nil {
+			rows.Close()
+			return safego.Some(err)
+		}
+	}
+	rows.Close()
+
+	for i := appliedMigrations; i < len(migrations); i++ {
+		errOpt = (*internalDb).Exec(migrations[i])
+		if errOpt.IsSome() {
+			return safego.Some(fmt.Errorf("migration %d failed: %v", i+1, errOpt.Unwrap()))
+		}
+
+		errOpt = (*internalDb).Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1))
+		if errOpt.IsSome() {
+			return errOpt
+		}
+	}
+
+	return safego.None[error]()
+}
@@ -1,22 +1,59 @@
 package internalDb
 
 import (
+	"database/sql"
+	"encoding/json"
 	"github.com/okira-e/goreports/datasource"
 	"github.com/okira-e/goreports/safego"
 	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
 )
 
+// reportColumns are the columns scanned by scanReports, in order.
+const reportColumns = "id, name, title, description, body, header, footer, printing_options, parameters, created_at, updated_at"
+
 func ListReports(internalDb *datasource.DataSource) ([]types.Report, safego.Option[error]) {
-	rows, errOpt := (*internalDb).Query("SELECT * FROM reports")
+	rows, errOpt := (*internalDb).Query("SELECT " + reportColumns + " FROM reports ORDER BY name")
 	if errOpt.IsSome() {
 		return []types.Report{}, safego.Some(errOpt.Unwrap())
 	}
 
+	reports, errOpt := scanReports(rows)
+	if errOpt.IsSome() {
+		return []types.Report{}, errOpt
+	}
+
+	return reports, safego.None[error]()
+}
+
+// GetReport returns the report with the given name, or None if it doesn't exist.
+func GetReport(internalDb *datasource.DataSource, name string) (safego.Option[types.Report], safego.Option[error]) {
+	rows, errOpt := (*internalDb).Query("SELECT "+reportColumns+" FROM reports WHERE name = ?", name)
+	if errOpt.IsSome() {
+		return safego.None[types.Report](), errOpt
+	}
+
+	reports, errOpt := scanReports(rows)
+	if errOpt.IsSome() {
+		return safego.None[types.Report](), errOpt
+	}
+
+	if len(reports) == 0 {
+		return safego.None[types.Report](), safego.None[error]()
+	}
+
+	return safego.Some(reports[0]), safego.None[error]()
+}
+
+// scanReports reads every report out of the rows and closes them.
+func scanReports(rows *sql.Rows) ([]types.Report, safego.Option[error]) {
+	defer rows.Close()
+
 	var reportsWithNullableFields []types.ReportWithNullableFields
 
 	for rows.Next() {
 		report := types.ReportWithNullableFields{}
-		err := rows.Scan(&report.ID, &report.Name, &report.Title, &report.Description, &report.Body, &report.Header, &report.Footer, &report.CreatedAt, &report.UpdatedAt)
+		err := rows.Scan(&report.ID, &report.Name, &report.Title, &report.Description, &report.Body, &report.Header, &report.Footer, &report.PrintingOptions, &report.Parameters, &report.CreatedAt, &report.UpdatedAt)
 		if err != nil {
 			return []types.Report{}, safego.Some(err)
 		}
@@ -26,16 +63,107 @@ func ListReports(internalDb *datasource.DataSource) ([]types.Report, safego.Opti
 	// Convert the nullable fields to non-nullable fields.
 	reports := []types.Report{}
 	for _, report := range reportsWithNullableFields {
-		reports = append(reports, types.Report{
+		convertedReport := types.Report{
 			ID:          report.ID,
 			Name:        report.Name.String,
 			Title:       report.Title.String,
 			Description: report.Description.String,
 			Body:        report.Body.String,
+			Header:      report.Header.String,
+			Footer:      report.Footer.String,
 			CreatedAt:   report.CreatedAt.Int64,
 			UpdatedAt:   report.UpdatedAt.Int64,
-		})
+		}
+
+		if report.PrintingOptions.Valid {
+			err := json.Unmarshal([]byte(report.PrintingOptions.String), &convertedReport.PrintingOptions)
+			if err != nil {
+				return []types.Report{}, safego.Some(err)
+			}
+		}
+		if report.Parameters.Valid {
+			err := json.Unmarshal([]byte(report.Parameters.String), &convertedReport.Parameters)
+			if err != nil {
+				return []types.Report{}, safego.Some(err)
+			}
+		}
+
+		reports = append(reports, convertedReport)
 	}
 
 	return reports, safego.None[error]()
 }
+
+// SaveReport inserts a new report.
+func SaveReport(internalDb *datasource.DataSource, report types.Report) safego.Option[error] {
+	printingOptions, parameters, errOpt := marshalReportSettings(report)
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	return (*internalDb).Exec(
+		"INSERT INTO reports (name, title, description, body, header, footer, printing_options, parameters, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		report.Name, report.Title, report.Description, report.Body, nullableString(report.Header), nullableString(report.Footer), printingOptions, parameters, report.CreatedAt, report.UpdatedAt,
+	)
+}
+
+// UpdateReport overwrites everything but the name and creation time of an existing report.
+func UpdateReport(internalDb *datasource.DataSource, report types.Report) safego.Option[error] {
+	printingOptions, parameters, errOpt := marshalReportSettings(report)
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	return (*internalDb).Exec(
+		"UPDATE reports SET title = ?, description = ?, body = ?, header = ?, footer = ?, printing_options = ?, parameters = ?, updated_at = ? WHERE name = ?",
+		report.Title, report.Description, report.Body, nullableString(report.Header), nullableString(report.Footer), printingOptions, parameters, report.UpdatedAt, report.Name,
+	)
+}
+
+// RenameReport changes the name of a report. Its translations and assets follow it, in the same transaction.
+func RenameReport(internalDb *datasource.DataSource, oldName string, newName string) safego.Option[error] {
+	return (*internalDb).ExecAll(
+		datasource.Statement{Query: "UPDATE reports SET name = ?, updated_at = ? WHERE name = ?", Args: []any{newName, utils.GetTimestamp(), oldName}},
+		datasource.Statement{Query: "UPDATE report_translations SET report_name = ? WHERE report_name = ?", Args: []any{newName, oldName}},
+		datasource.Statement{Query: "UPDATE assets SET report_name = ? WHERE report_name = ?", Args: []any{newName, oldName}},
+	)
+}
+
+// DeleteReport deletes a report with its translations and assets, in a single transaction.
+func DeleteReport(internalDb *datasource.DataSource, name string) safego.Option[error] {
+	return (*internalDb).ExecAll(
+		datasource.Statement{Query: "DELETE FROM reports WHERE name = ?", Args: []any{name}},
+		datasource.Statement{Query: "DELETE FROM report_translations WHERE report_name = ?", Args: []any{name}},
+		datasource.Statement{Query: "DELETE FROM assets WHERE report_name = ?", Args: []any{name}},
+	)
+}
+
+// nullableString stores empty strings as NULL.
+func nullableString(str string) sql.NullString {
+	return sql.NullString{
+		String: str,
+		Valid:  len(str) > 0,
+	}
+}
+
+// marshalReportSettings encodes the printing options and parameters of a report as nullable JSON strings.
+func marshalReportSettings(report types.Report) (sql.NullString, sql.NullString, safego.Option[error]) {
+	printingOptions, parameters := sql.NullString{}, sql.NullString{}
+
+	if report.PrintingOptions != nil {
+		encoded, err := json.Marshal(report.PrintingOptions)
+		if err != nil {
+			return printingOptions, parameters, safego.Some(err)
+		}
+		printingOptions = nullableString(string(encoded))
+	}
+	if len(report.Parameters) > 0 {
+		encoded, err := json.Marshal(report.Parameters)
+		if err != nil {
+			return printingOptions, parameters, safego.Some(err)
+		}
+		parameters = nullableString(string(encoded))
+	}
+
+	return printingOptions, parameters, safego.None[error]()
+}
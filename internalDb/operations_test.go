@@ -0,0 +1,78 @@
+package internalDb
+
+import (
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenameReportRollsBack(t *testing.T) {
+	internalDbConn := newInternalDb(t)
+	mustSucceed(t, SaveReport(&internalDbConn, types.Report{Name: "invoice", Title: "Invoice", Body: "<p>Invoice</p>"}))
+	mustSucceed(t, SaveTranslations(&internalDbConn, types.ReportTranslations{ReportName: "invoice", Locale: "fr", Translations: map[string]string{"total": "Total"}}))
+	mustSucceed(t, SaveAsset(&internalDbConn, types.Asset{ReportName: "invoice", Name: "logo.png", Data: []byte("invoice")}))
+	// A leftover asset of a deleted receipt report makes moving the assets of the invoice fail.
+	mustSucceed(t, SaveAsset(&internalDbConn, types.Asset{ReportName: "receipt", Name: "logo.png", Data: []byte("receipt")}))
+
+	errOpt := RenameReport(&internalDbConn, "invoice", "receipt")
+	if errOpt.IsNone() {
+		t.Fatal("got no error, want the assets to conflict")
+	}
+
+	reportOpt, errOpt := GetReport(&internalDbConn, "invoice")
+	mustSucceed(t, errOpt)
+	if reportOpt.IsNone() {
+		t.Error("the report was renamed although its assets weren't moved")
+	}
+	translations, errOpt := ListTranslations(&internalDbConn, "invoice")
+	mustSucceed(t, errOpt)
+	if len(translations) != 1 {
+		t.Errorf("got the translations %v, want them left with the invoice", translations)
+	}
+}
+
+func TestDeleteReport(t *testing.T) {
+	internalDbConn := newInternalDb(t)
+	mustSucceed(t, SaveReport(&internalDbConn, types.Report{Name: "invoice", Title: "Invoice", Body: "<p>Invoice</p>"}))
+	mustSucceed(t, SaveTranslations(&internalDbConn, types.ReportTranslations{ReportName: "invoice", Locale: "fr", Translations: map[string]string{"total": "Total"}}))
+	mustSucceed(t, SaveAsset(&internalDbConn, types.Asset{ReportName: "invoice", Name: "logo.png", Data: []byte("invoice")}))
+	mustSucceed(t, SaveAsset(&internalDbConn, types.Asset{Name: "logo.png", Data: []byte("global")}))
+
+	mustSucceed(t, DeleteReport(&internalDbConn, "invoice"))
+
+	reportOpt, errOpt := GetReport(&internalDbConn, "invoice")
+	mustSucceed(t, errOpt)
+	translations, errOpt := ListTranslations(&internalDbConn, "invoice")
+	mustSucceed(t, errOpt)
+	assets, errOpt := ListAssets(&internalDbConn, safego.None[string]())
+	mustSucceed(t, errOpt)
+	if reportOpt.IsSome() || len(translations) != 0 {
+		t.Errorf("got the report %v and the translations %v, want them deleted", reportOpt.IsSome(), translations)
+	}
+	if len(assets) != 1 || assets[0].ReportName != "" {
+		t.Errorf("got the assets %+v, want only the global one", assets)
+	}
+}
+
+func mustSucceed(t *testing.T, errOpt safego.Option[error]) {
+	t.Helper()
+
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+}
+
+// newInternalDb returns a migrated internal database in a temporary directory.
+func newInternalDb(t *testing.T) datasource.DataSource {
+	t.Helper()
+
+	var internalDbConn datasource.DataSource
+	internalDbConn = datasource.NewSqliteDb(filepath.Join(t.TempDir(), "internal.db"))
+	mustSucceed(t, internalDbConn.Connect())
+	t.Cleanup(func() { internalDbConn.Disconnect() })
+	mustSucceed(t, MigrateInternalDb(&internalDbConn))
+
+	return internalDbConn
+}
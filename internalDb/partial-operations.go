@@ -0,0 +1,69 @@
+package internalDb
+
+import (
+	"database/sql"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+)
+
+// partialColumns are the columns scanned by scanPartials, in order.
+const partialColumns = "name, description, body, created_at, updated_at"
+
+// ListPartials returns every partial, ordered by name.
+func ListPartials(internalDb *datasource.DataSource) ([]types.Partial, safego.Option[error]) {
+	rows, errOpt := (*internalDb).Query("SELECT " + partialColumns + " FROM partials ORDER BY name")
+	if errOpt.IsSome() {
+		return []types.Partial{}, errOpt
+	}
+
+	return scanPartials(rows)
+}
+
+// GetPartial returns the partial with the given name, or None if it doesn't exist.
+func GetPartial(internalDb *datasource.DataSource, name string) (safego.Option[types.Partial], safego.Option[error]) {
+	rows, errOpt := (*internalDb).Query("SELECT "+partialColumns+" FROM partials WHERE name = ?", name)
+	if errOpt.IsSome() {
+		return safego.None[types.Partial](), errOpt
+	}
+
+	partials, errOpt := scanPartials(rows)
+	if errOpt.IsSome() || len(partials) == 0 {
+		return safego.None[types.Partial](), errOpt
+	}
+
+	return safego.Some(partials[0]), safego.None[error]()
+}
+
+// SavePartial creates the partial, or replaces the one with the same name and keeps its creation date.
+func SavePartial(internalDb *datasource.DataSource, partial types.Partial) safego.Option[error] {
+	return (*internalDb).Exec(
+		`INSERT INTO partials (name, description, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET description = excluded.description, body = excluded.body, updated_at = excluded.updated_at`,
+		partial.Name, partial.Description, partial.Body, partial.CreatedAt, partial.UpdatedAt,
+	)
+}
+
+// DeletePartial deletes the partial with the given name.
+func DeletePartial(internalDb *datasource.DataSource, name string) safego.Option[error] {
+	return (*internalDb).Exec("DELETE FROM partials WHERE name = ?", name)
+}
+
+// scanPartials reads every partial out of the rows and closes them.
+func scanPartials(rows *sql.Rows) ([]types.Partial, safego.Option[error]) {
+	defer rows.Close()
+
+	partials := []types.Partial{}
+	for rows.Next() {
+		partial := types.Partial{}
+
+		err := rows.Scan(&partial.Name, &partial.Description, &partial.Body, &partial.CreatedAt, &partial.UpdatedAt)
+		if err != nil {
+			return []types.Partial{}, safego.Some(err)
+		}
+
+		partials = append(partials, partial)
+	}
+
+	return partials, safego.None[error]()
+}
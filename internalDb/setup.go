@@ -29,10 +29,8 @@ func BuildInternalDb(dataDir string) safego.Option[error] {
 	return safego.None[error]()
 }
 
-// createInternalDbTables creates all internal database tables.
-func createInternalDbTables(connection *sql.DB) safego.Option[error] {
-	const (
-		createReportsTableSQL = `
+// createReportsTableSQL creates the reports table. Later schema changes live in migrations.go.
+const createReportsTableSQL = `
 			CREATE TABLE IF NOT EXISTS reports (
 				id INTEGER PRIMARY KEY AUTOINCREMENT,
 				name VARCHAR(255) NOT NULL UNIQUE,
@@ -44,8 +42,9 @@ func createInternalDbTables(connection *sql.DB) safego.Option[error] {
 				created_at TEXT NOT NULL,
 				updated_at TEXT NOT NULL
         );`
-	)
 
+// createInternalDbTables creates all internal database tables.
+func createInternalDbTables(connection *sql.DB) safego.Option[error] {
 	// Create the reports table.
 	_, err := connection.Exec(createReportsTableSQL)
 	if err != nil {
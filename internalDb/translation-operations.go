@@ -0,0 +1,58 @@
+package internalDb
+
+import (
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+)
+
+// ListTranslations returns the translation tables of a report by locale.
+func ListTranslations(internalDb *datasource.DataSource, reportName string) (map[string]map[string]string, safego.Option[error]) {
+	rows, errOpt := (*internalDb).Query("SELECT locale, key, value FROM report_translations WHERE report_name = ?", reportName)
+	if errOpt.IsSome() {
+		return map[string]map[string]string{}, errOpt
+	}
+	defer rows.Close()
+
+	tables := map[string]map[string]string{}
+	for rows.Next() {
+		var locale, key, value string
+
+		err := rows.Scan(&locale, &key, &value)
+		if err != nil {
+			return map[string]map[string]string{}, safego.Some(err)
+		}
+
+		if tables[locale] == nil {
+			tables[locale] = map[string]string{}
+		}
+		tables[locale][key] = value
+	}
+
+	return tables, safego.None[error]()
+}
+
+// SaveTranslations replaces the translation table of a report in a locale.
+func SaveTranslations(internalDb *datasource.DataSource, translations types.ReportTranslations) safego.Option[error] {
+	errOpt := DeleteTranslations(internalDb, translations.ReportName, translations.Locale)
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	for key, value := range translations.Translations {
+		errOpt = (*internalDb).Exec(
+			"INSERT INTO report_translations (report_name, locale, key, value) VALUES (?, ?, ?, ?)",
+			translations.ReportName, translations.Locale, key, value,
+		)
+		if errOpt.IsSome() {
+			return errOpt
+		}
+	}
+
+	return safego.None[error]()
+}
+
+// DeleteTranslations deletes the translation table of a report in a locale.
+func DeleteTranslations(internalDb *datasource.DataSource, reportName string, locale string) safego.Option[error] {
+	return (*internalDb).Exec("DELETE FROM report_translations WHERE report_name = ? AND locale = ?", reportName, locale)
+}
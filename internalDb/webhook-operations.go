@@ -0,0 +1,51 @@
+package internalDb
+
+import (
+	"database/sql"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+)
+
+// InsertWebhookDelivery records a webhook delivery attempt.
+func InsertWebhookDelivery(internalDb *datasource.DataSource, delivery types.WebhookDelivery) safego.Option[error] {
+	errorMessage := sql.NullString{String: delivery.Error, Valid: delivery.Error != ""}
+
+	return (*internalDb).Exec(
+		"INSERT INTO webhook_deliveries (render_id, url, attempt, status_code, error, canceled, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		delivery.RenderId, delivery.Url, delivery.Attempt, delivery.StatusCode, errorMessage, delivery.Canceled, delivery.CreatedAt,
+	)
+}
+
+// ListWebhookDeliveries returns the delivery attempts of a render, or of every render if renderId is empty.
+func ListWebhookDeliveries(internalDb *datasource.DataSource, renderId string) ([]types.WebhookDelivery, safego.Option[error]) {
+	query := "SELECT id, render_id, url, attempt, status_code, error, canceled, created_at FROM webhook_deliveries"
+	args := []any{}
+	if renderId != "" {
+		query += " WHERE render_id = ?"
+		args = append(args, renderId)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, errOpt := (*internalDb).Query(query, args...)
+	if errOpt.IsSome() {
+		return []types.WebhookDelivery{}, errOpt
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		delivery := types.WebhookDelivery{}
+		errorMessage := sql.NullString{}
+
+		err := rows.Scan(&delivery.ID, &delivery.RenderId, &delivery.Url, &delivery.Attempt, &delivery.StatusCode, &errorMessage, &delivery.Canceled, &delivery.CreatedAt)
+		if err != nil {
+			return []types.WebhookDelivery{}, safego.Some(err)
+		}
+		delivery.Error = errorMessage.String
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, safego.None[error]()
+}
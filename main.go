@@ -2,6 +2,9 @@
 
 package main
 
+// The descriptions of the routes hold handlebars, the docs are templated with other delimiters.
+//go:generate swag init -o docs --templateDelims "[[,]]"
+
 import "github.com/okira-e/goreports/cmd"
 
 func main() {
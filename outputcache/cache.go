@@ -0,0 +1,215 @@
+package outputcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"sync"
+	"time"
+)
+
+// The defaults of the config.
+const (
+	DefaultMaxSizeBytes         = 256 << 20
+	DefaultMaxDocumentSizeBytes = 16 << 20
+	DefaultTtl                  = 5 * time.Minute
+)
+
+// Dependencies are the inputs of a document that are only known once it is rendered, e.g. the sub-reports and the
+// assets it embeds, and their checksum at the time.
+type Dependencies struct {
+	Names    []string
+	Checksum string
+}
+
+// entry is a cached document.
+type entry struct {
+	key          string
+	reportName   string
+	document     []byte
+	etag         string
+	dependencies Dependencies
+	expiresAt    time.Time
+}
+
+// Cache holds rendered documents in memory by the hash of everything they are rendered from, and evicts the least
+// recently used ones past its size limit.
+type Cache struct {
+	maxSizeBytes         int64
+	maxDocumentSizeBytes int64
+	defaultTtl           time.Duration
+	reportTtls           map[string]time.Duration
+
+	mutex sync.Mutex
+	size  int64
+	// recency holds the entries from the most recently used to the least recently used.
+	recency   *list.List
+	entries   map[string]*list.Element
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+// NewCacheFromConfig builds the cache described by the config.
+// It returns a None cache if caching is disabled.
+func NewCacheFromConfig(config types.OutputCacheConfig) safego.Option[*Cache] {
+	if !config.Enabled {
+		return safego.None[*Cache]()
+	}
+
+	return safego.Some(NewCache(config))
+}
+
+// NewCache returns a new Cache instance.
+func NewCache(config types.OutputCacheConfig) *Cache {
+	cache := &Cache{
+		maxSizeBytes:         config.MaxSizeBytes,
+		maxDocumentSizeBytes: config.MaxDocumentSizeBytes,
+		defaultTtl:           DefaultTtl,
+		reportTtls:           map[string]time.Duration{},
+		recency:              list.New(),
+		entries:              map[string]*list.Element{},
+	}
+	if cache.maxSizeBytes <= 0 {
+		cache.maxSizeBytes = DefaultMaxSizeBytes
+	}
+	if cache.maxDocumentSizeBytes <= 0 {
+		cache.maxDocumentSizeBytes = DefaultMaxDocumentSizeBytes
+	}
+	if config.TtlSeconds > 0 {
+		cache.defaultTtl = time.Duration(config.TtlSeconds) * time.Second
+	}
+	for reportName, seconds := range config.ReportTtlSeconds {
+		cache.reportTtls[reportName] = time.Duration(seconds) * time.Second
+	}
+
+	return cache
+}
+
+// ETagOf returns the entity tag of a document, the hash of its content.
+func ETagOf(document []byte) string {
+	hash := sha256.Sum256(document)
+
+	return `"` + hex.EncodeToString(hash[:]) + `"`
+}
+
+// TtlOf returns how long the documents of the report are reused. 0 means they aren't cached.
+func (self *Cache) TtlOf(reportName string) time.Duration {
+	if ttl, ok := self.reportTtls[reportName]; ok {
+		return ttl
+	}
+
+	return self.defaultTtl
+}
+
+// Get returns the cached document and its entity tag, or false if there is none, it expired or isCurrent tells its
+// dependencies changed since it was rendered.
+func (self *Cache) Get(key string, isCurrent func(Dependencies) bool) ([]byte, string, bool) {
+	self.mutex.Lock()
+	element, ok := self.entries[key]
+	var cached *entry
+	if ok {
+		cached = element.Value.(*entry)
+	}
+	self.mutex.Unlock()
+
+	// The dependencies are checked without the lock, it takes queries to the internal database.
+	current := ok && time.Now().Before(cached.expiresAt) && (len(cached.dependencies.Names) == 0 || isCurrent(cached.dependencies))
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	// The entry may have been replaced or removed while its dependencies were checked.
+	element, ok = self.entries[key]
+	if ok && element.Value.(*entry) != cached {
+		ok = false
+	} else if ok && !current {
+		self.remove(element)
+		ok = false
+	}
+	if !ok {
+		self.misses++
+		return nil, "", false
+	}
+
+	self.hits++
+	self.recency.MoveToFront(element)
+
+	return cached.document, cached.etag, true
+}
+
+// Set caches the document of the report for the given TTL, unless it is larger than the largest document cached.
+func (self *Cache) Set(key string, reportName string, document []byte, etag string, dependencies Dependencies, ttl time.Duration) {
+	if int64(len(document)) > self.maxDocumentSizeBytes || ttl <= 0 {
+		return
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if element, ok := self.entries[key]; ok {
+		self.remove(element)
+	}
+
+	self.entries[key] = self.recency.PushFront(&entry{
+		key:          key,
+		reportName:   reportName,
+		document:     document,
+		etag:         etag,
+		dependencies: dependencies,
+		expiresAt:    time.Now().Add(ttl),
+	})
+	self.size += int64(len(document))
+
+	for self.size > self.maxSizeBytes {
+		self.remove(self.recency.Back())
+		self.evictions++
+	}
+}
+
+// InvalidateReport deletes the cached documents of the report, or every document if the name is empty, and returns
+// how many there were.
+func (self *Cache) InvalidateReport(reportName string) int {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	deleted := 0
+	for element := self.recency.Front(); element != nil; {
+		next := element.Next()
+		if reportName == "" || element.Value.(*entry).reportName == reportName {
+			self.remove(element)
+			deleted++
+		}
+		element = next
+	}
+
+	return deleted
+}
+
+// Stats returns the lookups counted since the cache was created.
+func (self *Cache) Stats() types.OutputCacheStats {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	stats := types.OutputCacheStats{
+		Entries:   self.recency.Len(),
+		SizeBytes: self.size,
+		Hits:      self.hits,
+		Misses:    self.misses,
+		Evictions: self.evictions,
+	}
+	if self.hits+self.misses > 0 {
+		stats.HitRatio = float64(self.hits) / float64(self.hits+self.misses)
+	}
+
+	return stats
+}
+
+// remove removes the element from the list and the index. The mutex must be held.
+func (self *Cache) remove(element *list.Element) {
+	cached := self.recency.Remove(element).(*entry)
+	delete(self.entries, cached.key)
+	self.size -= int64(len(cached.document))
+}
@@ -0,0 +1,28 @@
+package querycache
+
+import (
+	"github.com/okira-e/goreports/safego"
+	"time"
+)
+
+// Entry is a cached query result. The results are cached per report, so a report can be invalidated on its own.
+type Entry struct {
+	ReportName string
+	Key        string
+	Value      []byte
+	ExpiresAt  time.Time
+}
+
+// Backend stores the cached query results.
+type Backend interface {
+	// Get returns the value of the entry, or false if there is none or it expired by now.
+	Get(reportName string, key string, now time.Time) ([]byte, bool, safego.Option[error])
+	Set(entry Entry) safego.Option[error]
+	// DeleteReport deletes the entries of the report and returns how many there were.
+	DeleteReport(reportName string) (int, safego.Option[error])
+	// Clear deletes every entry and returns how many there were.
+	Clear() (int, safego.Option[error])
+	Len() (int, safego.Option[error])
+	// Close releases the resources of the backend, it isn't used afterwards.
+	Close() safego.Option[error]
+}
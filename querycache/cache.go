@@ -0,0 +1,200 @@
+package querycache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTtl is how long a query result is reused when the config doesn't say.
+const DefaultTtl = time.Minute
+
+// ttlHintRegex matches the /* cache_ttl=<seconds> */ comment setting the TTL of a query.
+var ttlHintRegex = regexp.MustCompile(`/\*\s*cache_ttl\s*=\s*(\d+)\s*\*/`)
+
+// Cache caches the results of the report queries by data source, normalized SQL and bound arguments, and counts the
+// hits and misses of every report.
+type Cache struct {
+	backend     Backend
+	backendName string
+	// dataSource identifies the database the queries run against, so results of another database are never reused.
+	dataSource string
+	defaultTtl time.Duration
+	reportTtls map[string]time.Duration
+
+	mutex sync.Mutex
+	stats map[string]*types.QueryCacheReportStats
+}
+
+// NewCache returns a new Cache instance.
+func NewCache(backend Backend, backendName string, dataSource string, config types.QueryCacheConfig) *Cache {
+	defaultTtl := DefaultTtl
+	if config.TtlSeconds > 0 {
+		defaultTtl = time.Duration(config.TtlSeconds) * time.Second
+	}
+
+	reportTtls := map[string]time.Duration{}
+	for reportName, seconds := range config.ReportTtlSeconds {
+		reportTtls[reportName] = time.Duration(seconds) * time.Second
+	}
+
+	return &Cache{
+		backend:     backend,
+		backendName: backendName,
+		dataSource:  dataSource,
+		defaultTtl:  defaultTtl,
+		reportTtls:  reportTtls,
+		stats:       map[string]*types.QueryCacheReportStats{},
+	}
+}
+
+// Key returns the key of the result of the query with the given arguments.
+func (self *Cache) Key(query string, args ...any) string {
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		encodedArgs = []byte(fmt.Sprintf("%#v", args))
+	}
+
+	hash := sha256.Sum256([]byte(self.dataSource + "\x00" + NormalizeQuery(query) + "\x00" + string(encodedArgs)))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// TtlOf returns how long the result of the query of the report is reused: the /* cache_ttl=<seconds> */ comment of the
+// query, or else the TTL of the report, or else the default one. 0 means it isn't cached.
+func (self *Cache) TtlOf(reportName string, query string) time.Duration {
+	if hint := ttlHintRegex.FindStringSubmatch(query); hint != nil {
+		seconds, _ := strconv.Atoi(hint[1])
+		return time.Duration(seconds) * time.Second
+	}
+
+	if ttl, ok := self.reportTtls[reportName]; ok {
+		return ttl
+	}
+
+	return self.defaultTtl
+}
+
+// Get returns the cached result of the query of the report and counts the lookup.
+func (self *Cache) Get(reportName string, key string) ([]byte, bool, safego.Option[error]) {
+	value, found, errOpt := self.backend.Get(reportName, key, time.Now())
+	if errOpt.IsSome() {
+		return nil, false, errOpt
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	stats, ok := self.stats[reportName]
+	if !ok {
+		stats = &types.QueryCacheReportStats{}
+		self.stats[reportName] = stats
+	}
+	if found {
+		stats.Hits++
+	} else {
+		stats.Misses++
+	}
+
+	return value, found, safego.None[error]()
+}
+
+// Set caches the result of the query of the report for the given TTL.
+func (self *Cache) Set(reportName string, key string, value []byte, ttl time.Duration) safego.Option[error] {
+	return self.backend.Set(Entry{
+		ReportName: reportName,
+		Key:        key,
+		Value:      value,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+}
+
+// InvalidateReport deletes the cached results of the queries of the report, or of every report if the name is empty,
+// and returns how many there were.
+func (self *Cache) InvalidateReport(reportName string) (int, safego.Option[error]) {
+	if reportName == "" {
+		return self.backend.Clear()
+	}
+
+	return self.backend.DeleteReport(reportName)
+}
+
+// Close closes the backend of the cache, it isn't used afterwards.
+func (self *Cache) Close() safego.Option[error] {
+	return self.backend.Close()
+}
+
+// Stats returns the lookups counted since the cache was created.
+func (self *Cache) Stats() (types.QueryCacheStats, safego.Option[error]) {
+	entries, errOpt := self.backend.Len()
+	if errOpt.IsSome() {
+		return types.QueryCacheStats{}, errOpt
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	stats := types.QueryCacheStats{
+		Backend: self.backendName,
+		Entries: entries,
+		Reports: map[string]types.QueryCacheReportStats{},
+	}
+	for reportName, reportStats := range self.stats {
+		stats.Hits += reportStats.Hits
+		stats.Misses += reportStats.Misses
+		stats.Reports[reportName] = types.QueryCacheReportStats{
+			Hits:     reportStats.Hits,
+			Misses:   reportStats.Misses,
+			HitRatio: hitRatio(reportStats.Hits, reportStats.Misses),
+		}
+	}
+	stats.HitRatio = hitRatio(stats.Hits, stats.Misses)
+
+	return stats, safego.None[error]()
+}
+
+// NormalizeQuery collapses the whitespace of the query outside of its quoted literals and identifiers and removes its
+// trailing semicolon, so formatting differences don't make different keys.
+func NormalizeQuery(query string) string {
+	builder := strings.Builder{}
+	var quote rune
+	pendingSpace := false
+
+	for _, char := range strings.TrimSpace(query) {
+		if quote == 0 && (char == ' ' || char == '\t' || char == '\n' || char == '\r') {
+			pendingSpace = true
+			continue
+		}
+
+		if pendingSpace {
+			builder.WriteRune(' ')
+			pendingSpace = false
+		}
+		builder.WriteRune(char)
+
+		switch {
+		case quote == 0 && (char == '\'' || char == '"' || char == '`'):
+			quote = char
+		case char == quote:
+			quote = 0
+		}
+	}
+
+	return strings.TrimSpace(strings.TrimSuffix(builder.String(), ";"))
+}
+
+func hitRatio(hits int64, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+
+	return float64(hits) / float64(hits+misses)
+}
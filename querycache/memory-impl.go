@@ -0,0 +1,118 @@
+package querycache
+
+import (
+	"container/list"
+	"github.com/okira-e/goreports/safego"
+	"sync"
+	"time"
+)
+
+// MemoryBackend holds the entries in memory and evicts the least recently used ones past its capacity.
+type MemoryBackend struct {
+	mutex      sync.Mutex
+	maxEntries int
+	// recency holds the entries from the most recently used to the least recently used.
+	recency *list.List
+	entries map[string]*list.Element
+}
+
+// NewMemoryBackend returns a new MemoryBackend instance.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	return &MemoryBackend{
+		maxEntries: maxEntries,
+		recency:    list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+// Get returns the value of the entry, or false if there is none or it expired by now.
+func (self *MemoryBackend) Get(reportName string, key string, now time.Time) ([]byte, bool, safego.Option[error]) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	element, ok := self.entries[memoryKey(reportName, key)]
+	if !ok {
+		return nil, false, safego.None[error]()
+	}
+
+	entry := element.Value.(Entry)
+	if !now.Before(entry.ExpiresAt) {
+		self.remove(element)
+		return nil, false, safego.None[error]()
+	}
+	self.recency.MoveToFront(element)
+
+	return entry.Value, true, safego.None[error]()
+}
+
+// Set stores the entry, evicting the least recently used entries past the capacity.
+func (self *MemoryBackend) Set(entry Entry) safego.Option[error] {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if element, ok := self.entries[memoryKey(entry.ReportName, entry.Key)]; ok {
+		element.Value = entry
+		self.recency.MoveToFront(element)
+		return safego.None[error]()
+	}
+
+	self.entries[memoryKey(entry.ReportName, entry.Key)] = self.recency.PushFront(entry)
+	for self.recency.Len() > self.maxEntries {
+		self.remove(self.recency.Back())
+	}
+
+	return safego.None[error]()
+}
+
+// DeleteReport deletes the entries of the report and returns how many there were.
+func (self *MemoryBackend) DeleteReport(reportName string) (int, safego.Option[error]) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	deleted := 0
+	for element := self.recency.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(Entry).ReportName == reportName {
+			self.remove(element)
+			deleted++
+		}
+		element = next
+	}
+
+	return deleted, safego.None[error]()
+}
+
+// Clear deletes every entry and returns how many there were.
+func (self *MemoryBackend) Clear() (int, safego.Option[error]) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	deleted := self.recency.Len()
+	self.recency.Init()
+	self.entries = map[string]*list.Element{}
+
+	return deleted, safego.None[error]()
+}
+
+// Len returns the number of entries.
+func (self *MemoryBackend) Len() (int, safego.Option[error]) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.recency.Len(), safego.None[error]()
+}
+
+// Close does nothing, the entries are dropped with the backend.
+func (self *MemoryBackend) Close() safego.Option[error] {
+	return safego.None[error]()
+}
+
+// remove removes the element from the list and the index. The mutex must be held.
+func (self *MemoryBackend) remove(element *list.Element) {
+	entry := self.recency.Remove(element).(Entry)
+	delete(self.entries, memoryKey(entry.ReportName, entry.Key))
+}
+
+func memoryKey(reportName string, key string) string {
+	return reportName + "\x00" + key
+}
@@ -0,0 +1,41 @@
+package querycache
+
+import (
+	"errors"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"path/filepath"
+)
+
+// DefaultMaxEntries is how many results the memory backend holds when the config doesn't say.
+const DefaultMaxEntries = 1000
+
+// NewCacheFromConfig builds the cache described by the config for the queries of the given data source.
+// It returns a None cache if caching is disabled.
+func NewCacheFromConfig(config types.QueryCacheConfig, dataSource string, dataDir string) (safego.Option[*Cache], safego.Option[error]) {
+	switch config.Backend {
+	case "":
+		return safego.None[*Cache](), safego.None[error]()
+	case "memory":
+		maxEntries := config.MaxEntries
+		if maxEntries <= 0 {
+			maxEntries = DefaultMaxEntries
+		}
+
+		return safego.Some(NewCache(NewMemoryBackend(maxEntries), config.Backend, dataSource, config)), safego.None[error]()
+	case "sqlite":
+		path := config.Path
+		if path == "" {
+			path = filepath.Join(dataDir, "query-cache.db")
+		}
+
+		backend, errOpt := NewSqliteBackend(path)
+		if errOpt.IsSome() {
+			return safego.None[*Cache](), errOpt
+		}
+
+		return safego.Some(NewCache(backend, config.Backend, dataSource, config)), safego.None[error]()
+	}
+
+	return safego.None[*Cache](), safego.Some(errors.New("unsupported query cache backend: " + config.Backend))
+}
@@ -0,0 +1,122 @@
+package querycache
+
+import (
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"time"
+)
+
+const createQueryCacheTableSQL = `CREATE TABLE IF NOT EXISTS query_cache (
+	report_name VARCHAR(255) NOT NULL,
+	key VARCHAR(64) NOT NULL,
+	value BLOB NOT NULL,
+	expires_at INTEGER NOT NULL,
+	PRIMARY KEY (report_name, key)
+);`
+
+// SqliteBackend keeps the entries in a SQLite database, so they survive restarts and are shared by the processes
+// using the same file.
+type SqliteBackend struct {
+	db datasource.DataSource
+}
+
+// NewSqliteBackend opens the database file, creating it and its table if needed.
+func NewSqliteBackend(path string) (*SqliteBackend, safego.Option[error]) {
+	var db datasource.DataSource
+	db = datasource.NewSqliteDb(path)
+
+	errOpt := db.Connect()
+	if errOpt.IsSome() {
+		return nil, errOpt
+	}
+
+	errOpt = db.Exec(createQueryCacheTableSQL)
+	if errOpt.IsSome() {
+		return nil, errOpt
+	}
+
+	return &SqliteBackend{db: db}, safego.None[error]()
+}
+
+// Get returns the value of the entry, or false if there is none or it expired by now.
+func (self *SqliteBackend) Get(reportName string, key string, now time.Time) ([]byte, bool, safego.Option[error]) {
+	rows, errOpt := self.db.Query("SELECT value FROM query_cache WHERE report_name = ? AND key = ? AND expires_at > ?", reportName, key, now.UnixMilli())
+	if errOpt.IsSome() {
+		return nil, false, errOpt
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, false, safego.None[error]()
+	}
+
+	var value []byte
+	err := rows.Scan(&value)
+	if err != nil {
+		return nil, false, safego.Some(err)
+	}
+
+	return value, true, safego.None[error]()
+}
+
+// Set stores the entry and deletes the expired ones.
+func (self *SqliteBackend) Set(entry Entry) safego.Option[error] {
+	errOpt := self.db.Exec(
+		"INSERT INTO query_cache (report_name, key, value, expires_at) VALUES (?, ?, ?, ?) "+
+			"ON CONFLICT (report_name, key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at",
+		entry.ReportName, entry.Key, entry.Value, entry.ExpiresAt.UnixMilli(),
+	)
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	return self.db.Exec("DELETE FROM query_cache WHERE expires_at <= ?", time.Now().UnixMilli())
+}
+
+// DeleteReport deletes the entries of the report and returns how many there were.
+func (self *SqliteBackend) DeleteReport(reportName string) (int, safego.Option[error]) {
+	count, errOpt := self.count("SELECT COUNT(*) FROM query_cache WHERE report_name = ?", reportName)
+	if errOpt.IsSome() {
+		return 0, errOpt
+	}
+
+	return count, self.db.Exec("DELETE FROM query_cache WHERE report_name = ?", reportName)
+}
+
+// Clear deletes every entry and returns how many there were.
+func (self *SqliteBackend) Clear() (int, safego.Option[error]) {
+	count, errOpt := self.Len()
+	if errOpt.IsSome() {
+		return 0, errOpt
+	}
+
+	return count, self.db.Exec("DELETE FROM query_cache")
+}
+
+// Len returns the number of entries.
+func (self *SqliteBackend) Len() (int, safego.Option[error]) {
+	return self.count("SELECT COUNT(*) FROM query_cache")
+}
+
+// Close closes the database file.
+func (self *SqliteBackend) Close() safego.Option[error] {
+	return self.db.Disconnect()
+}
+
+func (self *SqliteBackend) count(query string, args ...any) (int, safego.Option[error]) {
+	rows, errOpt := self.db.Query(query, args...)
+	if errOpt.IsSome() {
+		return 0, errOpt
+	}
+	defer rows.Close()
+
+	count := 0
+	if rows.Next() {
+		err := rows.Scan(&count)
+		if err != nil {
+			return 0, safego.Some(err)
+		}
+	}
+
+	return count, safego.None[error]()
+}
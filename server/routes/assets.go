@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/assets"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"path"
+)
+
+var AssetConfig types.AssetConfig
+
+// AssetsRouter sets up the routes for report assets.
+// This function is called from server/routes/index.go.
+func AssetsRouter(app fiber.Router) {
+	const controllerName = "/report/assets"
+
+	app.Get(controllerName, listAssets)
+
+	app.Get(controllerName+"/download", downloadAsset)
+
+	app.Post(controllerName+"/upload", uploadAsset)
+
+	app.Delete(controllerName+"/delete", deleteAsset)
+}
+
+// @Summary List assets
+// @Description List the assets of a report, or the global assets if reportName is omitted
+// @Tags assets
+// @Produce json
+// @Param reportName query string false "The name of the report"
+// @Success 200 {array} types.Asset
+// @Router /report/assets [get]
+func listAssets(ctx *fiber.Ctx) error {
+	reportName := safego.Some(ctx.Query("reportName"))
+
+	storedAssets, errOpt := internalDb.ListAssets(InternalDb, reportName)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(storedAssets)
+}
+
+// @Summary Download an asset
+// @Description Download an asset of a report, or a global asset if reportName is omitted
+// @Tags assets
+// @Produce octet-stream
+// @Param name query string true "The name of the asset"
+// @Param reportName query string false "The name of the report"
+// @Success 200 "OK"
+// @Router /report/assets/download [get]
+func downloadAsset(ctx *fiber.Ctx) error {
+	name := ctx.Query("name")
+	if name == "" {
+		return ctx.Status(400).SendString("name is required.")
+	}
+
+	assetOpt, errOpt := internalDb.GetAsset(InternalDb, ctx.Query("reportName"), name)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+	if assetOpt.IsNone() {
+		return ctx.Status(404).SendString("asset was not found.")
+	}
+	asset := assetOpt.Unwrap()
+
+	ctx.Set(fiber.HeaderContentType, asset.ContentType)
+	ctx.Set(fiber.HeaderETag, `"`+asset.Checksum+`"`)
+	ctx.Attachment(path.Base(asset.Name))
+
+	return ctx.Status(200).Send(asset.Data)
+}
+
+// @Summary Upload an asset
+// @Description Store an image, font or stylesheet for a report, or for every report if reportName is omitted.
+// @Description The request body is the content of the file. An asset with the same name is replaced.
+// @Description Templates reference it with {{asset "name"}} or asset://name.
+// @Tags assets
+// @Accept octet-stream
+// @Produce json
+// @Param name query string true "The name of the asset, e.g. logo.png or fonts/inter.woff2"
+// @Param reportName query string false "The name of the report"
+// @Success 200 {object} types.Asset
+// @Router /report/assets/upload [post]
+func uploadAsset(ctx *fiber.Ctx) error {
+	reportName := ctx.Query("reportName")
+
+	if reportName != "" {
+		reportOpt, errOpt := findReport(reportName)
+		if errOpt.IsSome() {
+			return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+		}
+		if reportOpt.IsNone() {
+			return ctx.Status(404).SendString("report was not found.")
+		}
+	}
+
+	// The body is only valid until the handler returns.
+	data := append([]byte{}, ctx.Body()...)
+
+	asset, errOpt := assets.NewAsset(reportName, ctx.Query("name"), data, AssetConfig.MaxSizeBytes)
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+
+	errOpt = internalDb.SaveAsset(InternalDb, asset)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(asset)
+}
+
+// @Summary Delete an asset
+// @Description Delete an asset of a report, or a global asset if reportName is omitted
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param name body string true "The name of the asset"
+// @Param reportName body string false "The name of the report"
+// @Success 200 "OK"
+// @Router /report/assets/delete [delete]
+func deleteAsset(ctx *fiber.Ctx) error {
+	var body struct {
+		Name       string `json:"name"`
+		ReportName string `json:"reportName"`
+	}
+
+	utils.ParseRequestBody(ctx, &body)
+
+	if body.Name == "" {
+		return ctx.Status(400).SendString("name is required.")
+	}
+
+	assetOpt, errOpt := internalDb.GetAsset(InternalDb, body.ReportName, body.Name)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+	if assetOpt.IsNone() {
+		return ctx.Status(404).SendString("asset was not found.")
+	}
+
+	errOpt = internalDb.DeleteAsset(InternalDb, body.ReportName, body.Name)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(map[string]string{
+		"message": "Asset deleted successfully.",
+	})
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// assetUploadPath is the route of the asset uploads, whose body is bounded by the largest asset instead.
+const assetUploadPath = "/report/assets/upload"
+
+// LimitBody rejects the requests whose body is larger than the limit, the asset uploads aside. The server reads the
+// bodies up to the larger of the limit and the largest asset, so that the uploads get through.
+func LimitBody(limit int) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if len(ctx.Request().Body()) <= limit || isAssetUpload(ctx.Path()) {
+			return ctx.Next()
+		}
+
+		return ctx.Status(fiber.StatusRequestEntityTooLarge).SendString(fmt.Sprintf("the request body is larger than %d bytes.", limit))
+	}
+}
+
+// isAssetUpload reports whether the path is the route of the asset uploads, matched the way the router does: ignoring
+// the case and a trailing slash.
+func isAssetUpload(requestPath string) bool {
+	return strings.EqualFold(strings.TrimSuffix(requestPath, "/"), BasePath+assetUploadPath)
+}
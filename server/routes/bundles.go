@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/bundle"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/types"
+	"strings"
+)
+
+// BundlesRouter sets up the routes for exporting and importing report bundles.
+// This function is called from server/routes/index.go.
+func BundlesRouter(app fiber.Router) {
+	const controllerName = "/report"
+
+	app.Get(controllerName+"/export", exportReports)
+
+	app.Post(controllerName+"/import", importReports)
+}
+
+// @Summary Export reports
+// @Description Export reports as a zip bundle holding a manifest and the templates of every report
+// @Tags bundles
+// @Produce application/zip
+// @Param names query string false "Comma-separated names of the reports to export. Defaults to every report"
+// @Param manifestFormat query string false "The format of the manifest: yaml (default) or json"
+// @Success 200 "OK"
+// @Router /report/export [get]
+func exportReports(ctx *fiber.Ctx) error {
+	reports, errOpt := listAllReports()
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	if names := ctx.Query("names"); names != "" {
+		filteredReports := []types.Report{}
+		for _, name := range strings.Split(names, ",") {
+			found := false
+			for _, report := range reports {
+				if report.Name == strings.TrimSpace(name) {
+					filteredReports = append(filteredReports, report)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return ctx.Status(404).SendString("report " + name + " was not found.")
+			}
+		}
+		reports = filteredReports
+	}
+
+	files, errOpt := bundle.Build(reports, ctx.Query("manifestFormat", "yaml"))
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+
+	data, errOpt := bundle.ToZip(files)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	ctx.Attachment("reports.zip")
+
+	return ctx.Status(200).Send(data)
+}
+
+// @Summary Import reports
+// @Description Import the reports of a zip bundle made by /report/export.
+// @Description The reports to create or update are validated first, the invalid ones are reported and not written.
+// @Tags bundles
+// @Accept application/zip
+// @Produce json
+// @Param mode query string false "create-only (default) leaves existing reports untouched, overwrite replaces them"
+// @Param dryRun query boolean false "Describe what would change, with a diff of the templates, without writing anything"
+// @Success 200 {array} types.ImportResult
+// @Router /report/import [post]
+func importReports(ctx *fiber.Ctx) error {
+	files, errOpt := bundle.FromZip(ctx.Body())
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+
+	reports, errOpt := bundle.Parse(files)
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+
+	for _, report := range reports {
+		if isReadOnlyReport(report.Name) {
+			return ctx.Status(403).SendString("The report " + report.Name + " is loaded from the reports directory and is read-only.")
+		}
+	}
+
+	validate := func(report types.Report) types.ValidationResult {
+		return core.ValidateReport(report, ExternalDb, CheckStatements)
+	}
+	results, errOpt := bundle.Import(InternalDb, reports, ctx.Query("mode", bundle.ModeCreateOnly), ctx.QueryBool("dryRun"), validate)
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(results)
+}
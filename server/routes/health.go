@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/health"
+	"github.com/okira-e/goreports/types"
+)
+
+var HealthChecker *health.Checker
+
+// HealthRouter sets up the health check route of the load balancers.
+// This function is called from server/routes/index.go.
+func HealthRouter(app fiber.Router) {
+	app.Get("/health", getHealth)
+}
+
+// @Summary Get the health of the server
+// @Description Report the outcome of the last check of every data source, the state of their connection pools and
+// @Description whether wkhtmltopdf is found. The status is 503 when any of them is down.
+// @Tags health
+// @Produce json
+// @Success 200 {object} types.HealthStatus
+// @Failure 503 {object} types.HealthStatus
+// @Router /health [get]
+func getHealth(ctx *fiber.Ctx) error {
+	status := types.HealthStatus{
+		Status:      health.StatusUp,
+		DataSources: HealthChecker.DataSources(),
+		Renderer:    types.RendererHealth{Status: health.StatusUp},
+	}
+
+	errOpt := core.CheckPDFGenerator()
+	if errOpt.IsSome() {
+		status.Renderer = types.RendererHealth{Status: health.StatusDown, Error: errOpt.Unwrap().Error()}
+	}
+
+	if !HealthChecker.IsUp() || status.Renderer.Status != health.StatusUp {
+		status.Status = health.StatusDown
+		return ctx.Status(503).JSON(status)
+	}
+
+	return ctx.Status(200).JSON(status)
+}
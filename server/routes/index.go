@@ -2,13 +2,36 @@ package routes
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/bundle"
 	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
 )
 
 var InternalDb *datasource.DataSource
 var ExternalDb *datasource.DataSource
 
-func GlobalRouter(app *fiber.App) {
+// QueryLimits bound the queries of the reports against the external database.
+var QueryLimits types.QueryLimitsConfig
+
+// AllowWrites runs the queries of the reports outside of read-only transactions.
+var AllowWrites bool
+
+// CheckStatements rejects the reports whose queries aren't a single SELECT statement.
+var CheckStatements bool
+
+// ReportsDirectory holds the read-only reports loaded with `goreports start --reports-dir`.
+var ReportsDirectory safego.Option[*bundle.Directory]
+
+func GlobalRouter(app fiber.Router) {
 	ReportsRouter(app)
+	OutputsRouter(app)
+	BundlesRouter(app)
+	WebhooksRouter(app)
+	TranslationsRouter(app)
+	AssetsRouter(app)
+	PartialsRouter(app)
+	QueryCacheRouter(app)
+	HealthRouter(app)
 	SwaggerRouter(app)
 }
@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"context"
+	"sync"
+)
+
+// shutdownCtx is canceled once the shutdown deadline is exceeded, to stop the renders that are still running.
+var shutdownCtx, cancelShutdownCtx = context.WithCancel(context.Background())
+
+// deliveriesCtx is canceled once the canceled renders were given time to notify their callback URL, to stop the
+// deliveries that are still retrying.
+var deliveriesCtx, cancelDeliveriesCtx = context.WithCancel(context.Background())
+
+// backgroundWork counts the background renders that haven't notified their callback URL yet.
+var backgroundWork sync.WaitGroup
+
+// draining is set once the server shuts down, the background renders are refused from then on. It is guarded by
+// drainingMutex so that no render is added to backgroundWork while it is waited for.
+var draining bool
+var drainingMutex sync.Mutex
+
+// startBackgroundWork runs the work in its own goroutine. It returns false, without running it, if the server is
+// shutting down.
+func startBackgroundWork(work func()) bool {
+	drainingMutex.Lock()
+	defer drainingMutex.Unlock()
+
+	if draining {
+		return false
+	}
+
+	backgroundWork.Add(1)
+	go func() {
+		defer backgroundWork.Done()
+		work()
+	}()
+
+	return true
+}
+
+// DrainBackgroundWork refuses the new background renders and waits for the running ones to archive their output and
+// notify their callback URL. It returns false if the context is done first.
+func DrainBackgroundWork(ctx context.Context) bool {
+	drainingMutex.Lock()
+	draining = true
+	drainingMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		backgroundWork.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// CancelRenders cancels the renders that are still running, those of the requests included. The background renders
+// then notify their callback URL that they were canceled.
+func CancelRenders() {
+	cancelShutdownCtx()
+}
+
+// CancelDeliveries cancels the callback deliveries that are still running. They log their canceled attempt and return.
+func CancelDeliveries() {
+	cancelDeliveriesCtx()
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/storage"
+	"github.com/okira-e/goreports/types"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+var OutputStorage safego.Option[storage.Storage]
+var StorageConfig types.StorageConfig
+
+// OutputsRouter sets up the routes for archived outputs.
+// This function is called from server/routes/index.go.
+func OutputsRouter(app fiber.Router) {
+	const controllerName = "/report/outputs"
+
+	app.Get(controllerName, listOutputs)
+
+	app.Get(controllerName+"/download", downloadOutput)
+
+	app.Post(controllerName+"/purge", purgeOutputs)
+}
+
+// @Summary List archived outputs
+// @Description List the rendered documents archived in the output storage, newest first
+// @Tags outputs
+// @Produce json
+// @Param prefix query string false "Only list the outputs whose key starts with this prefix"
+// @Success 200 {array} types.StoredOutput
+// @Router /report/outputs [get]
+func listOutputs(ctx *fiber.Ctx) error {
+	if OutputStorage.IsNone() {
+		return ctx.Status(404).SendString("The output storage is not configured.")
+	}
+
+	outputs, errOpt := OutputStorage.Unwrap().List(ctx.Query("prefix"))
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].LastModified > outputs[j].LastModified
+	})
+
+	return ctx.Status(200).JSON(outputs)
+}
+
+// @Summary Download an archived output
+// @Description Download a rendered document from the output storage
+// @Tags outputs
+// @Produce octet-stream
+// @Param key query string true "The key of the output"
+// @Success 200 "OK"
+// @Failure 404 "The output was not found"
+// @Router /report/outputs/download [get]
+func downloadOutput(ctx *fiber.Ctx) error {
+	if OutputStorage.IsNone() {
+		return ctx.Status(404).SendString("The output storage is not configured.")
+	}
+
+	key := ctx.Query("key")
+	if key == "" {
+		return ctx.Status(400).SendString("key is required.")
+	}
+
+	data, errOpt := OutputStorage.Unwrap().Get(key)
+	if errOpt.IsSome() {
+		if errors.Is(errOpt.Unwrap(), fs.ErrNotExist) {
+			return ctx.Status(404).SendString("The output " + key + " was not found.")
+		}
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	ctx.Attachment(path.Base(key))
+
+	return ctx.Status(200).Send(data)
+}
+
+// @Summary Purge archived outputs
+// @Description Delete the archived outputs that break the configured retention rules
+// @Tags outputs
+// @Produce json
+// @Success 200 "OK"
+// @Router /report/outputs/purge [post]
+func purgeOutputs(ctx *fiber.Ctx) error {
+	if OutputStorage.IsNone() {
+		return ctx.Status(404).SendString("The output storage is not configured.")
+	}
+
+	deletedKeys, errOpt := storage.ApplyRetention(OutputStorage.Unwrap(), StorageConfig.Retention, time.Now())
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(map[string]any{
+		"message":     "Outputs purged successfully.",
+		"deletedKeys": deletedKeys,
+	})
+}
+
+// archiveOutput stores a rendered PDF in the output storage and returns its key.
+func archiveOutput(reportName string, params map[string]any, data []byte) (string, safego.Option[error]) {
+	key, errOpt := storage.BuildOutputKey(StorageConfig.NamingTemplate, reportName, params, "pdf", time.Now())
+	if errOpt.IsSome() {
+		return "", errOpt
+	}
+
+	errOpt = OutputStorage.Unwrap().Put(key, data, "application/pdf")
+	if errOpt.IsSome() {
+		return "", errOpt
+	}
+
+	return key, safego.None[error]()
+}
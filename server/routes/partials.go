@@ -0,0 +1,175 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+)
+
+// PartialsRouter sets up the routes for the partials and layouts shared between reports.
+// This function is called from server/routes/index.go.
+func PartialsRouter(app fiber.Router) {
+	const controllerName = "/report/partials"
+
+	app.Get(controllerName, listPartials)
+
+	app.Get(controllerName+"/dependents", listPartialDependents)
+
+	app.Post(controllerName+"/save", savePartial)
+
+	app.Delete(controllerName+"/delete", deletePartial)
+}
+
+// @Summary List partials
+// @Description List the partials and layouts shared between reports
+// @Tags partials
+// @Produce json
+// @Success 200 {array} types.Partial
+// @Router /report/partials [get]
+func listPartials(ctx *fiber.Ctx) error {
+	partials, errOpt := internalDb.ListPartials(InternalDb)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(partials)
+}
+
+// @Summary List the reports using a partial
+// @Description List the reports that include or extend a partial, directly or through other partials
+// @Tags partials
+// @Produce json
+// @Param name query string true "The name of the partial"
+// @Success 200 {array} string
+// @Router /report/partials/dependents [get]
+func listPartialDependents(ctx *fiber.Ctx) error {
+	name := ctx.Query("name")
+	if name == "" {
+		return ctx.Status(400).SendString("name is required.")
+	}
+
+	dependents, errOpt := dependentReportsOf(name)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(dependents)
+}
+
+// @Summary Save a partial
+// @Description Create a partial or layout, or replace the one with the same name.
+// @Description Templates include it with {{> name}} or extend it with {{#extends "name"}}.
+// @Description The response lists the reports using it.
+// @Tags partials
+// @Accept json
+// @Produce json
+// @Param name body string true "The name of the partial"
+// @Param description body string false "The description of the partial"
+// @Param `body` body string true "The template of the partial"
+// @Success 200 "OK"
+// @Router /report/partials/save [post]
+func savePartial(ctx *fiber.Ctx) error {
+	partial := types.Partial{}
+
+	utils.ParseRequestBody(ctx, &partial)
+
+	if partial.Name == "" || partial.Body == "" {
+		return ctx.Status(400).SendString("name and body are required.")
+	}
+
+	partials, errOpt := internalDb.ListPartials(InternalDb)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	errOpt = core.ValidatePartial(partial, partials)
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+
+	partial.CreatedAt = utils.GetTimestamp()
+	partial.UpdatedAt = partial.CreatedAt
+
+	errOpt = internalDb.SavePartial(InternalDb, partial)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	dependents, errOpt := dependentReportsOf(partial.Name)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(map[string]any{
+		"message":          "Partial saved successfully.",
+		"dependentReports": dependents,
+	})
+}
+
+// @Summary Delete a partial
+// @Description Delete a partial or layout. It fails with 409 while reports use it, unless force is set.
+// @Tags partials
+// @Accept json
+// @Produce json
+// @Param name body string true "The name of the partial"
+// @Param force body bool false "Delete the partial even if reports use it"
+// @Success 200 "OK"
+// @Failure 409 {array} string "The reports using the partial"
+// @Router /report/partials/delete [delete]
+func deletePartial(ctx *fiber.Ctx) error {
+	var body struct {
+		Name  string `json:"name"`
+		Force bool   `json:"force"`
+	}
+
+	utils.ParseRequestBody(ctx, &body)
+
+	if body.Name == "" {
+		return ctx.Status(400).SendString("name is required.")
+	}
+
+	partialOpt, errOpt := internalDb.GetPartial(InternalDb, body.Name)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+	if partialOpt.IsNone() {
+		return ctx.Status(404).SendString("partial was not found.")
+	}
+
+	if !body.Force {
+		dependents, errOpt := dependentReportsOf(body.Name)
+		if errOpt.IsSome() {
+			return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+		}
+		if len(dependents) > 0 {
+			return ctx.Status(409).JSON(dependents)
+		}
+	}
+
+	errOpt = internalDb.DeletePartial(InternalDb, body.Name)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(map[string]string{
+		"message": "Partial deleted successfully.",
+	})
+}
+
+// dependentReportsOf returns the names of the reports using the partial.
+func dependentReportsOf(name string) ([]string, safego.Option[error]) {
+	reports, errOpt := listAllReports()
+	if errOpt.IsSome() {
+		return []string{}, errOpt
+	}
+
+	partials, errOpt := internalDb.ListPartials(InternalDb)
+	if errOpt.IsSome() {
+		return []string{}, errOpt
+	}
+
+	return core.DependentReports(name, reports, partials), safego.None[error]()
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package routes
+
+import "net"
+
+// peerClosed reports whether the client closed the connection. The socket can't be peeked at on this platform, so
+// disconnects are only noticed when the response is written.
+func peerClosed(conn net.Conn) bool {
+	return false
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd
+
+package routes
+
+import (
+	"crypto/tls"
+	"net"
+	"syscall"
+)
+
+// peerClosed reports whether the client closed the connection. It peeks at the socket without consuming or waiting
+// for what it holds, so the connection is left as is for the server.
+func peerClosed(conn net.Conn) bool {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	syscallConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	rawConn, err := syscallConn.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	closed := false
+	buffer := make([]byte, 1)
+	err = rawConn.Read(func(fd uintptr) bool {
+		n, _, err := syscall.Recvfrom(int(fd), buffer, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		// A closed connection reads as the end of the stream, an open one has data or would block.
+		closed = (n == 0 && err == nil) || err == syscall.ECONNRESET
+
+		return true
+	})
+
+	return err == nil && closed
+}
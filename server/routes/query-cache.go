@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/outputcache"
+	"github.com/okira-e/goreports/querycache"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/utils"
+	"log"
+)
+
+var QueryCache safego.Option[*querycache.Cache]
+var OutputCache safego.Option[*outputcache.Cache]
+
+// QueryCacheRouter sets up the routes for the caches of the query results and of the rendered documents.
+// This function is called from server/routes/index.go.
+func QueryCacheRouter(app fiber.Router) {
+	const controllerName = "/report/cache"
+
+	app.Get(controllerName+"/stats", getQueryCacheStats)
+
+	app.Get(controllerName+"/outputs/stats", getOutputCacheStats)
+
+	app.Post(controllerName+"/invalidate", invalidateQueryCache)
+}
+
+// @Summary Get the query cache stats
+// @Description Count the hits and misses of the query cache since the server started, overall and per report
+// @Tags cache
+// @Produce json
+// @Success 200 {object} types.QueryCacheStats
+// @Router /report/cache/stats [get]
+func getQueryCacheStats(ctx *fiber.Ctx) error {
+	if QueryCache.IsNone() {
+		return ctx.Status(404).SendString("The query cache is not configured.")
+	}
+
+	stats, errOpt := QueryCache.Unwrap().Stats()
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(stats)
+}
+
+// @Summary Get the rendered document cache stats
+// @Description Count the hits, misses and evictions of the rendered document cache since the server started
+// @Tags cache
+// @Produce json
+// @Success 200 {object} types.OutputCacheStats
+// @Router /report/cache/outputs/stats [get]
+func getOutputCacheStats(ctx *fiber.Ctx) error {
+	if OutputCache.IsNone() {
+		return ctx.Status(404).SendString("The output cache is not configured.")
+	}
+
+	return ctx.Status(200).JSON(OutputCache.Unwrap().Stats())
+}
+
+// @Summary Invalidate the caches
+// @Description Delete the cached query results and rendered documents of a report, or of every report when reportName
+// @Description is omitted. The queries of the sub-reports are cached under the name of the sub-report.
+// @Tags cache
+// @Accept json
+// @Produce json
+// @Param reportName body string false "The report whose query results and documents are deleted. Defaults to every report"
+// @Success 200 "OK"
+// @Router /report/cache/invalidate [post]
+func invalidateQueryCache(ctx *fiber.Ctx) error {
+	if QueryCache.IsNone() && OutputCache.IsNone() {
+		return ctx.Status(404).SendString("Neither the query cache nor the output cache is configured.")
+	}
+
+	var body struct {
+		ReportName string `json:"reportName"`
+	}
+
+	utils.ParseRequestBody(ctx, &body)
+
+	deleted := 0
+	if QueryCache.IsSome() {
+		deletedResults, errOpt := QueryCache.Unwrap().InvalidateReport(body.ReportName)
+		if errOpt.IsSome() {
+			return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+		}
+		deleted = deletedResults
+	}
+
+	deletedOutputs := 0
+	if OutputCache.IsSome() {
+		deletedOutputs = OutputCache.Unwrap().InvalidateReport(body.ReportName)
+	}
+
+	return ctx.Status(200).JSON(map[string]any{
+		"message":        "Caches invalidated successfully.",
+		"deleted":        deleted,
+		"deletedOutputs": deletedOutputs,
+	})
+}
+
+// invalidateCachesOf deletes the cached query results and documents of a report whose template changed or that was
+// deleted.
+func invalidateCachesOf(reportName string) {
+	if OutputCache.IsSome() {
+		OutputCache.Unwrap().InvalidateReport(reportName)
+	}
+	if QueryCache.IsNone() {
+		return
+	}
+
+	_, errOpt := QueryCache.Unwrap().InvalidateReport(reportName)
+	if errOpt.IsSome() {
+		log.Printf("error while invalidating the query cache of %s: %v", reportName, errOpt.Unwrap())
+	}
+}
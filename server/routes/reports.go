@@ -1,18 +1,23 @@
 package routes
 
 import (
-	"database/sql"
+	"context"
+	"encoding/json"
 	"github.com/gofiber/fiber/v2"
 	"github.com/okira-e/goreports/core"
 	"github.com/okira-e/goreports/internalDb"
 	"github.com/okira-e/goreports/safego"
 	"github.com/okira-e/goreports/types"
 	"github.com/okira-e/goreports/utils"
+	"github.com/okira-e/goreports/webhooks"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // ReportsRouter sets up the routes for reports.
 // This function is called from server/routes/index.go.
-func ReportsRouter(app *fiber.App) {
+func ReportsRouter(app fiber.Router) {
 	const controllerName = "/report"
 
 	app.Get(controllerName+"/list", listReportsApi)
@@ -21,6 +26,12 @@ func ReportsRouter(app *fiber.App) {
 
 	app.Post(controllerName+"/render", renderReport)
 
+	app.Post(controllerName+"/render/batch", renderBatch)
+
+	app.Post(controllerName+"/render/cancel", cancelRender)
+
+	app.Post(controllerName+"/validate", validateReport)
+
 	app.Delete(controllerName+"/delete", deleteReport)
 }
 
@@ -31,7 +42,7 @@ func ReportsRouter(app *fiber.App) {
 // @Success 200 "OK"
 // @Router /report/list [get]
 func listReportsApi(ctx *fiber.Ctx) error {
-	reports, errOpt := internalDb.ListReports(InternalDb)
+	reports, errOpt := listAllReports()
 	if errOpt.IsSome() {
 		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
 	}
@@ -50,7 +61,10 @@ func listReportsApi(ctx *fiber.Ctx) error {
 // @Param `body` body string true "The body of the report"
 // @Param header body string false "The header of the report"
 // @Param footer body string false "The footer of the report"
+// @Param printingOptions body types.PrintingOptions false "The default printing options of the report"
+// @Param parameters body []types.ReportParameter false "The definitions of the report parameters"
 // @Success 201 "Created"
+// @Failure 400 {object} types.ValidationResult "The template is invalid"
 // @Router /report/save [post]
 func saveReport(ctx *fiber.Ctx) error {
 	report := types.Report{}
@@ -73,28 +87,22 @@ func saveReport(ctx *fiber.Ctx) error {
 	report.CreatedAt = utils.GetTimestamp()
 	report.UpdatedAt = 0
 
-	// Save the report.
-	var header, footer sql.NullString
-
-	header = sql.NullString{
-		String: report.Header,
-		Valid:  true,
-	}
-	footer = sql.NullString{
-		String: report.Footer,
-		Valid:  true,
+	if isReadOnlyReport(report.Name) {
+		return ctx.Status(403).SendString("The report " + report.Name + " is loaded from the reports directory and is read-only.")
 	}
 
-	if len(header.String) == 0 {
-		header = sql.NullString{}
+	// Reject the templates that would fail to render.
+	validationResult := core.ValidateReport(report, ExternalDb, CheckStatements)
+	if !validationResult.Valid {
+		return ctx.Status(400).JSON(validationResult)
 	}
-	if len(footer.String) == 0 {
-		footer = sql.NullString{}
-	}
-	errOpt := (*InternalDb).Exec("INSERT INTO reports (name, title, description, body, header, footer, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", report.Name, report.Title, report.Description, report.Body, header, footer, report.CreatedAt, report.UpdatedAt)
+
+	// Save the report.
+	errOpt := internalDb.SaveReport(InternalDb, report)
 	if errOpt.IsSome() {
 		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
 	}
+	invalidateCachesOf(report.Name)
 
 	// Return a response.
 	return ctx.Status(201).JSON(map[string]string{
@@ -110,15 +118,24 @@ func saveReport(ctx *fiber.Ctx) error {
 // @Produce plain
 // @Param reportName body string true "The name of the report"
 // @Param params body object false "The parameters injected inside the report body to be passed at runtime"
-// @Param printingOptions body types.PrintingOptions false "The printing options to be used in the report"
+// @Param printingOptions body types.PrintingOptions false "The printing options to be used in the report. Defaults to the printing options saved with the report"
+// @Param locale body string false "The locale to format values and translate the report in, e.g. fr or ar"
+// @Param archive body boolean false "Archive the rendered document in the output storage"
+// @Param callbackUrl body string false "Render in the background and POST the signed result to this URL when done. Requires webhook_config.secret"
+// @Param If-None-Match header string false "The ETag of a previous response, answered with 304 if the document is the same"
 // @Success 200 "OK"
+// @Success 202 "Accepted"
+// @Success 304 "Not Modified"
 // @Router /report/render [post]
 func renderReport(ctx *fiber.Ctx) error {
 	// Define the request renderBody.
 	var renderBody struct {
-		ReportName      string                `json:"reportName"`
-		Params          map[string]any        `json:"params"`
-		PrintingOptions types.PrintingOptions `json:"printingOptions"`
+		ReportName      string                 `json:"reportName"`
+		Params          map[string]any         `json:"params"`
+		PrintingOptions *types.PrintingOptions `json:"printingOptions"`
+		Locale          string                 `json:"locale"`
+		Archive         bool                   `json:"archive"`
+		CallbackUrl     string                 `json:"callbackUrl"`
 	}
 
 	// Parse the request renderBody.
@@ -133,61 +150,200 @@ func renderReport(ctx *fiber.Ctx) error {
 		renderBody.Params = make(map[string]any)
 	}
 
+	if (renderBody.Archive || renderBody.CallbackUrl != "") && OutputStorage.IsNone() {
+		return ctx.Status(400).SendString("The output storage is not configured.")
+	}
+
+	if renderBody.CallbackUrl != "" {
+		if WebhookConfig.Secret == "" {
+			return ctx.Status(400).SendString(webhooks.ErrNoSecret.Error())
+		}
+		errOpt := webhooks.ValidateCallbackUrl(renderBody.CallbackUrl)
+		if errOpt.IsSome() {
+			return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+		}
+	}
+
 	// Get the report from the database.
-	report := types.ReportWithNullableFields{}
-	rows, errOpt := (*InternalDb).Query("SELECT * FROM reports WHERE name = ?", renderBody.ReportName)
+	reportOpt, errOpt := findReport(renderBody.ReportName)
 	if errOpt.IsSome() {
 		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
 	}
-	// Extract the report from the rows.
-	emptyResult := true
-	for rows.Next() {
-		emptyResult = false
-		err := rows.Scan(&report.ID, &report.Name, &report.Title, &report.Description, &report.Body, &report.Header, &report.Footer, &report.CreatedAt, &report.UpdatedAt)
-		if err != nil {
-			return ctx.Status(500).SendString(err.Error())
-		}
-	}
 	// If the report was not found, return a 404 response.
-	if emptyResult {
+	if reportOpt.IsNone() {
 		return ctx.Status(404).SendString("report was not found.")
 	}
+	report := reportOpt.Unwrap()
+	printingOptions := core.ResolvePrintingOptions(report, renderBody.PrintingOptions)
+
+	// Render in the background and notify the callback URL when done.
+	if renderBody.CallbackUrl != "" {
+		renderId := utils.GenerateId()
+
+		renderCtx, cancel := context.WithCancel(shutdownCtx)
+		backgroundRenders.Store(renderId, cancel)
+		started := startBackgroundWork(func() {
+			renderAndNotify(renderCtx, renderId, report, renderBody.Params, printingOptions, renderBody.Locale, renderBody.CallbackUrl)
+		})
+		if !started {
+			backgroundRenders.Delete(renderId)
+			cancel()
+			return ctx.Status(503).SendString("The server is shutting down.")
+		}
 
-	handlebarsTemplate, queries, errMsgOpt := core.ParseTemplate(report.Body.String, renderBody.Params, ExternalDb)
-	if errMsgOpt.IsSome() {
-		return ctx.Status(400).SendString(errMsgOpt.Unwrap())
+		return ctx.Status(202).JSON(map[string]string{
+			"message":  "Report render started.",
+			"renderId": renderId,
+		})
 	}
 
-	// Parse the template in handlebars.
-	compiledTemplate, errOpt := utils.ParseHandleBars(handlebarsTemplate, queries)
+	requestCtx, cancel := requestContext(ctx)
+	defer cancel()
+
+	generatedPDFBuffer, etag, errOpt := renderer().RenderWithETag(requestCtx, report, renderBody.Params, printingOptions, core.FormatPdf, renderBody.Locale)
 	if errOpt.IsSome() {
+		if core.IsTemplateError(errOpt.Unwrap()) {
+			return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+		}
+
 		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
 	}
 
-	// Generate the document
-	header, footer := safego.None[string](), safego.None[string]()
+	// Archive the document if requested.
+	if renderBody.Archive {
+		key, errOpt := archiveOutput(renderBody.ReportName, renderBody.Params, generatedPDFBuffer.Bytes())
+		if errOpt.IsSome() {
+			return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+		}
+
+		ctx.Set("X-Output-Key", key)
+	}
+
+	// Let the client reuse its copy if the document didn't change.
+	ctx.Set(fiber.HeaderETag, etag)
+	if etagMatches(ctx.Get(fiber.HeaderIfNoneMatch), etag) {
+		return ctx.SendStatus(304)
+	}
+
+	// Return a response.
+	return ctx.Status(200).Send(generatedPDFBuffer.Bytes())
+}
 
-	if report.Header.Valid {
-		header = safego.Some(report.Header.String)
+// @Summary Render a report in batch
+// @Description Render a report once per parameter set, given as items or as the rows of itemsQuery, and return a single
+// @Description PDF with a bookmark per item or a ZIP archive with a file per item.
+// @Description The items that fail are left out. The X-Batch-Failed header counts them and X-Batch-Errors lists them as JSON.
+// @Description The ZIP archive also lists them in errors.json. It responds with 422 and the results if no item could be rendered.
+// @Tags reports
+// @Accept json
+// @Produce application/pdf,application/zip
+// @Param batch body types.BatchRender true "The report and its parameter sets"
+// @Success 200 "OK"
+// @Failure 422 {array} types.BatchItemResult "The results of the items, none of which could be rendered"
+// @Router /report/render/batch [post]
+func renderBatch(ctx *fiber.Ctx) error {
+	batch := types.BatchRender{}
+
+	utils.ParseRequestBody(ctx, &batch)
+
+	if batch.ReportName == "" {
+		return ctx.Status(400).SendString("The report name is required.")
 	}
-	if report.Footer.Valid {
-		footer = safego.Some(report.Footer.String)
+	if len(batch.Items) == 0 && batch.ItemsQuery == "" {
+		return ctx.Status(400).SendString("Either items or itemsQuery is required.")
 	}
 
-	reportGeneratorParams := types.ReportAttributesForPdfGenerator{
-		Title:  report.Title.String,
-		Body:   compiledTemplate,
-		Header: header,
-		Footer: footer,
+	reportOpt, errOpt := findReport(batch.ReportName)
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+	if reportOpt.IsNone() {
+		return ctx.Status(404).SendString("report was not found.")
 	}
+	report := reportOpt.Unwrap()
+	printingOptions := core.ResolvePrintingOptions(report, batch.PrintingOptions)
 
-	generatedPDFBuffer, errOpt := core.GeneratePDFFromHtml(reportGeneratorParams, renderBody.PrintingOptions)
+	requestCtx, cancel := requestContext(ctx)
+	defer cancel()
+
+	document, results, errOpt := renderer().RenderBatch(requestCtx, report, batch, printingOptions)
 	if errOpt.IsSome() {
+		if len(results) > 0 {
+			return ctx.Status(422).JSON(results)
+		}
+		if core.IsTemplateError(errOpt.Unwrap()) {
+			return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+		}
+
 		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
 	}
 
-	// Return a response.
-	return ctx.Status(200).Send(generatedPDFBuffer.Bytes())
+	failures := []types.BatchItemResult{}
+	for _, result := range results {
+		if result.Error != "" {
+			failures = append(failures, result)
+		}
+	}
+	ctx.Set("X-Batch-Failed", strconv.Itoa(len(failures)))
+	if len(failures) > 0 {
+		encodedFailures, err := json.Marshal(failures)
+		if err != nil {
+			return ctx.Status(500).SendString(err.Error())
+		}
+		ctx.Set("X-Batch-Errors", string(encodedFailures))
+	}
+
+	if batch.Output == core.BatchOutputZip {
+		ctx.Attachment(report.Name + ".zip")
+	} else {
+		ctx.Attachment(report.Name + ".pdf")
+	}
+
+	return ctx.Status(200).Send(document.Bytes())
+}
+
+// @Summary Validate a report
+// @Description Check the handlebars, the [P[...]]/[Q[...]] directives and the queries of a report without rendering it.
+// @Description Validates the saved report named reportName, or the given body when provided.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param reportName body string false "The name of a saved report"
+// @Param `body` body string false "The body to validate instead of a saved report"
+// @Param parameters body []types.ReportParameter false "The definitions of the parameters of the body"
+// @Success 200 {object} types.ValidationResult
+// @Router /report/validate [post]
+func validateReport(ctx *fiber.Ctx) error {
+	var validateBody struct {
+		ReportName string                  `json:"reportName"`
+		Body       string                  `json:"body"`
+		Parameters []types.ReportParameter `json:"parameters"`
+	}
+
+	utils.ParseRequestBody(ctx, &validateBody)
+
+	report := types.Report{
+		Name:       validateBody.ReportName,
+		Body:       validateBody.Body,
+		Parameters: validateBody.Parameters,
+	}
+
+	if report.Body == "" {
+		if report.Name == "" {
+			return ctx.Status(400).SendString("Either the report name or the body is required.")
+		}
+
+		reportOpt, errOpt := findReport(report.Name)
+		if errOpt.IsSome() {
+			return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+		}
+		if reportOpt.IsNone() {
+			return ctx.Status(404).SendString("report was not found.")
+		}
+		report = reportOpt.Unwrap()
+	}
+
+	return ctx.Status(200).JSON(core.ValidateReport(report, ExternalDb, CheckStatements))
 }
 
 // @Summary Delete a report
@@ -211,14 +367,89 @@ func deleteReport(ctx *fiber.Ctx) error {
 		return ctx.Status(400).SendString("reportName is required.")
 	}
 
+	if isReadOnlyReport(body.ReportName) {
+		return ctx.Status(403).SendString("The report " + body.ReportName + " is loaded from the reports directory and is read-only.")
+	}
+
 	// Delete the report.
-	errOpt := (*InternalDb).Exec("DELETE FROM reports WHERE name = ?", body.ReportName)
+	errOpt := internalDb.DeleteReport(InternalDb, body.ReportName)
 	if errOpt.IsSome() {
 		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
 	}
+	invalidateCachesOf(body.ReportName)
 
 	// Return a response.
 	return ctx.Status(200).JSON(map[string]string{
 		"message": "Report " + body.ReportName + " deleted successfully.",
 	})
 }
+
+// renderer returns the renderer of the server's reports.
+func renderer() *core.Renderer {
+	return &core.Renderer{
+		InternalDb:       InternalDb,
+		ExternalDb:       ExternalDb,
+		DefaultLocale:    DefaultLocale,
+		ReportsDirectory: ReportsDirectory,
+		QueryLimits:      QueryLimits,
+		AllowWrites:      AllowWrites,
+		CheckStatements:  CheckStatements,
+		QueryCache:       QueryCache,
+		OutputCache:      OutputCache,
+	}
+}
+
+// etagMatches reports whether the If-None-Match header lists the entity tag.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findReport returns the report with the given name from the reports directory, or else from the internal database.
+func findReport(name string) (safego.Option[types.Report], safego.Option[error]) {
+	if ReportsDirectory.IsSome() {
+		reportOpt := ReportsDirectory.Unwrap().Get(name)
+		if reportOpt.IsSome() {
+			return reportOpt, safego.None[error]()
+		}
+	}
+
+	return internalDb.GetReport(InternalDb, name)
+}
+
+// listAllReports returns the reports of the reports directory and the ones of the internal database they don't shadow.
+func listAllReports() ([]types.Report, safego.Option[error]) {
+	reports, errOpt := internalDb.ListReports(InternalDb)
+	if errOpt.IsSome() || ReportsDirectory.IsNone() {
+		return reports, errOpt
+	}
+
+	allReports := ReportsDirectory.Unwrap().List()
+	for _, report := range reports {
+		if !isReadOnlyReport(report.Name) {
+			allReports = append(allReports, report)
+		}
+	}
+	sort.Slice(allReports, func(i, j int) bool {
+		return allReports[i].Name < allReports[j].Name
+	})
+
+	return allReports, safego.None[error]()
+}
+
+// isReadOnlyReport reports whether the report is loaded from the reports directory.
+func isReadOnlyReport(name string) bool {
+	if ReportsDirectory.IsNone() {
+		return false
+	}
+
+	reportOpt := ReportsDirectory.Unwrap().Get(name)
+
+	return reportOpt.IsSome()
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+// disconnectPollInterval is how often requestContext checks whether the client is still connected.
+const disconnectPollInterval = 500 * time.Millisecond
+
+// requestContext returns a context canceled when the client disconnects or the shutdown deadline is exceeded, so the
+// queries and wkhtmltopdf of a render don't outlive the request. fasthttp doesn't report disconnects, so the connection
+// is polled. The caller must call the cancel function once done with the request.
+func requestContext(ctx *fiber.Ctx) (context.Context, context.CancelFunc) {
+	requestCtx, cancel := context.WithCancel(ctx.UserContext())
+	// The request context of fasthttp is reused once the handler returns, keep what is needed. Its Done channel isn't
+	// watched, it is closed as soon as the server shuts down and the renders in flight are meant to be drained.
+	conn := ctx.Context().Conn()
+
+	go func() {
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-requestCtx.Done():
+				return
+			case <-shutdownCtx.Done():
+				cancel()
+				return
+			case <-ticker.C:
+				if conn != nil && peerClosed(conn) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return requestCtx, cancel
+}
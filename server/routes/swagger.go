@@ -3,11 +3,16 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
+	"github.com/okira-e/goreports/docs"
 )
 
+// BasePath is the URL path prefix the routes are served under, empty if they're served at the root.
+var BasePath string
+
 // SwaggerRouter sets up the swagger routes
-func SwaggerRouter(app *fiber.App) {
-	port := "3200"
+func SwaggerRouter(app fiber.Router) {
+	// The requests of the Swagger UI are sent to the routes under the prefix, on the host the UI is served from.
+	docs.SwaggerInfo.BasePath = BasePath
 
 	app.Get("/docs/swagger.json", func(ctx *fiber.Ctx) error {
 		// Only allow swagger.json to be retrieved from localhost
@@ -15,10 +20,11 @@ func SwaggerRouter(app *fiber.App) {
 			return ctx.Status(fiber.StatusForbidden).SendString("Forbidden")
 		}
 
-		return ctx.Status(fiber.StatusOK).SendFile("./docs/swagger.json")
+		ctx.Type("json")
+		return ctx.Status(fiber.StatusOK).SendString(docs.SwaggerInfo.ReadDoc())
 	})
 
 	app.Get("/swagger/*", swagger.New(swagger.Config{
-		URL: "http://localhost:" + port + "/docs/swagger.json",
+		URL: BasePath + "/docs/swagger.json",
 	}))
 }
@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+)
+
+// DefaultLocale is the locale the {{t}} translations fall back to.
+var DefaultLocale string
+
+// TranslationsRouter sets up the routes for report translations.
+// This function is called from server/routes/index.go.
+func TranslationsRouter(app fiber.Router) {
+	const controllerName = "/report/translations"
+
+	app.Get(controllerName, listTranslations)
+
+	app.Post(controllerName+"/save", saveTranslations)
+
+	app.Delete(controllerName+"/delete", deleteTranslations)
+}
+
+// @Summary List the translations of a report
+// @Description List the translation tables of a report by locale
+// @Tags translations
+// @Produce json
+// @Param reportName query string true "The name of the report"
+// @Success 200 {object} map[string]map[string]string
+// @Router /report/translations [get]
+func listTranslations(ctx *fiber.Ctx) error {
+	reportName := ctx.Query("reportName")
+	if reportName == "" {
+		return ctx.Status(400).SendString("reportName is required.")
+	}
+
+	tables, errOpt := internalDb.ListTranslations(InternalDb, reportName)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(tables)
+}
+
+// @Summary Save the translations of a report
+// @Description Replace the translation table of a report in a locale
+// @Tags translations
+// @Accept json
+// @Produce plain
+// @Param reportName body string true "The name of the report"
+// @Param locale body string true "The locale of the translations, e.g. fr or fr-CA"
+// @Param translations body object true "The translations by {{t}} key"
+// @Success 200 "OK"
+// @Router /report/translations/save [post]
+func saveTranslations(ctx *fiber.Ctx) error {
+	translations := types.ReportTranslations{}
+
+	utils.ParseRequestBody(ctx, &translations)
+
+	if translations.ReportName == "" {
+		return ctx.Status(400).SendString("reportName is required.")
+	}
+	if translations.Locale == "" {
+		return ctx.Status(400).SendString("locale is required.")
+	}
+
+	reportOpt, errOpt := findReport(translations.ReportName)
+	if errOpt.IsSome() {
+		return ctx.Status(400).SendString(errOpt.Unwrap().Error())
+	}
+	if reportOpt.IsNone() {
+		return ctx.Status(404).SendString("report was not found.")
+	}
+
+	errOpt = internalDb.SaveTranslations(InternalDb, translations)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(map[string]string{
+		"message": "Translations saved successfully.",
+	})
+}
+
+// @Summary Delete the translations of a report
+// @Description Delete the translation table of a report in a locale
+// @Tags translations
+// @Accept json
+// @Produce plain
+// @Param reportName body string true "The name of the report"
+// @Param locale body string true "The locale of the translations"
+// @Success 200 "OK"
+// @Router /report/translations/delete [delete]
+func deleteTranslations(ctx *fiber.Ctx) error {
+	var body struct {
+		ReportName string `json:"reportName"`
+		Locale     string `json:"locale"`
+	}
+
+	utils.ParseRequestBody(ctx, &body)
+
+	if body.ReportName == "" || body.Locale == "" {
+		return ctx.Status(400).SendString("reportName and locale are required.")
+	}
+
+	errOpt := internalDb.DeleteTranslations(InternalDb, body.ReportName, body.Locale)
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(map[string]string{
+		"message": "Translations deleted successfully.",
+	})
+}
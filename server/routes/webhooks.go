@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/okira-e/goreports/core"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"github.com/okira-e/goreports/webhooks"
+	"log"
+	"sync"
+	"time"
+)
+
+var WebhookConfig types.WebhookConfig
+
+// backgroundRenders holds the functions canceling the renders running in the background, by render id.
+var backgroundRenders sync.Map
+
+// WebhooksRouter sets up the routes for webhook callbacks.
+// This function is called from server/routes/index.go.
+func WebhooksRouter(app fiber.Router) {
+	const controllerName = "/report/webhooks"
+
+	app.Get(controllerName+"/deliveries", listWebhookDeliveries)
+}
+
+// @Summary List webhook deliveries
+// @Description List the delivery attempts of render callbacks, newest first
+// @Tags webhooks
+// @Produce json
+// @Param renderId query string false "Only list the deliveries of this render"
+// @Success 200 {array} types.WebhookDelivery
+// @Router /report/webhooks/deliveries [get]
+func listWebhookDeliveries(ctx *fiber.Ctx) error {
+	deliveries, errOpt := internalDb.ListWebhookDeliveries(InternalDb, ctx.Query("renderId"))
+	if errOpt.IsSome() {
+		return ctx.Status(500).SendString(errOpt.Unwrap().Error())
+	}
+
+	return ctx.Status(200).JSON(deliveries)
+}
+
+// @Summary Cancel a background render
+// @Description Cancel a render started with a callbackUrl. Its queries and wkhtmltopdf are stopped and the callback
+// @Description URL is notified with the canceled status.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param renderId body string true "The id of the render returned when it was started"
+// @Success 200 "OK"
+// @Failure 404 "The render is not running"
+// @Router /report/render/cancel [post]
+func cancelRender(ctx *fiber.Ctx) error {
+	var body struct {
+		RenderId string `json:"renderId"`
+	}
+
+	utils.ParseRequestBody(ctx, &body)
+
+	if body.RenderId == "" {
+		return ctx.Status(400).SendString("renderId is required.")
+	}
+
+	cancel, ok := backgroundRenders.Load(body.RenderId)
+	if !ok {
+		return ctx.Status(404).SendString("The render is not running.")
+	}
+	cancel.(context.CancelFunc)()
+
+	return ctx.Status(200).JSON(map[string]string{
+		"message": "Render canceled.",
+	})
+}
+
+// renderAndNotify renders the report, archives it and POSTs the outcome to the callback URL.
+// It is meant to run in its own goroutine, and stops rendering when the context is canceled with cancelRender.
+func renderAndNotify(ctx context.Context, renderId string, report types.Report, params map[string]any, printingOptions types.PrintingOptions, locale string, callbackUrl string) {
+	if cancel, ok := backgroundRenders.Load(renderId); ok {
+		defer cancel.(context.CancelFunc)()
+	}
+	defer backgroundRenders.Delete(renderId)
+
+	startedAt := time.Now()
+	payload := types.WebhookPayload{
+		RenderId:   renderId,
+		ReportName: report.Name,
+		Status:     "succeeded",
+		StartedAt:  startedAt.UnixNano(),
+	}
+
+	generatedPDFBuffer, errOpt := renderer().Render(ctx, report, params, printingOptions, core.FormatPdf, locale)
+	if errOpt.IsNone() {
+		payload.OutputKey, errOpt = archiveOutput(report.Name, params, generatedPDFBuffer.Bytes())
+	}
+	if errOpt.IsSome() {
+		payload.Status = "failed"
+		if ctx.Err() != nil {
+			payload.Status = "canceled"
+		}
+		payload.Error = errOpt.Unwrap().Error()
+	}
+
+	finishedAt := time.Now()
+	payload.FinishedAt = finishedAt.UnixNano()
+	payload.DurationMs = finishedAt.Sub(startedAt).Milliseconds()
+
+	// The delivery isn't bound to the context of the render, a canceled render still notifies its callback URL. It is
+	// only canceled when the server shuts down.
+	errOpt = webhooks.Deliver(deliveriesCtx, InternalDb, WebhookConfig, callbackUrl, payload)
+	if errOpt.IsSome() {
+		log.Printf("error while delivering the callback of render %s: %v", renderId, errOpt.Unwrap())
+	}
+}
@@ -1,17 +1,47 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/okira-e/goreports/assets"
+	"github.com/okira-e/goreports/bundle"
 	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/health"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/outputcache"
+	"github.com/okira-e/goreports/querycache"
+	"github.com/okira-e/goreports/safego"
 	"github.com/okira-e/goreports/server/routes"
+	"github.com/okira-e/goreports/storage"
+	"github.com/okira-e/goreports/types"
 	"github.com/okira-e/goreports/utils"
 	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
-// StartServer starts a Fiber web server to listen for any requests to GoReports.
-func StartServer() {
-	var internalDb datasource.DataSource
+// The defaults of the server config.
+const (
+	DefaultHost                   = "0.0.0.0"
+	DefaultPort                   = 3200
+	DefaultShutdownTimeoutSeconds = 25
+)
+
+// cancelGracePeriod is how long the renders canceled at the shutdown deadline are given to notify their callback URL.
+const cancelGracePeriod = 5 * time.Second
+
+// StartServer starts a Fiber web server to listen for any requests to GoReports, until it receives SIGINT or SIGTERM.
+// It then stops accepting requests, drains the renders in flight and closes the databases. It returns an error if the
+// server couldn't listen, if the renders didn't finish in time or if a database couldn't be closed.
+func StartServer(options types.ServerOptions) safego.Option[error] {
+	var internalDbConn datasource.DataSource
 	var externalDb datasource.DataSource
 
 	// Establish a connection with internal database.
@@ -20,55 +50,275 @@ func StartServer() {
 		log.Fatalf("error while getting the data directory: %v", errOpt.Unwrap())
 	}
 
-	internalDb = datasource.NewSqliteDb(dataDir + "/internal.db")
+	internalDbConn = datasource.NewSqliteDb(dataDir + "/internal.db")
 
-	errOpt = internalDb.Connect()
+	errOpt = internalDbConn.Connect()
 	if errOpt.IsSome() {
 		log.Fatalf("error while connecting to the database: %v", errOpt.Unwrap())
 	}
 
-	// Establish a connection with external database.
+	errOpt = internalDb.MigrateInternalDb(&internalDbConn)
+	if errOpt.IsSome() {
+		log.Fatalf("error while migrating the internal database: %v", errOpt.Unwrap())
+	}
+
+	// Set up the connection pool of the external database, it is connected to lazily.
 	config, errOpt := utils.GetConfigData()
 	if errOpt.IsSome() {
 		log.Fatalf("error while getting the config data: %v", errOpt.Unwrap())
 	}
 
-	connStr, errMsgOpt := config.DbConfig.GetConnectionString()
-	if errMsgOpt.IsSome() {
-		log.Fatalf("error while getting the connection string: %v", errMsgOpt.Unwrap())
+	connection, errOpt := datasource.ConnectionOf(config.DbConfig)
+	if errOpt.IsSome() {
+		log.Fatalf("error while getting the connection string: %v", errOpt.Unwrap())
 	}
-	externalDb = datasource.NewExternalDb(connStr)
+	externalDb = datasource.NewExternalDb(connection, config.DbConfig.Pool)
 
 	errOpt = externalDb.Connect()
 	if errOpt.IsSome() {
 		log.Fatalf("error while connecting to the external database: %v", errOpt.Unwrap())
 	}
 
-	// Close the database connections when the server stops.
-	defer func() {
-		if errOpt = internalDb.Disconnect(); errOpt.IsSome() {
-			log.Fatalf("error while disconnecting from the internal database: %v", errOpt.Unwrap())
-		}
+	// Check the databases periodically. The server starts even if they are down, they are connected to once they're up.
+	healthChecker := health.NewChecker(config.DbConfig.HealthCheck)
+	healthChecker.Watch("internal", &internalDbConn)
+	healthChecker.Watch("external", &externalDb)
 
-		if errOpt = externalDb.Disconnect(); errOpt.IsSome() {
-			log.Fatalf("error while disconnecting from the external database: %v", errOpt.Unwrap())
+	// stop is closed once the server shuts down, to stop the periodic tasks.
+	stop := make(chan struct{})
+
+	// Set up the output storage, if configured.
+	outputStorage, errOpt := storage.NewStorageFromConfig(config.StorageConfig, dataDir)
+	if errOpt.IsSome() {
+		log.Fatalf("error while setting up the output storage: %v", errOpt.Unwrap())
+	}
+	if outputStorage.IsSome() {
+		go purgeOutputsPeriodically(outputStorage.Unwrap(), config.StorageConfig.Retention, stop)
+	}
+
+	// Set up the query cache, if configured.
+	queryCache, errOpt := querycache.NewCacheFromConfig(config.QueryCacheConfig, connection.Dsn, dataDir)
+	if errOpt.IsSome() {
+		log.Fatalf("error while setting up the query cache: %v", errOpt.Unwrap())
+	}
+
+	// Load the reports directory, if any, and reload it when the files change.
+	reportsDirectory := safego.None[*bundle.Directory]()
+	if options.ReportsDir != "" {
+		directory, errOpt := bundle.LoadDirectory(options.ReportsDir)
+		if errOpt.IsSome() {
+			log.Fatalf("error while loading the reports directory: %v", errOpt.Unwrap())
 		}
-	}()
+		utils.Log(fmt.Sprintf("Loaded %d report(s) from %s", len(directory.List()), options.ReportsDir))
+
+		go directory.Watch(options.ReportsDirPollInterval, stop)
+		reportsDirectory = safego.Some(directory)
+	}
 
-	// Create a new Fiber instance.
-	app := fiber.New()
+	// Create a new Fiber instance. The uploaded assets must fit in a request, so the server reads the bodies up to the
+	// largest asset. The other routes are held to the body limit by routes.LimitBody, the uploads by their route.
+	serverConfig := serverConfigOf(config.ServerConfig, options.Server)
+	maxAssetSize := config.AssetConfig.MaxSizeBytes
+	if maxAssetSize <= 0 {
+		maxAssetSize = assets.DefaultMaxSizeBytes
+	}
+	readLimit := serverConfig.BodyLimitBytes
+	if maxAssetSize >= int64(readLimit) {
+		readLimit = int(maxAssetSize) + 1
+	}
+	app := fiber.New(fiber.Config{
+		BodyLimit:    readLimit,
+		ReadTimeout:  time.Duration(serverConfig.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(serverConfig.WriteTimeoutSeconds) * time.Second,
+	})
 	// Set up CORS.
 	app.Use(cors.New())
+	app.Use(routes.LimitBody(serverConfig.BodyLimitBytes))
 	// Set up the databases.
-	routes.InternalDb = &internalDb
+	routes.InternalDb = &internalDbConn
 	routes.ExternalDb = &externalDb
-	// Set up the routes.
-	routes.GlobalRouter(app)
-
-	// Start the server.
-	const port = ":3200"
-	err := app.Listen("0.0.0.0" + port)
-	if err != nil {
-		log.Fatalf("error while starting the server: %v", err)
+	routes.QueryLimits = config.DbConfig.Limits
+	routes.AllowWrites = config.DbConfig.AllowWrites
+	routes.CheckStatements = config.DbConfig.CheckStatements
+	routes.ReportsDirectory = reportsDirectory
+	// Set up the output storage.
+	routes.OutputStorage = outputStorage
+	routes.StorageConfig = config.StorageConfig
+	// Set up the webhooks.
+	routes.WebhookConfig = config.WebhookConfig
+	// Set up the translations.
+	routes.DefaultLocale = config.DefaultLocale
+	// Set up the assets.
+	routes.AssetConfig = config.AssetConfig
+	// Watch the databases.
+	routes.HealthChecker = healthChecker
+	// Set up the caches.
+	routes.QueryCache = queryCache
+	routes.OutputCache = outputcache.NewCacheFromConfig(config.OutputCacheConfig)
+	// Set up the routes, under the base path if any.
+	routes.BasePath = serverConfig.BasePath
+	var router fiber.Router = app
+	if serverConfig.BasePath != "" {
+		router = app.Group(serverConfig.BasePath)
+	}
+	routes.GlobalRouter(router)
+
+	// Start the server, and shut it down on SIGINT or SIGTERM.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	listenErrors := make(chan error, 1)
+	go func() {
+		address := net.JoinHostPort(serverConfig.Host, strconv.Itoa(serverConfig.Port))
+		if serverConfig.TlsCertPath != "" {
+			listenErrors <- app.ListenTLS(address, serverConfig.TlsCertPath, serverConfig.TlsKeyPath)
+		} else {
+			listenErrors <- app.Listen(address)
+		}
+	}()
+
+	shutdownErrOpt := safego.None[error]()
+	select {
+	case err := <-listenErrors:
+		shutdownErrOpt = safego.Some(err)
+	case received := <-signals:
+		utils.Log(fmt.Sprintf("Received %s, shutting down...", received))
+
+		// Another signal doesn't wait for the renders.
+		go func() {
+			received := <-signals
+			log.Printf("received %s while shutting down, exiting right away", received)
+			os.Exit(1)
+		}()
+
+		shutdownErrOpt = drain(app, time.Duration(serverConfig.ShutdownTimeoutSeconds)*time.Second)
+	}
+
+	// Stop the periodic tasks, then close the query cache and the databases.
+	close(stop)
+	healthChecker.Stop()
+
+	if queryCache.IsSome() {
+		if errOpt = queryCache.Unwrap().Close(); errOpt.IsSome() {
+			log.Printf("error while closing the query cache: %v", errOpt.Unwrap())
+		}
+	}
+
+	if errOpt = externalDb.Disconnect(); errOpt.IsSome() {
+		log.Printf("error while disconnecting from the external database: %v", errOpt.Unwrap())
+		if shutdownErrOpt.IsNone() {
+			shutdownErrOpt = errOpt
+		}
+	}
+
+	if errOpt = internalDbConn.Disconnect(); errOpt.IsSome() {
+		log.Printf("error while disconnecting from the internal database: %v", errOpt.Unwrap())
+		if shutdownErrOpt.IsNone() {
+			shutdownErrOpt = errOpt
+		}
+	}
+
+	if shutdownErrOpt.IsNone() {
+		utils.Log("The server was shut down.")
+	}
+
+	return shutdownErrOpt
+}
+
+// drain stops accepting requests, then waits for the requests and the background renders in flight until the timeout.
+// The renders still running by then are canceled, and it returns an error. It only returns once the background renders
+// did, so that they don't outlive the databases.
+func drain(app *fiber.App, timeout time.Duration) safego.Option[error] {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := app.ShutdownWithContext(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("error while shutting down the server: %v", err)
+	}
+	drained := routes.DrainBackgroundWork(ctx)
+	if err == nil && drained {
+		return safego.None[error]()
+	}
+
+	// Give the canceled background renders a moment to notify their callback URL, then cancel the deliveries.
+	routes.CancelRenders()
+	graceCtx, cancelGrace := context.WithTimeout(context.Background(), cancelGracePeriod)
+	defer cancelGrace()
+	if !routes.DrainBackgroundWork(graceCtx) {
+		routes.CancelDeliveries()
+		routes.DrainBackgroundWork(context.Background())
+	}
+
+	return safego.Some(fmt.Errorf("the renders in flight didn't finish within %s and were canceled", timeout))
+}
+
+// serverConfigOf returns the server config with the flags of `goreports start` applied, and the defaults.
+func serverConfigOf(config types.ServerConfig, flags types.ServerConfig) types.ServerConfig {
+	if flags.Host != "" {
+		config.Host = flags.Host
+	}
+	if flags.Port != 0 {
+		config.Port = flags.Port
+	}
+	if flags.TlsCertPath != "" {
+		config.TlsCertPath = flags.TlsCertPath
+	}
+	if flags.TlsKeyPath != "" {
+		config.TlsKeyPath = flags.TlsKeyPath
+	}
+	if flags.BasePath != "" {
+		config.BasePath = flags.BasePath
+	}
+	if flags.BodyLimitBytes != 0 {
+		config.BodyLimitBytes = flags.BodyLimitBytes
+	}
+	if flags.ReadTimeoutSeconds != 0 {
+		config.ReadTimeoutSeconds = flags.ReadTimeoutSeconds
+	}
+	if flags.WriteTimeoutSeconds != 0 {
+		config.WriteTimeoutSeconds = flags.WriteTimeoutSeconds
+	}
+	if flags.ShutdownTimeoutSeconds != 0 {
+		config.ShutdownTimeoutSeconds = flags.ShutdownTimeoutSeconds
+	}
+
+	if config.Host == "" {
+		config.Host = DefaultHost
+	}
+	if config.Port == 0 {
+		config.Port = DefaultPort
+	}
+	if config.BodyLimitBytes <= 0 {
+		config.BodyLimitBytes = fiber.DefaultBodyLimit
+	}
+	if config.ShutdownTimeoutSeconds <= 0 {
+		config.ShutdownTimeoutSeconds = DefaultShutdownTimeoutSeconds
+	}
+	// The base path is written /prefix, without a trailing slash.
+	config.BasePath = strings.TrimSuffix(config.BasePath, "/")
+	if config.BasePath != "" && !strings.HasPrefix(config.BasePath, "/") {
+		config.BasePath = "/" + config.BasePath
+	}
+
+	return config
+}
+
+// purgeOutputsPeriodically applies the retention rules to the output storage once an hour, until stop is closed.
+func purgeOutputsPeriodically(outputStorage storage.Storage, rules types.RetentionConfig, stop <-chan struct{}) {
+	for {
+		deletedKeys, errOpt := storage.ApplyRetention(outputStorage, rules, time.Now())
+		if errOpt.IsSome() {
+			log.Printf("error while purging the archived outputs: %v", errOpt.Unwrap())
+		} else if len(deletedKeys) > 0 {
+			log.Printf("purged %d archived output(s)", len(deletedKeys))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Hour):
+		}
 	}
 }
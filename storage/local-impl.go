@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"errors"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage stores outputs as files inside a directory.
+type LocalStorage struct {
+	rootDir string
+}
+
+// NewLocalStorage returns a new LocalStorage instance.
+func NewLocalStorage(rootDir string) *LocalStorage {
+	return &LocalStorage{
+		rootDir: rootDir,
+	}
+}
+
+// Put writes the data to the file of the given key, creating the parent directories if needed.
+func (self *LocalStorage) Put(key string, data []byte, contentType string) safego.Option[error] {
+	path, errOpt := self.pathOf(key)
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	return safego.None[error]()
+}
+
+// Get reads the file of the given key.
+func (self *LocalStorage) Get(key string) ([]byte, safego.Option[error]) {
+	path, errOpt := self.pathOf(key)
+	if errOpt.IsSome() {
+		return nil, errOpt
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, safego.Some(err)
+	}
+
+	return data, safego.None[error]()
+}
+
+// List returns every stored output whose key starts with the given prefix.
+func (self *LocalStorage) List(prefix string) ([]types.StoredOutput, safego.Option[error]) {
+	outputs := []types.StoredOutput{}
+
+	err := filepath.WalkDir(self.rootDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The root directory is created lazily by the first Put.
+			if errors.Is(err, fs.ErrNotExist) && path == self.rootDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(self.rootDir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relativePath)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		outputs = append(outputs, types.StoredOutput{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime().UnixNano(),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, safego.Some(err)
+	}
+
+	return outputs, safego.None[error]()
+}
+
+// Delete removes the file of the given key.
+func (self *LocalStorage) Delete(key string) safego.Option[error] {
+	path, errOpt := self.pathOf(key)
+	if errOpt.IsSome() {
+		return errOpt
+	}
+
+	err := os.Remove(path)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	return safego.None[error]()
+}
+
+// pathOf maps a key to a path inside the root directory.
+// Keys escaping the root directory are rejected.
+func (self *LocalStorage) pathOf(key string) (string, safego.Option[error]) {
+	cleanKey := filepath.Clean("/" + key)
+	if cleanKey == "/" {
+		return "", safego.Some(errors.New("the output key is empty"))
+	}
+
+	return filepath.Join(self.rootDir, filepath.FromSlash(cleanKey)), safego.None[error]()
+}
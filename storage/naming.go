@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"errors"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/utils"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultNamingTemplate is used when the config doesn't provide a naming template.
+const DefaultNamingTemplate = "{{reportName}}/{{date}}/{{reportName}}-{{time}}.{{extension}}"
+
+var unsafeKeyCharacters = regexp.MustCompile(`[^A-Za-z0-9._/=-]+`)
+
+// BuildOutputKey renders the naming template into the key of an archived output.
+// The template has access to reportName, params, date (YYYY-MM-DD), time (HHMMSS), timestamp and extension.
+func BuildOutputKey(namingTemplate string, reportName string, params map[string]any, extension string, now time.Time) (string, safego.Option[error]) {
+	if namingTemplate == "" {
+		namingTemplate = DefaultNamingTemplate
+	}
+
+	key, errOpt := utils.ParseHandleBars(namingTemplate, map[string]any{
+		"reportName": reportName,
+		"params":     params,
+		"date":       now.Format("2006-01-02"),
+		"time":       now.Format("150405"),
+		"timestamp":  now.UnixNano(),
+		"extension":  extension,
+	})
+	if errOpt.IsSome() {
+		return "", errOpt
+	}
+
+	// Keep the key portable across file systems and object storages.
+	key = unsafeKeyCharacters.ReplaceAllString(key, "_")
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if key == "" || key == "." {
+		return "", safego.Some(errors.New("the naming template produced an empty key"))
+	}
+
+	return key, safego.None[error]()
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"sort"
+	"time"
+)
+
+// ApplyRetention deletes the outputs that break the retention rules and returns the deleted keys.
+// Outputs older than MaxAgeDays are deleted first, then the oldest outputs beyond MaxOutputs.
+func ApplyRetention(storage Storage, rules types.RetentionConfig, now time.Time) ([]string, safego.Option[error]) {
+	deletedKeys := []string{}
+
+	if rules.MaxAgeDays <= 0 && rules.MaxOutputs <= 0 {
+		return deletedKeys, safego.None[error]()
+	}
+
+	outputs, errOpt := storage.List("")
+	if errOpt.IsSome() {
+		return deletedKeys, errOpt
+	}
+
+	// Newest first.
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].LastModified > outputs[j].LastModified
+	})
+
+	oldestAllowed := now.AddDate(0, 0, -rules.MaxAgeDays).UnixNano()
+	kept := 0
+	for _, output := range outputs {
+		tooOld := rules.MaxAgeDays > 0 && output.LastModified < oldestAllowed
+		tooMany := rules.MaxOutputs > 0 && kept >= rules.MaxOutputs
+
+		if !tooOld && !tooMany {
+			kept++
+			continue
+		}
+
+		errOpt = storage.Delete(output.Key)
+		if errOpt.IsSome() {
+			return deletedKeys, errOpt
+		}
+		deletedKeys = append(deletedKeys, output.Key)
+	}
+
+	return deletedKeys, safego.None[error]()
+}
@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage stores outputs in a bucket of an S3-compatible object storage.
+// Requests are signed with AWS Signature Version 4, which MinIO and most S3 clones support.
+type S3Storage struct {
+	config types.S3Config
+	client *http.Client
+}
+
+// NewS3Storage returns a new S3Storage instance.
+func NewS3Storage(config types.S3Config) *S3Storage {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	return &S3Storage{
+		config: config,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Put uploads the data as the object of the given key.
+func (self *S3Storage) Put(key string, data []byte, contentType string) safego.Option[error] {
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+
+	_, errOpt := self.do(http.MethodPut, self.objectKey(key), url.Values{}, headers, data)
+
+	return errOpt
+}
+
+// Get downloads the object of the given key. The error wraps fs.ErrNotExist if there is no such object.
+func (self *S3Storage) Get(key string) ([]byte, safego.Option[error]) {
+	return self.do(http.MethodGet, self.objectKey(key), url.Values{}, map[string]string{}, nil)
+}
+
+// List returns every object whose key starts with the given prefix.
+func (self *S3Storage) List(prefix string) ([]types.StoredOutput, safego.Option[error]) {
+	type listBucketResult struct {
+		Contents []struct {
+			Key          string `xml:"Key"`
+			Size         int64  `xml:"Size"`
+			LastModified string `xml:"LastModified"`
+		} `xml:"Contents"`
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+	}
+
+	outputs := []types.StoredOutput{}
+	continuationToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", self.objectKey(prefix))
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		body, errOpt := self.do(http.MethodGet, "", query, map[string]string{}, nil)
+		if errOpt.IsSome() {
+			return nil, errOpt
+		}
+
+		result := listBucketResult{}
+		err := xml.Unmarshal(body, &result)
+		if err != nil {
+			return nil, safego.Some(err)
+		}
+
+		for _, object := range result.Contents {
+			lastModified, err := time.Parse(time.RFC3339, object.LastModified)
+			if err != nil {
+				return nil, safego.Some(err)
+			}
+
+			outputs = append(outputs, types.StoredOutput{
+				Key:          strings.TrimPrefix(object.Key, self.objectKey("")),
+				Size:         object.Size,
+				LastModified: lastModified.UnixNano(),
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	return outputs, safego.None[error]()
+}
+
+// Delete removes the object of the given key.
+func (self *S3Storage) Delete(key string) safego.Option[error] {
+	_, errOpt := self.do(http.MethodDelete, self.objectKey(key), url.Values{}, map[string]string{}, nil)
+
+	return errOpt
+}
+
+// objectKey prepends the configured prefix to the key.
+func (self *S3Storage) objectKey(key string) string {
+	if self.config.Prefix == "" {
+		return key
+	}
+
+	return strings.TrimSuffix(self.config.Prefix, "/") + "/" + key
+}
+
+// do sends a signed request to the bucket and returns the response body.
+// Responses outside the 2xx range are returned as errors, wrapping fs.ErrNotExist for a 404.
+func (self *S3Storage) do(method string, objectKey string, query url.Values, headers map[string]string, payload []byte) ([]byte, safego.Option[error]) {
+	scheme := "http"
+	if self.config.UseSSL {
+		scheme = "https"
+	}
+
+	host := self.config.Endpoint
+	path := "/" + objectKey
+	if self.config.PathStyle {
+		path = "/" + self.config.Bucket + path
+	} else {
+		host = self.config.Bucket + "." + host
+	}
+	if objectKey == "" && !self.config.PathStyle {
+		path = "/"
+	}
+
+	canonicalPath := encodeURIPath(path)
+	canonicalQuery := encodeQuery(query)
+
+	requestUrl := scheme + "://" + host + canonicalPath
+	if canonicalQuery != "" {
+		requestUrl += "?" + canonicalQuery
+	}
+
+	request, err := http.NewRequest(method, requestUrl, bytes.NewReader(payload))
+	if err != nil {
+		return nil, safego.Some(err)
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	self.sign(request, host, canonicalPath, canonicalQuery, payload, time.Now().UTC())
+
+	response, err := self.client.Do(request)
+	if err != nil {
+		return nil, safego.Some(err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, safego.Some(err)
+	}
+
+	// Missing objects are reported like those of the local storage.
+	if response.StatusCode == http.StatusNotFound {
+		return nil, safego.Some(fmt.Errorf("s3 %s %s: %w", method, path, fs.ErrNotExist))
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, safego.Some(fmt.Errorf("s3 %s %s failed with status %d: %s", method, path, response.StatusCode, strings.TrimSpace(string(body))))
+	}
+
+	return body, safego.None[error]()
+}
+
+// sign adds the AWS Signature Version 4 headers to the request.
+func (self *S3Storage) sign(request *http.Request, host string, canonicalPath string, canonicalQuery string, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	request.Host = host
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaderNames := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	signedHeaderValues := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if contentType := request.Header.Get("Content-Type"); contentType != "" {
+		signedHeaderNames = append(signedHeaderNames, "content-type")
+		signedHeaderValues["content-type"] = contentType
+	}
+	sort.Strings(signedHeaderNames)
+
+	canonicalHeaders := ""
+	for _, name := range signedHeaderNames {
+		canonicalHeaders += name + ":" + strings.TrimSpace(signedHeaderValues[name]) + "\n"
+	}
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		canonicalPath,
+		canonicalQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := shortDate + "/" + self.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSha256([]byte("AWS4"+self.config.SecretAccessKey), shortDate)
+	signingKey = hmacSha256(signingKey, self.config.Region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		self.config.AccessKeyId, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// encodeURIPath percent-encodes every segment of a path the way SigV4 expects it.
+func encodeURIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = encodeURIComponent(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// encodeQuery builds the canonical (sorted and percent-encoded) query string.
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, encodeURIComponent(key)+"="+encodeURIComponent(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// encodeURIComponent percent-encodes everything but the unreserved characters of RFC 3986.
+func encodeURIComponent(str string) string {
+	builder := strings.Builder{}
+	for _, b := range []byte(str) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' {
+			builder.WriteByte(b)
+		} else {
+			builder.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"errors"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"path/filepath"
+)
+
+// NewStorageFromConfig builds the storage described by the config.
+// It returns a None storage if archiving is disabled.
+func NewStorageFromConfig(config types.StorageConfig, dataDir string) (safego.Option[Storage], safego.Option[error]) {
+	switch config.Backend {
+	case "":
+		return safego.None[Storage](), safego.None[error]()
+	case "local":
+		directory := config.Directory
+		if directory == "" {
+			directory = filepath.Join(dataDir, "outputs")
+		}
+
+		return safego.Some[Storage](NewLocalStorage(directory)), safego.None[error]()
+	case "s3":
+		if config.S3.Endpoint == "" || config.S3.Bucket == "" {
+			return safego.None[Storage](), safego.Some(errors.New("the s3 storage requires an endpoint and a bucket"))
+		}
+
+		return safego.Some[Storage](NewS3Storage(config.S3)), safego.None[error]()
+	}
+
+	return safego.None[Storage](), safego.Some(errors.New("unsupported storage backend: " + config.Backend))
+}
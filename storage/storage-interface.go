@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+)
+
+// Storage archives rendered outputs under slash-separated keys.
+type Storage interface {
+	Put(key string, data []byte, contentType string) safego.Option[error]
+	Get(key string) ([]byte, safego.Option[error])
+	List(prefix string) ([]types.StoredOutput, safego.Option[error])
+	Delete(key string) safego.Option[error]
+}
@@ -0,0 +1,14 @@
+package types
+
+// Asset is an image, font or stylesheet stored with a report, or globally when ReportName is empty.
+type Asset struct {
+	ReportName  string `json:"reportName"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	// Checksum is the hex encoded SHA-256 of the data.
+	Checksum  string `json:"checksum"`
+	Data      []byte `json:"-"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
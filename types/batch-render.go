@@ -0,0 +1,32 @@
+package types
+
+// BatchRender describes a batch render: a report rendered once per parameter set.
+type BatchRender struct {
+	ReportName string `json:"reportName"`
+	// Items are the parameter sets, one per rendered document.
+	Items []map[string]any `json:"items"`
+	// ItemsQuery is a query whose rows are the parameter sets, used when Items is empty. It can use the [P[...]] Params.
+	ItemsQuery string `json:"itemsQuery"`
+	// Params are given to every item. The parameters of an item take precedence.
+	Params map[string]any `json:"params"`
+	// Output is pdf, a single document with a bookmark per item, or zip, an archive with a file per item.
+	Output string `json:"output"`
+	// Format is the format of the files of a zip output: pdf (default), html or csv.
+	Format string `json:"format"`
+	// NameTemplate is a handlebars template of the file names and bookmark titles, rendered with the parameters of
+	// each item, e.g. statement-{{customer_id}}. Defaults to <report name>-<item number>.
+	NameTemplate string `json:"nameTemplate"`
+	// Concurrency is how many items are rendered at the same time. Defaults to 4.
+	Concurrency     int              `json:"concurrency"`
+	Locale          string           `json:"locale"`
+	PrintingOptions *PrintingOptions `json:"printingOptions"`
+}
+
+// BatchItemResult is the outcome of an item of a batch render.
+type BatchItemResult struct {
+	// Index is the position of the item in the batch, from 0.
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Error is empty when the item was rendered.
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,31 @@
+package types
+
+// QueryCacheStats counts the lookups of the query cache since the server started.
+type QueryCacheStats struct {
+	Backend string `json:"backend"`
+	// Entries is the number of cached results, expired ones included until they are evicted.
+	Entries  int     `json:"entries"`
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hitRatio"`
+	// Reports holds the lookups of the queries of every report.
+	Reports map[string]QueryCacheReportStats `json:"reports"`
+}
+
+// QueryCacheReportStats counts the lookups of the query cache for the queries of a report.
+type QueryCacheReportStats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hitRatio"`
+}
+
+// OutputCacheStats counts the lookups of the rendered document cache since the server started.
+type OutputCacheStats struct {
+	Entries int `json:"entries"`
+	// SizeBytes is the total size of the cached documents.
+	SizeBytes int64   `json:"sizeBytes"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	HitRatio  float64 `json:"hitRatio"`
+	Evictions int64   `json:"evictions"`
+}
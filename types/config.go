@@ -1,30 +1,214 @@
 package types
 
-import "github.com/okira-e/goreports/safego"
-
+// Config is the content of config.json. Every value can be overridden with an environment variable.
+// The values tagged secret:"true" are masked when the config is shown. For the maps, only the values of the keys that
+// look like credentials (password, token, sslkey...) are.
 type Config struct {
-	DbConfig DbConfig `json:"db_config"`
+	ServerConfig  ServerConfig  `json:"server_config"`
+	DbConfig      DbConfig      `json:"db_config"`
+	StorageConfig StorageConfig `json:"storage_config"`
+	WebhookConfig WebhookConfig `json:"webhook_config"`
+	AssetConfig   AssetConfig   `json:"asset_config"`
+	// QueryCacheConfig configures the cache of the query results. It is disabled by default.
+	QueryCacheConfig QueryCacheConfig `json:"query_cache_config"`
+	// OutputCacheConfig configures the cache of the rendered documents. It is disabled by default.
+	OutputCacheConfig OutputCacheConfig `json:"output_cache_config"`
+	// DefaultLocale is the locale the {{t}} translations of a report fall back to. Defaults to en.
+	DefaultLocale string `json:"default_locale"`
+}
+
+// ServerConfig configures how the server listens. The flags of `goreports start` override it.
+type ServerConfig struct {
+	// Host is the address the server listens on. Defaults to 0.0.0.0.
+	Host string `json:"host"`
+	// Port defaults to 3200.
+	Port int `json:"port"`
+	// TlsCertPath and TlsKeyPath are the PEM files of the certificate the server listens with over HTTPS. The server
+	// listens over HTTP when they're empty.
+	TlsCertPath string `json:"tls_cert_path"`
+	TlsKeyPath  string `json:"tls_key_path"`
+	// BasePath is the URL path prefix of every route, e.g. /goreports behind a reverse proxy.
+	BasePath string `json:"base_path"`
+	// BodyLimitBytes is the largest request body. Defaults to 4 MiB. The asset uploads are bounded by
+	// AssetConfig.MaxSizeBytes instead.
+	BodyLimitBytes int `json:"body_limit_bytes"`
+	// ReadTimeoutSeconds bounds the time it takes to read a request. 0 means no limit.
+	ReadTimeoutSeconds int `json:"read_timeout_seconds"`
+	// WriteTimeoutSeconds bounds the time it takes to write a response, renders included. 0 means no limit.
+	WriteTimeoutSeconds int `json:"write_timeout_seconds"`
+	// ShutdownTimeoutSeconds bounds the time the requests and the background renders in flight are waited for when the
+	// server shuts down, before they're canceled. Defaults to 25.
+	ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds"`
 }
 
 type DbConfig struct {
-	Dialect  string `json:"dialect"`
-	Host     string `json:"host"`
+	// Dialect is "postgres", "mysql", "mariadb" or "mssql".
+	Dialect string `json:"dialect"`
+	Host    string `json:"host"`
+	// Port defaults to the port of the dialect: 5432, 3306 or 1433.
 	Port     int    `json:"port"`
 	Username string `json:"username"`
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true"`
 	Database string `json:"database"`
+	// Schema is the search_path of the PostgreSQL connections, the schema the unqualified names are looked up in.
+	Schema string `json:"schema"`
+	// Charset is the character set of the MySQL and MariaDB connections.
+	Charset string `json:"charset"`
+	// ConnectTimeoutSeconds bounds the time it takes to connect. 0 keeps the default of the driver.
+	ConnectTimeoutSeconds int `json:"connect_timeout_seconds"`
+	// Tls configures the encryption of the connections.
+	Tls DbTlsConfig `json:"tls"`
+	// Options are the other parameters of the driver, added to the connection string.
+	Options map[string]string `json:"options" secret:"true"`
+	// Dsn is the connection string given as is to the driver of the dialect. The connection fields above are ignored
+	// when it is set.
+	Dsn string `json:"dsn" secret:"true"`
+	// Limits bound the report queries run against the database.
+	Limits QueryLimitsConfig `json:"limits"`
+	// AllowWrites runs the report queries outside of read-only transactions, for the trusted reports that write to the
+	// database.
+	AllowWrites bool `json:"allow_writes"`
+	// CheckStatements rejects the queries that aren't a single SELECT statement when the reports are saved and rendered.
+	CheckStatements bool `json:"check_statements"`
+	// Pool configures the connections kept open to the database.
+	Pool PoolConfig `json:"pool"`
+	// HealthCheck configures how the database is watched while the server runs.
+	HealthCheck HealthCheckConfig `json:"health_check"`
+}
+
+// DbTlsConfig configures the encryption of the connections to a database.
+type DbTlsConfig struct {
+	// Mode is one of:
+	//   - "disable": the connections aren't encrypted.
+	//   - "require": the connections are encrypted, the certificate of the server isn't verified.
+	//   - "verify-ca": the certificate of the server must be signed by the CA.
+	//   - "verify-full": the certificate of the server must also be issued for the host.
+	// Defaults to "disable" for PostgreSQL and to the default of the driver for the others.
+	Mode string `json:"mode"`
+	// CaCertPath is the PEM file of the CA verifying the certificate of the server. Defaults to the CAs of the system.
+	CaCertPath string `json:"ca_cert_path"`
+	// ClientCertPath and ClientKeyPath are the PEM files of the certificate authenticating GoReports, PostgreSQL and
+	// MySQL only.
+	ClientCertPath string `json:"client_cert_path"`
+	ClientKeyPath  string `json:"client_key_path"`
+}
+
+// PoolConfig configures the connections kept open to a database. The zero fields keep the defaults of database/sql.
+type PoolConfig struct {
+	// MaxOpenConns is the most connections open at once. 0 means no limit.
+	MaxOpenConns int `json:"max_open_conns"`
+	// MaxIdleConns is the most idle connections kept open. Defaults to 2, -1 keeps none.
+	MaxIdleConns int `json:"max_idle_conns"`
+	// ConnMaxLifetimeSeconds is how long a connection is reused before it is closed. 0 means for ever.
+	ConnMaxLifetimeSeconds int `json:"conn_max_lifetime_seconds"`
+	// ConnMaxIdleTimeSeconds is how long a connection stays idle before it is closed. 0 means for ever.
+	ConnMaxIdleTimeSeconds int `json:"conn_max_idle_time_seconds"`
+}
+
+// HealthCheckConfig configures the checks of a database.
+type HealthCheckConfig struct {
+	// IntervalSeconds is how often the database is pinged while it is up. Defaults to 30.
+	IntervalSeconds int `json:"interval_seconds"`
+	// TimeoutSeconds is how long a ping can take. Defaults to 5.
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxBackoffSeconds bounds the wait between the connection attempts while the database is down, which doubles from
+	// a second after every failed attempt. Defaults to 60.
+	MaxBackoffSeconds int `json:"max_backoff_seconds"`
+}
+
+// QueryLimitsConfig holds the limits of the report queries and their overrides per report.
+type QueryLimitsConfig struct {
+	QueryLimits
+	// Reports overrides the limits for the given reports. The zero fields keep the limits of the database.
+	Reports map[string]QueryLimits `json:"reports"`
+}
+
+// QueryLimits bound a report query.
+type QueryLimits struct {
+	// TimeoutSeconds is how long a query can run before it is canceled. Defaults to 60, -1 disables the timeout.
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxRows is the most rows a query can return, the render fails past it. 0 means no limit.
+	MaxRows int `json:"max_rows"`
+}
+
+// StorageConfig configures where rendered outputs are archived.
+// An empty Backend disables archiving.
+type StorageConfig struct {
+	// Backend is either "local" or "s3".
+	Backend string `json:"backend"`
+	// Directory is the root directory of the "local" backend.
+	Directory string `json:"directory"`
+	// S3 holds the settings of the "s3" backend.
+	S3 S3Config `json:"s3"`
+	// NamingTemplate is a handlebars template used to build the key of every archived output.
+	NamingTemplate string `json:"naming_template"`
+	// Retention holds the rules used to purge old outputs.
+	Retention RetentionConfig `json:"retention"`
+}
+
+// S3Config holds the settings of an S3-compatible object storage (AWS S3, MinIO...).
+type S3Config struct {
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"`
+	AccessKeyId     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key" secret:"true"`
+	UseSSL          bool   `json:"use_ssl"`
+	PathStyle       bool   `json:"path_style"`
+}
+
+// RetentionConfig holds the rules used to purge archived outputs.
+// A zero value disables the corresponding rule.
+type RetentionConfig struct {
+	MaxAgeDays int `json:"max_age_days"`
+	MaxOutputs int `json:"max_outputs"`
+}
+
+// WebhookConfig configures the delivery of render callbacks.
+type WebhookConfig struct {
+	// Secret is the HMAC-SHA256 key used to sign every payload.
+	Secret string `json:"secret" secret:"true"`
+	// MaxAttempts is the number of delivery attempts before giving up. Defaults to 5.
+	MaxAttempts int `json:"max_attempts"`
+	// InitialBackoffSeconds is the delay before the first retry. It doubles after each attempt. Defaults to 2.
+	InitialBackoffSeconds int `json:"initial_backoff_seconds"`
+	// TimeoutSeconds is the timeout of a single attempt. Defaults to 10.
+	TimeoutSeconds int `json:"timeout_seconds"`
 }
 
-// GetConnectionString returns the connection string for the database.
-// If the dialect is not supported, it returns an error.
-func (d *DbConfig) GetConnectionString() (string, safego.Option[string]) {
-	if d.Dialect == "mysql" || d.Dialect == "mariadb" {
-		return d.Username + ":" + d.Password + "@(" + d.Host + ":" + string(rune(d.Port)) + ")/" + d.Database, safego.None[string]()
-	} else if d.Dialect == "postgres" {
-		return "postgres://" + d.Username + ":" + d.Password + "@" + d.Host + "/" + d.Database + "?sslmode=disable", safego.None[string]()
-	} else if d.Dialect == "mssql" {
-		return "sqlserver://" + d.Username + ":" + d.Password + "@" + d.Host, safego.None[string]()
-	}
+// AssetConfig configures the images, fonts and stylesheets stored with the reports.
+type AssetConfig struct {
+	// MaxSizeBytes is the largest asset that can be uploaded. Defaults to 5 MiB.
+	MaxSizeBytes int64 `json:"max_size_bytes"`
+}
+
+// QueryCacheConfig configures the cache of the results of the report queries.
+// An empty Backend disables caching.
+type QueryCacheConfig struct {
+	// Backend is either "memory" or "sqlite".
+	Backend string `json:"backend"`
+	// TtlSeconds is how long a query result is reused. Defaults to 60.
+	TtlSeconds int `json:"ttl_seconds"`
+	// ReportTtlSeconds overrides TtlSeconds for the queries of the given reports. 0 disables caching for a report.
+	ReportTtlSeconds map[string]int `json:"report_ttl_seconds"`
+	// MaxEntries is how many results the "memory" backend holds before evicting the least recently used. Defaults to 1000.
+	MaxEntries int `json:"max_entries"`
+	// Path is the database file of the "sqlite" backend. Defaults to query-cache.db in the data directory.
+	Path string `json:"path"`
+}
 
-	return "", safego.Some[string]("Invalid dialect")
+// OutputCacheConfig configures the cache of the rendered documents.
+// It is disabled unless Enabled is set.
+type OutputCacheConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxSizeBytes bounds the total size of the cached documents, the least recently used are evicted past it.
+	// Defaults to 256 MiB.
+	MaxSizeBytes int64 `json:"max_size_bytes"`
+	// MaxDocumentSizeBytes is the largest document cached. Defaults to 16 MiB.
+	MaxDocumentSizeBytes int64 `json:"max_document_size_bytes"`
+	// TtlSeconds is how long a document is reused. Defaults to 300.
+	TtlSeconds int `json:"ttl_seconds"`
+	// ReportTtlSeconds overrides TtlSeconds for the given reports. 0 disables caching for a report.
+	ReportTtlSeconds map[string]int `json:"report_ttl_seconds"`
 }
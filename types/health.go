@@ -0,0 +1,32 @@
+package types
+
+// HealthStatus is the status of the server, of its data sources and of its renderer.
+type HealthStatus struct {
+	// Status is "up" when the data sources and the renderer are all up, else "down".
+	Status      string                      `json:"status"`
+	DataSources map[string]DataSourceHealth `json:"dataSources"`
+	Renderer    RendererHealth              `json:"renderer"`
+}
+
+// DataSourceHealth is the outcome of the last check of a data source and the state of its connection pool.
+type DataSourceHealth struct {
+	// Status is "up", "down", or "unknown" until the data source is first checked.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// CheckedAt is when the data source was last checked, in nanoseconds since the epoch.
+	CheckedAt int64 `json:"checkedAt"`
+	LatencyMs int64 `json:"latencyMs"`
+	// ConsecutiveFailures is how many checks failed in a row since the data source was last up.
+	ConsecutiveFailures int   `json:"consecutiveFailures"`
+	OpenConnections     int   `json:"openConnections"`
+	InUseConnections    int   `json:"inUseConnections"`
+	IdleConnections     int   `json:"idleConnections"`
+	WaitCount           int64 `json:"waitCount"`
+}
+
+// RendererHealth tells whether the PDF documents can be rendered.
+type RendererHealth struct {
+	// Status is "up" when wkhtmltopdf is found, else "down".
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,14 @@
+package types
+
+// ImportResult describes what importing a bundle did, or would do in a dry run, to a single report.
+type ImportResult struct {
+	Name string `json:"name"`
+	// Action is one of "create", "update", "skip" (exists and the mode is create-only), "unchanged" or "invalid" (the
+	// report would be created or updated, but its template has errors and it isn't written).
+	Action        string   `json:"action"`
+	ChangedFields []string `json:"changedFields,omitempty"`
+	// Diff is a unified-style line diff of the changed templates.
+	Diff string `json:"diff,omitempty"`
+	// Problems are those found validating a report that would be created or updated, the warnings included.
+	Problems []ValidationProblem `json:"problems,omitempty"`
+}
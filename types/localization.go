@@ -0,0 +1,8 @@
+package types
+
+// Localization is the locale a report is rendered in and the translations of its {{t}} keys in that locale.
+// The zero value renders in the default locale without translations.
+type Localization struct {
+	Locale       string
+	Translations map[string]string
+}
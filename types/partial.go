@@ -0,0 +1,11 @@
+package types
+
+// Partial is a template shared between reports. Templates include it with {{> name}}, or extend it as a layout with
+// {{#extends "name"}}.
+type Partial struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	Body        string `json:"body" validate:"required"`
+	CreatedAt   int64  `json:"createdAt"`
+	UpdatedAt   int64  `json:"updatedAt"`
+}
@@ -1,16 +1,16 @@
 package types
 
 type PrintingOptions struct {
-	PaperSize    string             `json:"paperSize"`
-	Landscape    bool               `json:"landscape"`
-	MarginTop    int                `json:"marginTop"`
-	MarginRight  int                `json:"marginRight"`
-	MarginBottom int                `json:"marginBottom"`
-	MarginLeft   int                `json:"marginLeft"`
-	PageNumbers  PageNumbersOptions `json:"pageNumbers"`
+	PaperSize    string             `json:"paperSize" yaml:"paperSize"`
+	Landscape    bool               `json:"landscape" yaml:"landscape"`
+	MarginTop    int                `json:"marginTop" yaml:"marginTop"`
+	MarginRight  int                `json:"marginRight" yaml:"marginRight"`
+	MarginBottom int                `json:"marginBottom" yaml:"marginBottom"`
+	MarginLeft   int                `json:"marginLeft" yaml:"marginLeft"`
+	PageNumbers  PageNumbersOptions `json:"pageNumbers" yaml:"pageNumbers"`
 }
 
 type PageNumbersOptions struct {
-	Enabled  bool   `json:"enabled"`
-	Position string `json:"position"`
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Position string `json:"position" yaml:"position"`
 }
@@ -9,4 +9,6 @@ type ReportAttributesForPdfGenerator struct {
 	Body   string
 	Header safego.Option[string]
 	Footer safego.Option[string]
+	// HtmlAttributes are added to the html element of the documents, e.g. the lang and dir of the locale.
+	HtmlAttributes string
 }
@@ -0,0 +1,8 @@
+package types
+
+// ReportTranslations holds the translations of the {{t}} keys of a report in a locale.
+type ReportTranslations struct {
+	ReportName   string            `json:"reportName"`
+	Locale       string            `json:"locale"`
+	Translations map[string]string `json:"translations"`
+}
@@ -10,18 +10,37 @@ type Report struct {
 	Body        string `json:"body" validate:"required"`
 	Header      string `json:"header"`
 	Footer      string `json:"footer"`
-	CreatedAt   int64  `json:"createdAt"`
-	UpdatedAt   int64  `json:"updatedAt"`
+	// PrintingOptions are used when a render request doesn't provide its own.
+	PrintingOptions *PrintingOptions `json:"printingOptions,omitempty"`
+	// Parameters describes the [P[...]] parameters of the report.
+	Parameters []ReportParameter `json:"parameters,omitempty"`
+	// ReadOnly is set on the reports loaded from a reports directory. They can only be changed by editing the files.
+	ReadOnly  bool  `json:"readOnly"`
+	CreatedAt int64 `json:"createdAt"`
+	UpdatedAt int64 `json:"updatedAt"`
+}
+
+// ReportParameter describes a parameter of a report.
+type ReportParameter struct {
+	Name string `json:"name" yaml:"name"`
+	// Type is informative: string, number, boolean or date.
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `json:"required,omitempty" yaml:"required,omitempty"`
+	// Default is used when a render request doesn't provide the parameter.
+	Default any `json:"default,omitempty" yaml:"default,omitempty"`
 }
 
 type ReportWithNullableFields struct {
-	ID          uint32         `json:"id"`
-	Name        sql.NullString `json:"name" validate:"required"`
-	Title       sql.NullString `json:"title" validate:"required"`
-	Description sql.NullString `json:"description"`
-	Body        sql.NullString `json:"body" validate:"required"`
-	Header      sql.NullString `json:"header"`
-	Footer      sql.NullString `json:"footer"`
-	CreatedAt   sql.NullInt64  `json:"createdAt"`
-	UpdatedAt   sql.NullInt64  `json:"updatedAt"`
+	ID              uint32         `json:"id"`
+	Name            sql.NullString `json:"name" validate:"required"`
+	Title           sql.NullString `json:"title" validate:"required"`
+	Description     sql.NullString `json:"description"`
+	Body            sql.NullString `json:"body" validate:"required"`
+	Header          sql.NullString `json:"header"`
+	Footer          sql.NullString `json:"footer"`
+	PrintingOptions sql.NullString `json:"printingOptions"`
+	Parameters      sql.NullString `json:"parameters"`
+	CreatedAt       sql.NullInt64  `json:"createdAt"`
+	UpdatedAt       sql.NullInt64  `json:"updatedAt"`
 }
@@ -0,0 +1,13 @@
+package types
+
+import "time"
+
+// ServerOptions holds the options given to `goreports start`.
+type ServerOptions struct {
+	// ReportsDir is a bundle directory the reports are loaded from. Empty to only use the internal database.
+	ReportsDir string
+	// ReportsDirPollInterval is how often the reports directory is checked for changes.
+	ReportsDirPollInterval time.Duration
+	// Server overrides the server config with the flags that were given. The zero fields keep the config.
+	Server ServerConfig
+}
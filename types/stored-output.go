@@ -0,0 +1,8 @@
+package types
+
+// StoredOutput describes a rendered document archived in the output storage.
+type StoredOutput struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	LastModified int64  `json:"lastModified"`
+}
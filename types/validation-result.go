@@ -0,0 +1,28 @@
+package types
+
+// The severities of the validation problems.
+const (
+	// ProblemSeverityError is the severity of the problems that make a report invalid.
+	ProblemSeverityError = "error"
+	// ProblemSeverityWarning is the severity of the problems that don't say anything about the report, e.g. the queries
+	// couldn't be checked because the database is unreachable.
+	ProblemSeverityWarning = "warning"
+)
+
+// ValidationResult describes the problems found in a report template.
+type ValidationResult struct {
+	// Valid is set when none of the problems is an error.
+	Valid bool `json:"valid"`
+	// Parameters lists the [P[...]] parameters of the template, in order of appearance.
+	Parameters []string            `json:"parameters"`
+	Problems   []ValidationProblem `json:"problems"`
+}
+
+// ValidationProblem is a single problem found in a report template.
+type ValidationProblem struct {
+	// Line and Column are 1-based. Column is 0 when only the line is known.
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
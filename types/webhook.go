@@ -0,0 +1,27 @@
+package types
+
+// WebhookPayload is the JSON body POSTed to a callback URL once a render completes or fails.
+type WebhookPayload struct {
+	RenderId   string `json:"renderId"`
+	ReportName string `json:"reportName"`
+	// Status is "succeeded", "failed" or "canceled".
+	Status     string `json:"status"`
+	OutputKey  string `json:"outputKey,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  int64  `json:"startedAt"`
+	FinishedAt int64  `json:"finishedAt"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// WebhookDelivery is a single delivery attempt of a webhook payload.
+type WebhookDelivery struct {
+	ID         uint32 `json:"id"`
+	RenderId   string `json:"renderId"`
+	Url        string `json:"url"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error"`
+	// Canceled is set when the attempt failed because the delivery was canceled.
+	Canceled  bool  `json:"canceled"`
+	CreatedAt int64 `json:"createdAt"`
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding the config.
+const EnvPrefix = "GOREPORTS_"
+
+// MaskedSecret replaces the secret values of a config that is shown.
+const MaskedSecret = "********"
+
+// ConfigOverride is a config value set by an environment variable.
+type ConfigOverride struct {
+	// Path is the JSON path of the value, e.g. db_config.password.
+	Path string
+	// Variable is the environment variable setting the value, the _FILE variant if the value was read from a file.
+	Variable string
+}
+
+// configVariable is a config value and the environment variable overriding it.
+type configVariable struct {
+	path     string
+	variable string
+	value    reflect.Value
+}
+
+// EnvVariableOf returns the environment variable overriding the value at the JSON path, e.g. GOREPORTS_DB_CONFIG_PASSWORD
+// for db_config.password.
+func EnvVariableOf(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// applyEnvOverrides sets the values of the config given by the environment. A value is read from the NAME variable, or
+// from the file named by NAME_FILE, like the secrets mounted by Docker and Kubernetes. Maps and slices are given as JSON.
+func applyEnvOverrides(config *types.Config, lookupEnv func(string) (string, bool)) ([]ConfigOverride, safego.Option[error]) {
+	overrides := []ConfigOverride{}
+
+	for _, variable := range configVariables(reflect.ValueOf(config).Elem(), "") {
+		value, found := lookupEnv(variable.variable)
+		fileName, fileFound := lookupEnv(variable.variable + "_FILE")
+		if found && fileFound {
+			return nil, safego.Some(fmt.Errorf("both %s and %s_FILE are set", variable.variable, variable.variable))
+		}
+
+		source := variable.variable
+		if fileFound {
+			content, err := os.ReadFile(fileName)
+			if err != nil {
+				return nil, safego.Some(fmt.Errorf("error while reading %s_FILE: %v", variable.variable, err))
+			}
+
+			value = strings.TrimRight(string(content), "\r\n")
+			source += "_FILE"
+		} else if !found {
+			continue
+		}
+
+		errOpt := setConfigValue(variable.value, value)
+		if errOpt.IsSome() {
+			return nil, safego.Some(fmt.Errorf("invalid %s: %v", source, errOpt.Unwrap()))
+		}
+
+		overrides = append(overrides, ConfigOverride{Path: variable.path, Variable: source})
+	}
+
+	return overrides, safego.None[error]()
+}
+
+// IsConfiguredByEnv reports whether the environment gives the database dialect, in which case the server runs without a
+// config file.
+func IsConfiguredByEnv() bool {
+	variable := EnvVariableOf("db_config.dialect")
+	_, found := os.LookupEnv(variable)
+	_, fileFound := os.LookupEnv(variable + "_FILE")
+
+	return found || fileFound
+}
+
+// MaskSecrets returns a copy of the config with its secret values replaced by MaskedSecret.
+func MaskSecrets(config types.Config) types.Config {
+	masked := config
+	maskSecrets(reflect.ValueOf(&masked).Elem())
+
+	return masked
+}
+
+func maskSecrets(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			maskSecrets(value.Field(i))
+		case field.Tag.Get("secret") == "true" && field.Type.Kind() == reflect.Map:
+			value.Field(i).Set(maskCredentialEntries(value.Field(i)))
+		case field.Tag.Get("secret") == "true" && value.Field(i).String() != "":
+			value.Field(i).SetString(MaskedSecret)
+		}
+	}
+}
+
+// credentialKeyParts are the parts of the map keys whose values are masked, e.g. password or sslkey.
+var credentialKeyParts = []string{"password", "passwd", "pwd", "secret", "token", "key"}
+
+// maskCredentialEntries returns a copy of a map of strings with the values of its credential-like keys masked. The map
+// is copied since the masked config shares it with the original.
+func maskCredentialEntries(value reflect.Value) reflect.Value {
+	if value.IsNil() {
+		return value
+	}
+
+	masked := reflect.MakeMapWithSize(value.Type(), value.Len())
+	iterator := value.MapRange()
+	for iterator.Next() {
+		entry := iterator.Value()
+
+		lowerKey := strings.ToLower(iterator.Key().String())
+		for _, part := range credentialKeyParts {
+			if strings.Contains(lowerKey, part) && entry.String() != "" {
+				entry = reflect.ValueOf(MaskedSecret).Convert(value.Type().Elem())
+				break
+			}
+		}
+
+		masked.SetMapIndex(iterator.Key(), entry)
+	}
+
+	return masked
+}
+
+// configVariables returns the values of the struct that can be overridden, the fields of the nested structs included.
+func configVariables(value reflect.Value, prefix string) []configVariable {
+	variables := []configVariable{}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		// The embedded structs share the JSON object of the struct embedding them.
+		if field.Anonymous {
+			variables = append(variables, configVariables(value.Field(i), prefix)...)
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			variables = append(variables, configVariables(value.Field(i), path+".")...)
+			continue
+		}
+
+		variables = append(variables, configVariable{path: path, variable: EnvVariableOf(path), value: value.Field(i)})
+	}
+
+	return variables
+}
+
+// setConfigValue parses the text into the value.
+func setConfigValue(value reflect.Value, text string) safego.Option[error] {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return safego.Some(err)
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return safego.Some(err)
+		}
+		value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return safego.Some(err)
+		}
+		value.SetFloat(parsed)
+	default:
+		err := json.Unmarshal([]byte(text), value.Addr().Interface())
+		if err != nil {
+			return safego.Some(err)
+		}
+	}
+
+	return safego.None[error]()
+}
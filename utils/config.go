@@ -9,25 +9,66 @@ import (
 	"runtime"
 )
 
-// GetConfigData extracts the database connection string from the config file.
+// ConfigPathEnv is the environment variable giving the path of the config file when --config isn't passed.
+const ConfigPathEnv = "GOREPORTS_CONFIG_PATH"
+
+// configFilePath is the path of the config file given with --config.
+var configFilePath string
+
+// SetConfigFilePath makes the config file the one at the given path instead of the one of the OS.
+func SetConfigFilePath(path string) {
+	configFilePath = path
+}
+
+// GetConfigData reads the config file and overrides its values with the environment variables.
 func GetConfigData() (types.Config, safego.Option[error]) {
+	config, _, errOpt := LoadConfig()
+
+	return config, errOpt
+}
+
+// LoadConfig reads the config and returns the values set by the environment variables. The values are, from the
+// lowest precedence to the highest:
+//   - the defaults.
+//   - the config file, which can be missing if the environment gives the database dialect.
+//   - the GOREPORTS_* environment variables and their _FILE variants.
+func LoadConfig() (types.Config, []ConfigOverride, safego.Option[error]) {
 	var config types.Config
 
 	filePath, errOpt := GetConfigFilePathBasedOnOS()
 	if errOpt.IsSome() {
-		return types.Config{}, errOpt
+		return types.Config{}, nil, errOpt
 	}
 
-	errOpt = ReadJSONFile(filePath, &config)
+	found, errOpt := DoesConfigFileExists()
 	if errOpt.IsSome() {
-		return types.Config{}, errOpt
+		return types.Config{}, nil, errOpt
+	}
+	if found || !IsConfiguredByEnv() {
+		errOpt = ReadJSONFile(filePath, &config)
+		if errOpt.IsSome() {
+			return types.Config{}, nil, errOpt
+		}
+	}
+
+	overrides, errOpt := applyEnvOverrides(&config, os.LookupEnv)
+	if errOpt.IsSome() {
+		return types.Config{}, nil, errOpt
 	}
 
-	return config, safego.None[error]()
+	return config, overrides, safego.None[error]()
 }
 
-// GetConfigFilePathBasedOnOS returns the config file path based on the OS.
+// GetConfigFilePathBasedOnOS returns the config file path given with --config, or else by GOREPORTS_CONFIG_PATH, or
+// else the one of the OS.
 func GetConfigFilePathBasedOnOS() (string, safego.Option[error]) {
+	if configFilePath != "" {
+		return configFilePath, safego.None[error]()
+	}
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path, safego.None[error]()
+	}
+
 	var osUserName string
 
 	if runtime.GOOS == "windows" {
@@ -113,7 +154,7 @@ func CreateConfigFile() safego.Option[error] {
 	}
 
 	// Create the directory.
-	dirPath := filePath[:len(filePath)-len("/config.json")]
+	dirPath := filepath.Dir(filePath)
 	err := os.MkdirAll(dirPath, os.ModePerm)
 	if err != nil {
 		return safego.Some(err)
@@ -0,0 +1,50 @@
+package utils
+
+import "strings"
+
+// DiffLines returns a line diff of two texts: unchanged lines are prefixed with "  ", removed lines with "- "
+// and added lines with "+ ". It returns an empty string if the texts are equal.
+func DiffLines(oldText string, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	oldLines := strings.Split(oldText, "\n")
+	newLines := strings.Split(newText, "\n")
+
+	// Longest common subsequence table, built from the end.
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	builder := strings.Builder{}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			builder.WriteString("  " + oldLines[i] + "\n")
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			builder.WriteString("- " + oldLines[i] + "\n")
+			i++
+		default:
+			builder.WriteString("+ " + newLines[j] + "\n")
+			j++
+		}
+	}
+
+	return builder.String()
+}
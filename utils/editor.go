@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"errors"
+	"github.com/okira-e/goreports/safego"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// EditInEditor opens the content in the user's editor ($VISUAL, then $EDITOR) and returns the saved content.
+// The extension of the temporary file (e.g. ".html") lets the editor pick the right syntax highlighting.
+func EditInEditor(content string, extension string) (string, safego.Option[error]) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vi"
+		}
+	}
+
+	file, err := os.CreateTemp("", "goreports-*"+extension)
+	if err != nil {
+		return "", safego.Some(err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(content)
+	if err != nil {
+		file.Close()
+		return "", safego.Some(err)
+	}
+	err = file.Close()
+	if err != nil {
+		return "", safego.Some(err)
+	}
+
+	// The editor may come with arguments, e.g. "code --wait".
+	editorFields := strings.Fields(editor)
+	if len(editorFields) == 0 {
+		return "", safego.Some(errors.New("no editor is configured, set the EDITOR environment variable"))
+	}
+
+	editorCmd := exec.Command(editorFields[0], append(editorFields[1:], file.Name())...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+
+	err = editorCmd.Run()
+	if err != nil {
+		return "", safego.Some(err)
+	}
+
+	editedContent, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", safego.Some(err)
+	}
+
+	return string(editedContent), safego.None[error]()
+}
@@ -2,12 +2,40 @@ package utils
 
 import (
 	"github.com/aymerick/raymond"
+	"github.com/okira-e/goreports/helpers"
 	"github.com/okira-e/goreports/safego"
 )
 
 // ParseHandleBars parses a handlebars template with the given template string and data.
+// The helpers of the helpers package are available in the template.
 func ParseHandleBars(template string, data map[string]any) (string, safego.Option[error]) {
-	result, err := raymond.Render(template, data)
+	return ParseHandleBarsWithPrivateData(template, data, nil)
+}
+
+// ParseHandleBarsWithPrivateData is ParseHandleBars with private data, available to the helpers and in the template
+// as @name, e.g. the locale of the render.
+func ParseHandleBarsWithPrivateData(template string, data map[string]any, privateData map[string]any) (string, safego.Option[error]) {
+	return ParseHandleBarsWithPartials(template, data, privateData, nil)
+}
+
+// ParseHandleBarsWithPartials is ParseHandleBarsWithPrivateData with partials, templates included with {{> name}} or
+// extended with {{#extends "name"}}, by name.
+func ParseHandleBarsWithPartials(template string, data map[string]any, privateData map[string]any, partials map[string]string) (string, safego.Option[error]) {
+	helpers.Register()
+
+	parsedTemplate, err := raymond.Parse(template)
+	if err != nil {
+		return "", safego.Some(err)
+	}
+	parsedTemplate.RegisterPartials(partials)
+
+	dataFrame := raymond.NewDataFrame()
+	for name, value := range privateData {
+		dataFrame.Set(name, value)
+	}
+	dataFrame.Set("partials", partials)
+
+	result, err := parsedTemplate.ExecWith(data, dataFrame)
 	if err != nil {
 		return "", safego.Some(err)
 	}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// GenerateId returns a random 128-bit identifier encoded in hex.
+func GenerateId() string {
+	bytes := make([]byte, 16)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return hex.EncodeToString(bytes)
+}
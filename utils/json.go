@@ -3,17 +3,32 @@ package utils
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"github.com/okira-e/goreports/safego"
 	"os"
 	"strconv"
 	"strings"
 )
 
+// ErrTooManyRows is returned by JsonifyQueryRows when the query returns more rows than allowed.
+var ErrTooManyRows = errors.New("the query returned too many rows")
+
 // JsonifyQueryData converts the data from a sql query to json.
 func JsonifyQueryData(rows *sql.Rows) []string {
+	data, errOpt := JsonifyQueryRows(rows, 0)
+	if errOpt.IsSome() {
+		panic(errOpt.Unwrap().Error())
+	}
+
+	return data
+}
+
+// JsonifyQueryRows converts the data from a sql query to json, like JsonifyQueryData. It fails with ErrTooManyRows
+// past maxRows rows, unless maxRows is 0, and with the error that stopped the iteration, e.g. the query being canceled.
+func JsonifyQueryRows(rows *sql.Rows, maxRows int) ([]string, safego.Option[error]) {
 	columns, err := rows.Columns()
 	if err != nil {
-		panic(err.Error())
+		return []string{}, safego.Some(err)
 	}
 
 	dest := make([]interface{}, len(columns))
@@ -28,13 +43,16 @@ func JsonifyQueryData(rows *sql.Rows) []string {
 	data := []string{}
 
 	for rows.Next() {
+		if maxRows > 0 && c == maxRows {
+			return []string{}, safego.Some(ErrTooManyRows)
+		}
 		if c > 0 {
 			data = append(data, ",")
 		}
 
 		err = rows.Scan(desRefs...)
 		if err != nil {
-			panic(err.Error())
+			return []string{}, safego.Some(err)
 		}
 
 		for i, value := range dest {
@@ -60,7 +78,12 @@ func JsonifyQueryData(rows *sql.Rows) []string {
 		c++
 	}
 
-	return data
+	err = rows.Err()
+	if err != nil {
+		return []string{}, safego.Some(err)
+	}
+
+	return data, safego.None[error]()
 }
 
 // ReadJSONFile reads a JSON file and unmarshals it into a value reference.
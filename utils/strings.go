@@ -16,3 +16,14 @@ func ExtractExpressions(str string, expr string) []string {
 
 	return result
 }
+
+// ContainsString reports whether the string is in the list.
+func ContainsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
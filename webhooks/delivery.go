@@ -0,0 +1,142 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/safego"
+	"github.com/okira-e/goreports/types"
+	"github.com/okira-e/goreports/utils"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed with "sha256=".
+const SignatureHeader = "X-GoReports-Signature"
+
+// ErrNoSecret is returned when a callback would be signed without a secret, which anyone could forge.
+var ErrNoSecret = errors.New("the webhook secret is not configured, set webhook_config.secret to render with a callback URL")
+
+// ValidateCallbackUrl checks that the callback URL is an absolute http(s) URL.
+func ValidateCallbackUrl(callbackUrl string) safego.Option[error] {
+	parsedUrl, err := url.Parse(callbackUrl)
+	if err != nil {
+		return safego.Some(err)
+	}
+
+	if (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		return safego.Some(errors.New("the callback URL must be an absolute http or https URL"))
+	}
+
+	return safego.None[error]()
+}
+
+// Sign returns the value of the signature header for the given body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver POSTs the payload to the callback URL, retrying with exponential backoff until it is
+// acknowledged with a 2xx response, the attempts run out or the context is done. Every attempt is logged in the
+// internal database, the one cut short by the context as canceled. Nothing is sent without a secret.
+func Deliver(ctx context.Context, internalDbConn *datasource.DataSource, config types.WebhookConfig, callbackUrl string, payload types.WebhookPayload) safego.Option[error] {
+	if config.Secret == "" {
+		return safego.Some(ErrNoSecret)
+	}
+
+	maxAttempts := config.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	backoff := time.Duration(config.InitialBackoffSeconds) * time.Second
+	if backoff <= 0 {
+		backoff = 2 * time.Second
+	}
+	timeout := time.Duration(config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return safego.Some(err)
+	}
+	signature := Sign(config.Secret, body)
+	client := &http.Client{Timeout: timeout}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		statusCode, errOpt := post(ctx, client, callbackUrl, body, signature)
+
+		delivery := types.WebhookDelivery{
+			RenderId:   payload.RenderId,
+			Url:        callbackUrl,
+			Attempt:    attempt,
+			StatusCode: statusCode,
+			CreatedAt:  utils.GetTimestamp(),
+		}
+		if errOpt.IsSome() {
+			lastErr = errOpt.Unwrap()
+			delivery.Error = lastErr.Error()
+			delivery.Canceled = ctx.Err() != nil
+		}
+
+		if logErrOpt := internalDb.InsertWebhookDelivery(internalDbConn, delivery); logErrOpt.IsSome() {
+			log.Printf("error while logging the webhook delivery of render %s: %v", payload.RenderId, logErrOpt.Unwrap())
+		}
+
+		if errOpt.IsNone() {
+			return safego.None[error]()
+		}
+		if delivery.Canceled {
+			return safego.Some(fmt.Errorf("the delivery to %s was canceled after %d attempt(s): %v", callbackUrl, attempt, ctx.Err()))
+		}
+
+		if attempt < maxAttempts {
+			// A context done while waiting fails the next attempt right away, which is logged as canceled.
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+
+	return safego.Some(fmt.Errorf("giving up on %s after %d attempts: %v", callbackUrl, maxAttempts, lastErr))
+}
+
+// post sends a single delivery attempt and returns the response status code.
+func post(ctx context.Context, client *http.Client, callbackUrl string, body []byte, signature string) (int, safego.Option[error]) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return 0, safego.Some(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, signature)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, safego.Some(err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, safego.Some(fmt.Errorf("unexpected status code %d", response.StatusCode))
+	}
+
+	return response.StatusCode, safego.None[error]()
+}
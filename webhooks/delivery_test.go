@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"context"
+	"github.com/okira-e/goreports/datasource"
+	"github.com/okira-e/goreports/internalDb"
+	"github.com/okira-e/goreports/types"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeliverCanceledWhileWaitingToRetry(t *testing.T) {
+	internalDbConn := newInternalDb(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Cancel once the first attempt failed, while Deliver waits to retry.
+		time.AfterFunc(50*time.Millisecond, cancel)
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	startedAt := time.Now()
+	errOpt := Deliver(ctx, &internalDbConn, types.WebhookConfig{Secret: "secret", MaxAttempts: 5, InitialBackoffSeconds: 10}, server.URL, types.WebhookPayload{RenderId: "render-1"})
+	if errOpt.IsNone() || !strings.Contains(errOpt.Unwrap().Error(), "was canceled after 2 attempt(s)") {
+		t.Fatalf("got %v, want the delivery to be canceled after 2 attempts", errOpt)
+	}
+	if elapsed := time.Since(startedAt); elapsed > 5*time.Second {
+		t.Errorf("took %s, want Deliver to return once canceled instead of waiting for the backoff", elapsed)
+	}
+
+	deliveries := listDeliveries(t, &internalDbConn, "render-1")
+	if len(deliveries) != 2 {
+		t.Fatalf("got %d deliveries, want 2", len(deliveries))
+	}
+	// The deliveries are listed from the latest.
+	if deliveries[1].StatusCode != http.StatusInternalServerError || deliveries[1].Canceled {
+		t.Errorf("got the first attempt %+v, want a 500 that isn't canceled", deliveries[1])
+	}
+	if deliveries[0].Attempt != 2 || !deliveries[0].Canceled || deliveries[0].StatusCode != 0 {
+		t.Errorf("got the second attempt %+v, want it canceled", deliveries[0])
+	}
+}
+
+func TestDeliverCanceledDuringAnAttempt(t *testing.T) {
+	internalDbConn := newInternalDb(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Hang until the client gives up.
+		time.AfterFunc(50*time.Millisecond, cancel)
+		select {
+		case <-request.Context().Done():
+		case <-released:
+		}
+	}))
+	defer server.Close()
+	defer close(released)
+
+	startedAt := time.Now()
+	errOpt := Deliver(ctx, &internalDbConn, types.WebhookConfig{Secret: "secret", MaxAttempts: 5, TimeoutSeconds: 30}, server.URL, types.WebhookPayload{RenderId: "render-2"})
+	if errOpt.IsNone() || !strings.Contains(errOpt.Unwrap().Error(), "was canceled after 1 attempt(s)") {
+		t.Fatalf("got %v, want the delivery to be canceled after 1 attempt", errOpt)
+	}
+	if elapsed := time.Since(startedAt); elapsed > 5*time.Second {
+		t.Errorf("took %s, want the attempt to be cut short once canceled", elapsed)
+	}
+
+	deliveries := listDeliveries(t, &internalDbConn, "render-2")
+	if len(deliveries) != 1 || !deliveries[0].Canceled || deliveries[0].Error == "" {
+		t.Fatalf("got %+v, want a single canceled attempt", deliveries)
+	}
+}
+
+func TestDeliverSucceeds(t *testing.T) {
+	internalDbConn := newInternalDb(t)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts++
+		if request.Header.Get(SignatureHeader) == "" {
+			t.Error("got no signature header")
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	errOpt := Deliver(context.Background(), &internalDbConn, types.WebhookConfig{Secret: "secret"}, server.URL, types.WebhookPayload{RenderId: "render-3"})
+	if errOpt.IsSome() {
+		t.Fatalf("unexpected error: %v", errOpt.Unwrap())
+	}
+
+	deliveries := listDeliveries(t, &internalDbConn, "render-3")
+	if attempts != 1 || len(deliveries) != 1 || deliveries[0].StatusCode != http.StatusNoContent || deliveries[0].Canceled {
+		t.Fatalf("got %d attempt(s) and %+v, want a single successful attempt", attempts, deliveries)
+	}
+}
+
+func TestDeliverWithoutSecret(t *testing.T) {
+	internalDbConn := newInternalDb(t)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		attempts++
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	errOpt := Deliver(context.Background(), &internalDbConn, types.WebhookConfig{}, server.URL, types.WebhookPayload{RenderId: "render-4"})
+	if errOpt.IsNone() || errOpt.Unwrap() != ErrNoSecret {
+		t.Fatalf("got %v, want %v", errOpt, ErrNoSecret)
+	}
+	if attempts != 0 {
+		t.Fatalf("got %d attempt(s), want the unsigned payload not to be sent", attempts)
+	}
+}
+
+// newInternalDb returns a migrated internal database in a temporary directory.
+func newInternalDb(t *testing.T) datasource.DataSource {
+	t.Helper()
+
+	var internalDbConn datasource.DataSource
+	internalDbConn = datasource.NewSqliteDb(filepath.Join(t.TempDir(), "internal.db"))
+
+	errOpt := internalDbConn.Connect()
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+	t.Cleanup(func() { internalDbConn.Disconnect() })
+
+	errOpt = internalDb.MigrateInternalDb(&internalDbConn)
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+
+	return internalDbConn
+}
+
+func listDeliveries(t *testing.T, internalDbConn *datasource.DataSource, renderId string) []types.WebhookDelivery {
+	t.Helper()
+
+	deliveries, errOpt := internalDb.ListWebhookDeliveries(internalDbConn, renderId)
+	if errOpt.IsSome() {
+		t.Fatal(errOpt.Unwrap())
+	}
+
+	return deliveries
+}